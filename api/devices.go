@@ -0,0 +1,32 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/Thivyesh/cameraServiceGo/devices"
+)
+
+// HandleListDevices handles requests to list local video devices
+// @Summary List video devices
+// @Description Enumerate local video capture devices with their formats and resolutions. A device's index or one of its stable links can be used as the URI of a webcam source.
+// @Tags devices
+// @Produce json
+// @Success 200 {array} types.VideoDevice
+// @Failure 500 "Devices could not be listed"
+// @Failure 501 "Device discovery is not supported on this platform"
+// @Router /devices [get]
+func (h *Handler) HandleListDevices(w http.ResponseWriter, r *http.Request) {
+	list, err := h.service.ListDevices()
+	if errors.Is(err, devices.ErrUnsupported) {
+		http.Error(w, err.Error(), http.StatusNotImplemented)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	json.NewEncoder(w).Encode(list)
+}
@@ -0,0 +1,113 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Thivyesh/cameraServiceGo/events"
+	"github.com/Thivyesh/cameraServiceGo/types"
+)
+
+// keepAliveInterval is how often an idle event stream receives a comment
+const keepAliveInterval = 15 * time.Second
+
+// HandleEvents streams service events using Server-Sent Events
+// @Summary Stream service events
+// @Description Receive source lifecycle, subscriber, error and analytics events as a Server-Sent Events stream
+// @Tags events
+// @Produce text/event-stream
+// @Param source query string false "Comma-separated source IDs to include"
+// @Param type query string false "Comma-separated event types to include, a trailing * matches a prefix"
+// @Param Last-Event-ID header string false "Resume after this event ID"
+// @Param last_event_id query int false "Resume after this event ID (for clients that cannot set headers)"
+// @Success 200 {object} types.Event
+// @Router /events [get]
+func (h *Handler) HandleEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "Streaming not supported", http.StatusInternalServerError)
+		return
+	}
+
+	// Build filter from query parameters
+	query := r.URL.Query()
+	filter := events.Filter{
+		Sources: splitList(query.Get("source")),
+		Types:   splitList(query.Get("type")),
+	}
+
+	// Resume from the last event the client saw, if any
+	lastID := r.Header.Get("Last-Event-ID")
+	if lastID == "" {
+		lastID = query.Get("last_event_id")
+	}
+	var after int64
+	if lastID != "" {
+		var err error
+		if after, err = strconv.ParseInt(lastID, 10, 64); err != nil {
+			http.Error(w, "Invalid Last-Event-ID", http.StatusBadRequest)
+			return
+		}
+	}
+
+	bus := h.service.Events()
+	replay, sub := bus.Subscribe(filter, after)
+	defer bus.Unsubscribe(sub)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+
+	for _, event := range replay {
+		if err := writeEvent(w, event); err != nil {
+			return
+		}
+	}
+	flusher.Flush()
+
+	keepAlive := time.NewTicker(keepAliveInterval)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case event := <-sub.C:
+			if err := writeEvent(w, event); err != nil {
+				return
+			}
+			flusher.Flush()
+		case <-keepAlive.C:
+			if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+// writeEvent writes a single event in SSE wire format
+func writeEvent(w http.ResponseWriter, event types.Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.ID, event.Type, data)
+	return err
+}
+
+// splitList splits a comma-separated query value, ignoring empty items
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
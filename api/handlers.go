@@ -3,8 +3,14 @@ package api
 import (
 	"encoding/json"
 	"net/http"
+	"strings"
+	"time"
 
+	"github.com/Thivyesh/cameraServiceGo/health"
+	"github.com/Thivyesh/cameraServiceGo/metrics"
+	"github.com/Thivyesh/cameraServiceGo/scheduler"
 	"github.com/Thivyesh/cameraServiceGo/service"
+	"github.com/Thivyesh/cameraServiceGo/timelapse"
 	"github.com/Thivyesh/cameraServiceGo/types"
 	"github.com/gorilla/mux"
 	"github.com/gorilla/websocket"
@@ -12,17 +18,20 @@ import (
 
 // Handler manages HTTP request handling
 type Handler struct {
-	service *service.CameraService
+	service    *service.CameraService
+	timelapses *timelapse.Manager
+	schedules  *scheduler.Scheduler
+	health     *health.Checker
 }
 
 // NewHandler creates a new HTTP handler instance
-func NewHandler(svc *service.CameraService) *Handler {
-	return &Handler{service: svc}
+func NewHandler(svc *service.CameraService, timelapses *timelapse.Manager, schedules *scheduler.Scheduler, checker *health.Checker) *Handler {
+	return &Handler{service: svc, timelapses: timelapses, schedules: schedules, health: checker}
 }
 
 // HandleAddSource handles requests to add a new video source
 // @Summary Add new video source
-// @Description Add a new camera or video source to the service
+// @Description Add a new camera or video source to the service. Push sources also return their ingest URL and token.
 // @Tags sources
 // @Accept json
 // @Produce json
@@ -45,10 +54,22 @@ func (h *Handler) HandleAddSource(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Return success response
-	json.NewEncoder(w).Encode(map[string]string{
+	response := map[string]string{
 		"source_id": sourceID,
 		"status":    "started",
-	})
+	}
+
+	// Push sources need to tell clients where and how to upload frames
+	if config.Type == "push" {
+		token, err := h.service.IngestToken(sourceID)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		response["ingest_url"] = ingestURL(r, sourceID)
+		response["ingest_token"] = token
+	}
+	json.NewEncoder(w).Encode(response)
 
 }
 
@@ -83,17 +104,88 @@ func (h *Handler) HandleRemoveSource(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
 }
 
+// HandlePauseSource handles requests to pause capturing from a source
+// @Summary Pause source
+// @Description Stop capturing and release the device while keeping the source and its subscribers
+// @Tags sources
+// @Param id path string true "Source ID"
+// @Success 200 "Source paused"
+// @Failure 409 "Source not found or already paused"
+// @Router /sources/{id}/pause [post]
+func (h *Handler) HandlePauseSource(w http.ResponseWriter, r *http.Request) {
+	if err := h.service.PauseSource(mux.Vars(r)["id"]); err != nil {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// HandleResumeSource handles requests to resume capturing from a source
+// @Summary Resume source
+// @Description Reopen the device of a paused source and continue streaming
+// @Tags sources
+// @Param id path string true "Source ID"
+// @Success 200 "Source resumed"
+// @Failure 409 "Source not found or not paused"
+// @Router /sources/{id}/resume [post]
+func (h *Handler) HandleResumeSource(w http.ResponseWriter, r *http.Request) {
+	if err := h.service.ResumeSource(mux.Vars(r)["id"]); err != nil {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// snapshotTimeout bounds how long a snapshot waits for the next frame
+const snapshotTimeout = 5 * time.Second
+
+// HandleSnapshot handles requests for a single processed frame
+// @Summary Get snapshot
+// @Description Get the next processed frame of a source as JPEG, with privacy zones and pipeline stages applied
+// @Tags stream
+// @Produce image/jpeg
+// @Param id path string true "Source ID"
+// @Success 200 {file} binary
+// @Router /sources/{id}/snapshot [get]
+func (h *Handler) HandleSnapshot(w http.ResponseWriter, r *http.Request) {
+	sourceID := mux.Vars(r)["id"]
+
+	frame, err := h.service.Snapshot(sourceID, snapshotTimeout)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+
+	w.Header().Set("Content-Type", "image/jpeg")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Write(frame.Data)
+}
+
 // HandleStreamFrames handles Websocket connections for frame streaming
 // @Summary Stream video frames
-// @Description Get real-time video frames via WebSocket
+// @Description Get real-time video frames via WebSocket. Frames are anonymized unless raw access is requested with the source's raw access token.
 // @Tags stream
 // @Param id path string true "Source ID"
+// @Param raw query bool false "Stream frames before anonymization"
+// @Param token query string false "Raw access token, alternatively sent as a Bearer token"
 // @Success 101 "Switching to WebSocket protocol"
+// @Failure 403 "Raw access denied"
 // @Router /sources/{id}/stream [get]
 func (h *Handler) HandleStreamFrames(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	sourceID := vars["id"]
 
+	// Raw frames require the source's access token
+	raw := r.URL.Query().Get("raw") == "true"
+	if raw {
+		if err := h.service.CheckRawAccess(sourceID, requestToken(r)); err != nil {
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		}
+	}
+
 	// Upgrade HTTP connection to Websocket
 	upgrader := websocket.Upgrader{
 		CheckOrigin: func(r *http.Request) bool {
@@ -115,10 +207,26 @@ func (h *Handler) HandleStreamFrames(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	defer h.service.Unsubscribe(sourceID, frames)
+
 	// Stream frames to client
 	for frame := range frames {
-		if err := conn.WriteMessage(websocket.BinaryMessage, frame.Data); err != nil {
+		data := frame.Data
+		if raw && frame.Raw != nil {
+			data = frame.Raw
+		}
+		if err := conn.WriteMessage(websocket.BinaryMessage, data); err != nil {
 			break
 		}
+		metrics.WebSocketBytes.WithLabelValues(sourceID).Add(float64(len(data)))
+	}
+}
+
+// requestToken extracts an access token from the Authorization header or the
+// token query parameter
+func requestToken(r *http.Request) string {
+	if token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+		return token
 	}
+	return r.URL.Query().Get("token")
 }
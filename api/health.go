@@ -0,0 +1,40 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+// HandleHealth handles requests for the health of the service and its sources
+// @Summary Get service health
+// @Description Get the overall status and readiness of the service and the health of every source. Sources are healthy while streaming frames no older than the configured age, while paused, or once a finite source has played all its frames. Critical sources, configured by camera name or source ID with CAMERA_CRITICAL_SOURCES, must be healthy for the service to be ready once they have been added; critical sources not added yet (pending) and other unhealthy sources degrade the status.
+// @Tags health
+// @Produce json
+// @Success 200 {object} types.HealthReport
+// @Failure 503 {object} types.HealthReport "Service not ready"
+// @Router /health [get]
+func (h *Handler) HandleHealth(w http.ResponseWriter, r *http.Request) {
+	report := h.health.Report()
+	w.Header().Set("Content-Type", "application/json")
+	if !report.Ready {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	json.NewEncoder(w).Encode(report)
+}
+
+// HandleLiveness answers liveness probes while the process serves HTTP
+func (h *Handler) HandleLiveness(w http.ResponseWriter, r *http.Request) {
+	w.Write([]byte("ok\n"))
+}
+
+// HandleReadiness answers readiness probes, failing with the reasons while
+// the service is starting or a critical source is unhealthy
+func (h *Handler) HandleReadiness(w http.ResponseWriter, r *http.Request) {
+	report := h.health.Report()
+	if !report.Ready {
+		http.Error(w, strings.Join(report.Problems, "\n"), http.StatusServiceUnavailable)
+		return
+	}
+	w.Write([]byte("ready\n"))
+}
@@ -0,0 +1,102 @@
+package api
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+
+	"github.com/gorilla/mux"
+	"github.com/gorilla/websocket"
+)
+
+// maxIngestFrameSize bounds a single uploaded frame
+const maxIngestFrameSize = 16 << 20
+
+// ingestURL returns the absolute upload URL of a push source
+func ingestURL(r *http.Request, sourceID string) string {
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	return fmt.Sprintf("%s://%s/api/sources/%s/ingest", scheme, r.Host, url.PathEscape(sourceID))
+}
+
+// HandleIngestFrame handles single frame uploads to a push source
+// @Summary Upload a frame
+// @Description Upload one JPEG frame to a push source. Frames are processed like captured frames; a frame arriving before the previous one was processed replaces it.
+// @Tags ingest
+// @Accept image/jpeg
+// @Param id path string true "Source ID"
+// @Param token query string false "Ingest token, alternatively sent as a Bearer token"
+// @Success 204 "Frame accepted"
+// @Failure 400 "Frame is not a JPEG image"
+// @Failure 403 "Ingest access denied"
+// @Router /sources/{id}/ingest [post]
+func (h *Handler) HandleIngestFrame(w http.ResponseWriter, r *http.Request) {
+	sourceID := mux.Vars(r)["id"]
+
+	if err := h.service.CheckIngestAccess(sourceID, requestToken(r)); err != nil {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+
+	data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxIngestFrameSize))
+	if err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.service.Ingest(sourceID, data); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// HandleIngestStream handles WebSocket frame uploads to a push source
+// @Summary Upload frames over WebSocket
+// @Description Upload JPEG frames to a push source as binary WebSocket messages. Rejected frames are reported as text messages.
+// @Tags ingest
+// @Param id path string true "Source ID"
+// @Param token query string false "Ingest token, alternatively sent as a Bearer token"
+// @Success 101 "Switching to WebSocket protocol"
+// @Failure 403 "Ingest access denied"
+// @Router /sources/{id}/ingest [get]
+func (h *Handler) HandleIngestStream(w http.ResponseWriter, r *http.Request) {
+	sourceID := mux.Vars(r)["id"]
+
+	if err := h.service.CheckIngestAccess(sourceID, requestToken(r)); err != nil {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+
+	upgrader := websocket.Upgrader{
+		CheckOrigin: func(r *http.Request) bool {
+			return true // Uploads are authorized by token
+		},
+	}
+
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		http.Error(w, "Could not upgrade connection", http.StatusInternalServerError)
+		return
+	}
+	defer conn.Close()
+	conn.SetReadLimit(maxIngestFrameSize)
+
+	for {
+		messageType, data, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		if messageType != websocket.BinaryMessage {
+			continue
+		}
+		if err := h.service.Ingest(sourceID, data); err != nil {
+			if conn.WriteMessage(websocket.TextMessage, []byte(err.Error())) != nil {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,220 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/Thivyesh/cameraServiceGo/media"
+	"github.com/Thivyesh/cameraServiceGo/types"
+	"github.com/gorilla/mux"
+)
+
+// mediaError writes a media library error with a matching status code
+func mediaError(w http.ResponseWriter, err error) {
+	var tooLarge *http.MaxBytesError
+	switch {
+	case errors.Is(err, media.ErrNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, media.ErrOffsetMismatch):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, media.ErrTooLarge), errors.As(err, &tooLarge):
+		http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+	default:
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	}
+}
+
+// HandleListMedia handles requests to list uploaded videos
+// @Summary List media
+// @Description Get the videos in the media library, most recent first
+// @Tags media
+// @Produce json
+// @Success 200 {array} types.MediaItem
+// @Router /media [get]
+func (h *Handler) HandleListMedia(w http.ResponseWriter, r *http.Request) {
+	json.NewEncoder(w).Encode(h.service.Media().List())
+}
+
+// HandleGetMedia handles requests for a single uploaded video
+// @Summary Get media
+// @Description Get the stream properties of an uploaded video
+// @Tags media
+// @Produce json
+// @Param id path string true "Media ID"
+// @Success 200 {object} types.MediaItem
+// @Failure 404 "Media not found"
+// @Router /media/{id} [get]
+func (h *Handler) HandleGetMedia(w http.ResponseWriter, r *http.Request) {
+	item, err := h.service.Media().Get(mux.Vars(r)["id"])
+	if err != nil {
+		mediaError(w, err)
+		return
+	}
+
+	json.NewEncoder(w).Encode(item)
+}
+
+// HandleUploadMedia handles single request video uploads
+// @Summary Upload media
+// @Description Upload a video as the file field of a multipart form. The video is probed and rejected if it cannot be played. Large files can be uploaded resumably through /media/uploads.
+// @Tags media
+// @Accept multipart/form-data
+// @Produce json
+// @Param file formData file true "Video file"
+// @Success 201 {object} types.MediaItem
+// @Failure 400 "Missing or unplayable video"
+// @Failure 413 "Video exceeds the maximum size"
+// @Router /media [post]
+func (h *Handler) HandleUploadMedia(w http.ResponseWriter, r *http.Request) {
+	reader, err := r.MultipartReader()
+	if err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	// Stream the file part to disk instead of buffering the form
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			http.Error(w, "Missing file field", http.StatusBadRequest)
+			return
+		}
+		if err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+		if part.FormName() != "file" {
+			part.Close()
+			continue
+		}
+
+		library := h.service.Media()
+		item, err := library.Add(part.FileName(), http.MaxBytesReader(w, part, library.MaxSize()))
+		part.Close()
+		if err != nil {
+			mediaError(w, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(item)
+		return
+	}
+}
+
+// HandleDeleteMedia handles requests to remove an uploaded video
+// @Summary Delete media
+// @Description Remove a video from the media library. Videos played by a source cannot be removed.
+// @Tags media
+// @Param id path string true "Media ID"
+// @Success 200 "Media removed"
+// @Failure 404 "Media not found"
+// @Failure 409 "Media is in use"
+// @Router /media/{id} [delete]
+func (h *Handler) HandleDeleteMedia(w http.ResponseWriter, r *http.Request) {
+	if err := h.service.DeleteMedia(mux.Vars(r)["id"]); err != nil {
+		if errors.Is(err, media.ErrNotFound) {
+			mediaError(w, err)
+		} else {
+			http.Error(w, err.Error(), http.StatusConflict)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// HandleCreateUpload handles requests to start a resumable upload
+// @Summary Start resumable upload
+// @Description Announce a video upload. Its chunks are then sent with PATCH requests, each starting at the offset reported by the previous one.
+// @Tags media
+// @Accept json
+// @Produce json
+// @Param upload body types.UploadRequest true "File name and size"
+// @Success 201 {object} types.UploadInfo
+// @Failure 413 "Video exceeds the maximum size"
+// @Router /media/uploads [post]
+func (h *Handler) HandleCreateUpload(w http.ResponseWriter, r *http.Request) {
+	var req types.UploadRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	info, err := h.service.Media().CreateUpload(req.Filename, req.Size)
+	if err != nil {
+		mediaError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(info)
+}
+
+// HandleGetUpload handles requests for the progress of an upload
+// @Summary Get upload progress
+// @Description Get the offset an interrupted upload resumes from
+// @Tags media
+// @Produce json
+// @Param uploadId path string true "Upload ID"
+// @Success 200 {object} types.UploadInfo
+// @Failure 404 "Upload not found"
+// @Router /media/uploads/{uploadId} [get]
+func (h *Handler) HandleGetUpload(w http.ResponseWriter, r *http.Request) {
+	info, err := h.service.Media().GetUpload(mux.Vars(r)["uploadId"])
+	if err != nil {
+		mediaError(w, err)
+		return
+	}
+
+	json.NewEncoder(w).Encode(info)
+}
+
+// HandleWriteUpload handles upload chunks
+// @Summary Upload chunk
+// @Description Append a chunk to a resumable upload. The Upload-Offset header must match the current offset. After the last chunk the response carries the media ID of the new video.
+// @Tags media
+// @Accept application/octet-stream
+// @Produce json
+// @Param uploadId path string true "Upload ID"
+// @Param Upload-Offset header int true "Offset of the chunk in the file"
+// @Success 200 {object} types.UploadInfo
+// @Failure 404 "Upload not found"
+// @Failure 409 "Offset does not match the upload"
+// @Router /media/uploads/{uploadId} [patch]
+func (h *Handler) HandleWriteUpload(w http.ResponseWriter, r *http.Request) {
+	offset, err := strconv.ParseInt(r.Header.Get("Upload-Offset"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid Upload-Offset header", http.StatusBadRequest)
+		return
+	}
+
+	info, err := h.service.Media().WriteUpload(mux.Vars(r)["uploadId"], offset, r.Body)
+	if err != nil {
+		mediaError(w, err)
+		return
+	}
+
+	w.Header().Set("Upload-Offset", strconv.FormatInt(info.Offset, 10))
+	json.NewEncoder(w).Encode(info)
+}
+
+// HandleCancelUpload handles requests to abandon an upload
+// @Summary Cancel upload
+// @Description Discard an unfinished upload and the data received so far
+// @Tags media
+// @Param uploadId path string true "Upload ID"
+// @Success 200 "Upload cancelled"
+// @Failure 404 "Upload not found"
+// @Router /media/uploads/{uploadId} [delete]
+func (h *Handler) HandleCancelUpload(w http.ResponseWriter, r *http.Request) {
+	if err := h.service.Media().CancelUpload(mux.Vars(r)["uploadId"]); err != nil {
+		mediaError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
@@ -0,0 +1,142 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Thivyesh/cameraServiceGo/types"
+	"github.com/gorilla/mux"
+)
+
+// HandleGetPipeline handles requests for a source's processing pipeline
+// @Summary Get source pipeline
+// @Description Get the frame processing stages of a source with per-stage timings
+// @Tags pipeline
+// @Produce json
+// @Param id path string true "Source ID"
+// @Success 200 {object} types.PipelineInfo
+// @Router /sources/{id}/pipeline [get]
+func (h *Handler) HandleGetPipeline(w http.ResponseWriter, r *http.Request) {
+	sourceID := mux.Vars(r)["id"]
+
+	info, err := h.service.GetPipeline(sourceID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	json.NewEncoder(w).Encode(info)
+}
+
+// HandleSetPipeline handles requests to replace a source's processing pipeline
+// @Summary Replace source pipeline
+// @Description Replace the ordered frame processing stages of a running source
+// @Tags pipeline
+// @Accept json
+// @Produce json
+// @Param id path string true "Source ID"
+// @Param stages body []types.StageConfig true "Ordered stage configuration"
+// @Success 200 {object} types.PipelineInfo
+// @Router /sources/{id}/pipeline [put]
+func (h *Handler) HandleSetPipeline(w http.ResponseWriter, r *http.Request) {
+	sourceID := mux.Vars(r)["id"]
+
+	var stages []types.StageConfig
+	if err := json.NewDecoder(r.Body).Decode(&stages); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.service.SetPipeline(sourceID, stages); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	info, err := h.service.GetPipeline(sourceID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	json.NewEncoder(w).Encode(info)
+}
+
+// HandleGetFrameInfo handles requests for the latest frame metadata
+// @Summary Get latest frame metadata
+// @Description Get metadata of the latest frame of a source, such as detections attached by analytics stages
+// @Tags pipeline
+// @Produce json
+// @Param id path string true "Source ID"
+// @Success 200 {object} types.FrameInfo
+// @Router /sources/{id}/metadata [get]
+func (h *Handler) HandleGetFrameInfo(w http.ResponseWriter, r *http.Request) {
+	sourceID := mux.Vars(r)["id"]
+
+	info, err := h.service.GetFrameInfo(sourceID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	json.NewEncoder(w).Encode(info)
+}
+
+// HandleListCodes handles requests for a source's decoded QR codes
+// @Summary List decoded codes
+// @Description Get QR codes decoded by the qrcode stage of a source, newest first. Repeated reads within the stage's window are reported once.
+// @Tags analytics
+// @Produce json
+// @Param id path string true "Source ID"
+// @Param since query string false "Only codes read after this RFC 3339 time"
+// @Param limit query int false "Maximum number of codes to return"
+// @Success 200 {array} types.CodeRead
+// @Router /sources/{id}/codes [get]
+func (h *Handler) HandleListCodes(w http.ResponseWriter, r *http.Request) {
+	sourceID := mux.Vars(r)["id"]
+	query := r.URL.Query()
+
+	var since time.Time
+	if value := query.Get("since"); value != "" {
+		var err error
+		if since, err = time.Parse(time.RFC3339, value); err != nil {
+			http.Error(w, "Invalid since parameter", http.StatusBadRequest)
+			return
+		}
+	}
+
+	limit := 0
+	if value := query.Get("limit"); value != "" {
+		var err error
+		if limit, err = strconv.Atoi(value); err != nil || limit < 0 {
+			http.Error(w, "Invalid limit parameter", http.StatusBadRequest)
+			return
+		}
+	}
+
+	codes, err := h.service.GetCodes(sourceID, since, limit)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	json.NewEncoder(w).Encode(codes)
+}
+
+// HandleResetHealthReference handles requests to reset the tamper reference
+// @Summary Reset tamper reference
+// @Description Use the next analysed frame as the reference scene for tamper detection, e.g. after a camera was intentionally moved
+// @Tags analytics
+// @Param id path string true "Source ID"
+// @Success 202 "Reference will be captured from the next analysed frame"
+// @Router /sources/{id}/health/reference [post]
+func (h *Handler) HandleResetHealthReference(w http.ResponseWriter, r *http.Request) {
+	sourceID := mux.Vars(r)["id"]
+
+	if err := h.service.ResetHealthReference(sourceID); err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
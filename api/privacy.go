@@ -0,0 +1,105 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/Thivyesh/cameraServiceGo/types"
+	"github.com/gorilla/mux"
+)
+
+// HandleListPrivacyZones handles requests to list a source's privacy zones
+// @Summary List privacy zones
+// @Description Get the privacy masking zones of a source
+// @Tags privacy
+// @Produce json
+// @Param id path string true "Source ID"
+// @Success 200 {array} types.PrivacyZone
+// @Router /sources/{id}/privacy-zones [get]
+func (h *Handler) HandleListPrivacyZones(w http.ResponseWriter, r *http.Request) {
+	sourceID := mux.Vars(r)["id"]
+
+	zones, err := h.service.GetPrivacyZones(sourceID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	json.NewEncoder(w).Encode(zones)
+}
+
+// HandleSetPrivacyZones handles requests to replace a source's privacy zones
+// @Summary Replace privacy zones
+// @Description Replace all privacy masking zones of a source. Changes apply from the next frame.
+// @Tags privacy
+// @Accept json
+// @Produce json
+// @Param id path string true "Source ID"
+// @Param zones body []types.PrivacyZone true "Privacy zones"
+// @Success 200 {array} types.PrivacyZone
+// @Router /sources/{id}/privacy-zones [put]
+func (h *Handler) HandleSetPrivacyZones(w http.ResponseWriter, r *http.Request) {
+	sourceID := mux.Vars(r)["id"]
+
+	var zones []types.PrivacyZone
+	if err := json.NewDecoder(r.Body).Decode(&zones); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	zones, err := h.service.SetPrivacyZones(sourceID, zones)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	json.NewEncoder(w).Encode(zones)
+}
+
+// HandleAddPrivacyZone handles requests to add a privacy zone
+// @Summary Add privacy zone
+// @Description Add a privacy masking zone to a source. The zone applies from the next frame.
+// @Tags privacy
+// @Accept json
+// @Produce json
+// @Param id path string true "Source ID"
+// @Param zone body types.PrivacyZone true "Privacy zone"
+// @Success 201 {object} types.PrivacyZone
+// @Router /sources/{id}/privacy-zones [post]
+func (h *Handler) HandleAddPrivacyZone(w http.ResponseWriter, r *http.Request) {
+	sourceID := mux.Vars(r)["id"]
+
+	var zone types.PrivacyZone
+	if err := json.NewDecoder(r.Body).Decode(&zone); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	zone, err := h.service.AddPrivacyZone(sourceID, zone)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(zone)
+}
+
+// HandleRemovePrivacyZone handles requests to remove a privacy zone
+// @Summary Remove privacy zone
+// @Description Remove a privacy masking zone from a source
+// @Tags privacy
+// @Param id path string true "Source ID"
+// @Param zoneId path string true "Zone ID"
+// @Success 200 "Zone removed successfully"
+// @Router /sources/{id}/privacy-zones/{zoneId} [delete]
+func (h *Handler) HandleRemovePrivacyZone(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	if err := h.service.RemovePrivacyZone(vars["id"], vars["zoneId"]); err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
@@ -0,0 +1,399 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/Thivyesh/cameraServiceGo/metrics"
+	"github.com/Thivyesh/cameraServiceGo/recording"
+	"github.com/Thivyesh/cameraServiceGo/types"
+	"github.com/gorilla/mux"
+	"github.com/gorilla/websocket"
+)
+
+// exportContentTypes maps export formats to download content types
+var exportContentTypes = map[string]string{
+	"avi":   "video/x-msvideo",
+	"zip":   "application/zip",
+	"mjpeg": "video/x-motion-jpeg",
+}
+
+// unsafeFilenameChars are replaced in suggested download file names
+var unsafeFilenameChars = regexp.MustCompile(`[^A-Za-z0-9_.-]+`)
+
+// exportError writes an export error with a matching status code
+func exportError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, recording.ErrNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, recording.ErrNotReady):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// HandleStartRecording handles requests to record a source to disk
+// @Summary Start recording
+// @Description Record the processed frames of a source to disk so they can be exported later
+// @Tags recording
+// @Param id path string true "Source ID"
+// @Success 200 "Recording started"
+// @Failure 409 "Source is already being recorded"
+// @Router /sources/{id}/recording [post]
+func (h *Handler) HandleStartRecording(w http.ResponseWriter, r *http.Request) {
+	if err := h.service.StartRecording(mux.Vars(r)["id"]); err != nil {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// HandleStopRecording handles requests to stop recording a source
+// @Summary Stop recording
+// @Description Stop recording a source. Footage recorded so far is kept.
+// @Tags recording
+// @Param id path string true "Source ID"
+// @Success 200 "Recording stopped"
+// @Failure 409 "Source is not being recorded"
+// @Router /sources/{id}/recording [delete]
+func (h *Handler) HandleStopRecording(w http.ResponseWriter, r *http.Request) {
+	if err := h.service.StopRecording(mux.Vars(r)["id"]); err != nil {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// HandleExport handles requests to export a clip of a source
+// @Summary Export clip
+// @Description Start assembling the frames of a source captured in a time range into a file. Frames come from recordings and from the in-memory pre-roll buffer kept for every source. The export runs in the background; poll the returned job and download it once done. AVI exports play at the average frame rate of the range.
+// @Tags recording
+// @Produce json
+// @Param id path string true "Source ID"
+// @Param from query string true "Start of the range (RFC 3339)"
+// @Param to query string false "End of the range (RFC 3339), defaults to now"
+// @Param format query string false "Output format: avi, zip of JPEG frames or mjpeg" default(avi)
+// @Param osd query bool false "Burn the capture time and camera name into the frames"
+// @Param osd_template query string false "OSD text template, see the osd pipeline stage"
+// @Success 202 {object} types.ExportJob
+// @Failure 400 "Invalid parameters"
+// @Router /sources/{id}/export [get]
+func (h *Handler) HandleExport(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	opts := recording.ExportOptions{
+		SourceID: mux.Vars(r)["id"],
+		To:       time.Now(),
+		Format:   query.Get("format"),
+	}
+	if opts.Format == "" {
+		opts.Format = "avi"
+	}
+
+	var err error
+	if opts.From, err = time.Parse(time.RFC3339, query.Get("from")); err != nil {
+		http.Error(w, "Invalid from parameter", http.StatusBadRequest)
+		return
+	}
+	if value := query.Get("to"); value != "" {
+		if opts.To, err = time.Parse(time.RFC3339, value); err != nil {
+			http.Error(w, "Invalid to parameter", http.StatusBadRequest)
+			return
+		}
+	}
+
+	osd := query.Get("osd_template") != ""
+	if value := query.Get("osd"); value != "" {
+		if osd, err = strconv.ParseBool(value); err != nil {
+			http.Error(w, "Invalid osd parameter", http.StatusBadRequest)
+			return
+		}
+	}
+	if osd {
+		stage := types.StageConfig{Type: "osd"}
+		if template := query.Get("osd_template"); template != "" {
+			stage.Options, _ = json.Marshal(map[string]string{"template": template})
+		}
+		opts.Stages = []types.StageConfig{stage}
+	}
+
+	job, err := h.service.Export(opts)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Location", "/api/exports/"+job.ID)
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(job)
+}
+
+// HandleListExports handles requests to list clip exports
+// @Summary List exports
+// @Description Get running and finished clip exports, most recent first. Finished exports are kept for 24 hours.
+// @Tags recording
+// @Produce json
+// @Success 200 {array} types.ExportJob
+// @Router /exports [get]
+func (h *Handler) HandleListExports(w http.ResponseWriter, r *http.Request) {
+	json.NewEncoder(w).Encode(h.service.Exports().List())
+}
+
+// HandleGetExport handles requests for the progress of a clip export
+// @Summary Get export
+// @Description Get the status and progress of a clip export
+// @Tags recording
+// @Produce json
+// @Param exportId path string true "Export ID"
+// @Success 200 {object} types.ExportJob
+// @Failure 404 "Export not found"
+// @Router /exports/{exportId} [get]
+func (h *Handler) HandleGetExport(w http.ResponseWriter, r *http.Request) {
+	job, err := h.service.Exports().Get(mux.Vars(r)["exportId"])
+	if err != nil {
+		exportError(w, err)
+		return
+	}
+
+	json.NewEncoder(w).Encode(job)
+}
+
+// HandleDownloadExport handles requests for the file of a finished export
+// @Summary Download export
+// @Description Download the file of a finished clip export
+// @Tags recording
+// @Produce octet-stream
+// @Param exportId path string true "Export ID"
+// @Success 200 {file} binary
+// @Failure 404 "Export not found"
+// @Failure 409 "Export has not finished"
+// @Router /exports/{exportId}/download [get]
+func (h *Handler) HandleDownloadExport(w http.ResponseWriter, r *http.Request) {
+	file, job, err := h.service.Exports().Open(mux.Vars(r)["exportId"])
+	if err != nil {
+		exportError(w, err)
+		return
+	}
+	defer file.Close()
+
+	name := fmt.Sprintf("%s_%s.%s",
+		unsafeFilenameChars.ReplaceAllString(job.Source, "_"),
+		job.From.UTC().Format("20060102T150405Z"), job.Format)
+	w.Header().Set("Content-Type", exportContentTypes[job.Format])
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", name))
+	http.ServeContent(w, r, name, *job.FinishedAt, file)
+}
+
+// HandleDeleteExport handles requests to cancel or remove a clip export
+// @Summary Delete export
+// @Description Cancel a running clip export or remove a finished one
+// @Tags recording
+// @Param exportId path string true "Export ID"
+// @Success 200 "Export removed"
+// @Failure 404 "Export not found"
+// @Router /exports/{exportId} [delete]
+func (h *Handler) HandleDeleteExport(w http.ResponseWriter, r *http.Request) {
+	if err := h.service.Exports().Delete(mux.Vars(r)["exportId"]); err != nil {
+		exportError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// defaultTimelineSpan is the time range of a timeline without from parameter
+const defaultTimelineSpan = 24 * time.Hour
+
+// timeParam parses an optional RFC 3339 query parameter
+func timeParam(r *http.Request, name string, fallback time.Time) (time.Time, error) {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return fallback, nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
+
+// HandleTimeline handles requests for the recorded footage of a source
+// @Summary Get timeline
+// @Description Get the periods of a time range covered by footage of a source, the gaps between them and event markers such as detections and tamper alerts, e.g. to render a scrub bar. Footage includes recordings and the pre-roll buffer; breaks shorter than 5 seconds are not reported as gaps. Up to 5000 events are returned.
+// @Tags recording
+// @Produce json
+// @Param id path string true "Source ID"
+// @Param from query string false "Start of the range (RFC 3339), defaults to 24 hours before to"
+// @Param to query string false "End of the range (RFC 3339), defaults to now"
+// @Success 200 {object} types.Timeline
+// @Failure 400 "Invalid parameters"
+// @Router /sources/{id}/timeline [get]
+func (h *Handler) HandleTimeline(w http.ResponseWriter, r *http.Request) {
+	to, err := timeParam(r, "to", time.Now())
+	if err != nil {
+		http.Error(w, "Invalid to parameter", http.StatusBadRequest)
+		return
+	}
+	from, err := timeParam(r, "from", to.Add(-defaultTimelineSpan))
+	if err != nil {
+		http.Error(w, "Invalid from parameter", http.StatusBadRequest)
+		return
+	}
+	if !from.Before(to) {
+		http.Error(w, "Time range must end after it starts", http.StatusBadRequest)
+		return
+	}
+
+	timeline, err := h.service.Recordings().Timeline(mux.Vars(r)["id"], from, to)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	json.NewEncoder(w).Encode(timeline)
+}
+
+// HandlePlayback handles requests to play back the footage of a source
+// @Summary Play back footage
+// @Description Stream the footage of a source from a point in time at the pace it was captured. WebSocket requests receive every frame as a binary JPEG message like /sources/{id}/stream, other requests receive an MJPEG stream (multipart/x-mixed-replace). Breaks in the footage are skipped and the stream ends with the range.
+// @Tags recording
+// @Produce multipart/x-mixed-replace
+// @Param id path string true "Source ID"
+// @Param from query string true "Start of playback (RFC 3339)"
+// @Param to query string false "End of playback (RFC 3339), defaults to now"
+// @Param speed query number false "Playback speed, up to 16" default(1)
+// @Success 200 {file} binary
+// @Failure 400 "Invalid parameters"
+// @Router /sources/{id}/playback [get]
+func (h *Handler) HandlePlayback(w http.ResponseWriter, r *http.Request) {
+	from, err := time.Parse(time.RFC3339, r.URL.Query().Get("from"))
+	if err != nil {
+		http.Error(w, "Invalid from parameter", http.StatusBadRequest)
+		return
+	}
+	to, err := timeParam(r, "to", time.Now())
+	if err != nil {
+		http.Error(w, "Invalid to parameter", http.StatusBadRequest)
+		return
+	}
+	speed := 1.0
+	if value := r.URL.Query().Get("speed"); value != "" {
+		if speed, err = strconv.ParseFloat(value, 64); err != nil {
+			http.Error(w, "Invalid speed parameter", http.StatusBadRequest)
+			return
+		}
+	}
+
+	player, err := h.service.Recordings().Play(mux.Vars(r)["id"], from, to, speed)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	defer player.Close()
+
+	if websocket.IsWebSocketUpgrade(r) {
+		playWebSocket(w, r, player)
+		return
+	}
+	playMJPEG(w, r, player)
+}
+
+// HandleControlPlayback handles requests to seek or change the speed of a
+// playback source
+// @Summary Control playback source
+// @Description Continue a playback source at another capture time or change its speed. Position and speed can be changed together; a paused playback source applies them when resumed.
+// @Tags recording
+// @Accept json
+// @Produce json
+// @Param id path string true "Playback source ID"
+// @Param control body types.PlaybackControl true "New position and/or speed"
+// @Success 200 {object} types.PlaybackStatus
+// @Failure 400 "Invalid position or speed, or not a playback source"
+// @Router /sources/{id}/playback [patch]
+func (h *Handler) HandleControlPlayback(w http.ResponseWriter, r *http.Request) {
+	var control types.PlaybackControl
+	if err := json.NewDecoder(r.Body).Decode(&control); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	status, err := h.service.ControlPlayback(mux.Vars(r)["id"], control)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	json.NewEncoder(w).Encode(status)
+}
+
+// playWebSocket sends played frames as binary WebSocket messages
+func playWebSocket(w http.ResponseWriter, r *http.Request, player *recording.Player) {
+	upgrader := websocket.Upgrader{
+		CheckOrigin: func(r *http.Request) bool {
+			return true // Allow all origins for demo
+		},
+	}
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		http.Error(w, "Could not upgrade connection", http.StatusInternalServerError)
+		return
+	}
+	defer conn.Close()
+
+	// Detect the client going away while waiting for frames
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+	go func() {
+		defer cancel()
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	for {
+		frame, err := player.Next(ctx)
+		if err != nil {
+			break
+		}
+		if err := conn.WriteMessage(websocket.BinaryMessage, frame.Data); err != nil {
+			return
+		}
+		metrics.PlaybackWebSocketBytes.Add(float64(len(frame.Data)))
+	}
+	conn.WriteMessage(websocket.CloseMessage,
+		websocket.FormatCloseMessage(websocket.CloseNormalClosure, "end of footage"))
+}
+
+// playMJPEG writes played frames as a multipart/x-mixed-replace stream
+func playMJPEG(w http.ResponseWriter, r *http.Request, player *recording.Player) {
+	const boundary = "frame"
+	w.Header().Set("Content-Type", "multipart/x-mixed-replace; boundary="+boundary)
+	w.Header().Set("Cache-Control", "no-cache")
+	flusher, _ := w.(http.Flusher)
+
+	for {
+		frame, err := player.Next(r.Context())
+		if err != nil {
+			return
+		}
+		if _, err := fmt.Fprintf(w, "--%s\r\nContent-Type: image/jpeg\r\nContent-Length: %d\r\n\r\n",
+			boundary, len(frame.Data)); err != nil {
+			return
+		}
+		if _, err := w.Write(frame.Data); err != nil {
+			return
+		}
+		if _, err := w.Write([]byte("\r\n")); err != nil {
+			return
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+	}
+}
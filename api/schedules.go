@@ -0,0 +1,186 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/Thivyesh/cameraServiceGo/scheduler"
+	"github.com/Thivyesh/cameraServiceGo/types"
+	"github.com/gorilla/mux"
+)
+
+// scheduleError writes a scheduler error with a matching status code
+func scheduleError(w http.ResponseWriter, err error) {
+	if errors.Is(err, scheduler.ErrNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	http.Error(w, err.Error(), http.StatusBadRequest)
+}
+
+// HandleListSchedules handles requests to list scheduled actions
+// @Summary List schedules
+// @Description Get all scheduled actions with their next and last run
+// @Tags schedules
+// @Produce json
+// @Success 200 {array} types.ScheduleInfo
+// @Router /schedules [get]
+func (h *Handler) HandleListSchedules(w http.ResponseWriter, r *http.Request) {
+	json.NewEncoder(w).Encode(h.schedules.List())
+}
+
+// HandleCreateSchedule handles requests to add a scheduled action
+// @Summary Create schedule
+// @Description Run an action on a source whenever a cron expression matches, e.g. "0 8 * * mon-fri" with action start_recording. Schedules are persisted across restarts.
+// @Tags schedules
+// @Accept json
+// @Produce json
+// @Param schedule body types.Schedule true "Schedule definition"
+// @Success 201 {object} types.ScheduleInfo
+// @Failure 400 "Invalid schedule"
+// @Router /schedules [post]
+func (h *Handler) HandleCreateSchedule(w http.ResponseWriter, r *http.Request) {
+	var schedule types.Schedule
+	if err := json.NewDecoder(r.Body).Decode(&schedule); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	info, err := h.schedules.Create(schedule)
+	if err != nil {
+		scheduleError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(info)
+}
+
+// HandleGetSchedule handles requests for a single scheduled action
+// @Summary Get schedule
+// @Description Get a scheduled action with its next and last run
+// @Tags schedules
+// @Produce json
+// @Param id path string true "Schedule ID"
+// @Success 200 {object} types.ScheduleInfo
+// @Failure 404 "Schedule not found"
+// @Router /schedules/{id} [get]
+func (h *Handler) HandleGetSchedule(w http.ResponseWriter, r *http.Request) {
+	info, err := h.schedules.Get(mux.Vars(r)["id"])
+	if err != nil {
+		scheduleError(w, err)
+		return
+	}
+
+	json.NewEncoder(w).Encode(info)
+}
+
+// HandleUpdateSchedule handles requests to edit a scheduled action
+// @Summary Update schedule
+// @Description Replace the definition of a scheduled action
+// @Tags schedules
+// @Accept json
+// @Produce json
+// @Param id path string true "Schedule ID"
+// @Param schedule body types.Schedule true "Schedule definition"
+// @Success 200 {object} types.ScheduleInfo
+// @Failure 400 "Invalid schedule"
+// @Failure 404 "Schedule not found"
+// @Router /schedules/{id} [put]
+func (h *Handler) HandleUpdateSchedule(w http.ResponseWriter, r *http.Request) {
+	var schedule types.Schedule
+	if err := json.NewDecoder(r.Body).Decode(&schedule); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	info, err := h.schedules.Update(mux.Vars(r)["id"], schedule)
+	if err != nil {
+		scheduleError(w, err)
+		return
+	}
+
+	json.NewEncoder(w).Encode(info)
+}
+
+// HandleDeleteSchedule handles requests to remove a scheduled action
+// @Summary Delete schedule
+// @Description Remove a scheduled action. Its execution history is kept.
+// @Tags schedules
+// @Param id path string true "Schedule ID"
+// @Success 200 "Schedule removed"
+// @Failure 404 "Schedule not found"
+// @Router /schedules/{id} [delete]
+func (h *Handler) HandleDeleteSchedule(w http.ResponseWriter, r *http.Request) {
+	if err := h.schedules.Delete(mux.Vars(r)["id"]); err != nil {
+		scheduleError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// HandleRunSchedule handles requests to run a scheduled action now
+// @Summary Run schedule
+// @Description Run the action of a schedule immediately, even when it is disabled
+// @Tags schedules
+// @Produce json
+// @Param id path string true "Schedule ID"
+// @Success 200 {object} types.ScheduleRun
+// @Failure 404 "Schedule not found"
+// @Router /schedules/{id}/run [post]
+func (h *Handler) HandleRunSchedule(w http.ResponseWriter, r *http.Request) {
+	run, err := h.schedules.Run(mux.Vars(r)["id"])
+	if err != nil {
+		scheduleError(w, err)
+		return
+	}
+
+	json.NewEncoder(w).Encode(run)
+}
+
+// HandleScheduleHistory handles requests for the executions of a schedule
+// @Summary Get schedule history
+// @Description Get past executions of a schedule, most recent first
+// @Tags schedules
+// @Produce json
+// @Param id path string true "Schedule ID"
+// @Param limit query int false "Maximum number of executions"
+// @Success 200 {array} types.ScheduleRun
+// @Router /schedules/{id}/history [get]
+func (h *Handler) HandleScheduleHistory(w http.ResponseWriter, r *http.Request) {
+	limit := 0
+	if value := r.URL.Query().Get("limit"); value != "" {
+		var err error
+		if limit, err = strconv.Atoi(value); err != nil || limit < 0 {
+			http.Error(w, "Invalid limit parameter", http.StatusBadRequest)
+			return
+		}
+	}
+
+	json.NewEncoder(w).Encode(h.schedules.History(mux.Vars(r)["id"], limit))
+}
+
+// HandleScheduleSnapshot handles requests for a still taken by a schedule
+// @Summary Get scheduled snapshot
+// @Description Get a still stored by a snapshot action, named in the execution history
+// @Tags schedules
+// @Produce image/jpeg
+// @Param id path string true "Schedule ID"
+// @Param name path string true "Snapshot file name"
+// @Success 200 {file} binary
+// @Failure 404 "Snapshot not found"
+// @Router /schedules/{id}/snapshots/{name} [get]
+func (h *Handler) HandleScheduleSnapshot(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	path, err := h.schedules.SnapshotPath(vars["id"], vars["name"])
+	if err != nil {
+		scheduleError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "image/jpeg")
+	http.ServeFile(w, r, path)
+}
@@ -0,0 +1,188 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+
+	"github.com/Thivyesh/cameraServiceGo/timelapse"
+	"github.com/Thivyesh/cameraServiceGo/types"
+	"github.com/gorilla/mux"
+)
+
+// timelapseError writes a time-lapse error with a matching status code
+func timelapseError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, timelapse.ErrNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, timelapse.ErrNoStills):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// HandleListTimelapses handles requests to list time-lapse jobs
+// @Summary List time-lapses
+// @Description Get all time-lapse jobs and their progress
+// @Tags timelapse
+// @Produce json
+// @Success 200 {array} types.TimelapseInfo
+// @Router /timelapses [get]
+func (h *Handler) HandleListTimelapses(w http.ResponseWriter, r *http.Request) {
+	json.NewEncoder(w).Encode(h.timelapses.List())
+}
+
+// HandleCreateTimelapse handles requests to start a time-lapse job
+// @Summary Create time-lapse
+// @Description Start taking a still of a source at a fixed interval. Stills are assembled into a video daily at assemble_at and on demand. Jobs resume after restarts.
+// @Tags timelapse
+// @Accept json
+// @Produce json
+// @Param config body types.TimelapseConfig true "Time-lapse configuration"
+// @Success 201 {object} types.TimelapseInfo
+// @Failure 400 "Invalid configuration"
+// @Router /timelapses [post]
+func (h *Handler) HandleCreateTimelapse(w http.ResponseWriter, r *http.Request) {
+	var config types.TimelapseConfig
+	if err := json.NewDecoder(r.Body).Decode(&config); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	info, err := h.timelapses.Create(config)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(info)
+}
+
+// HandleGetTimelapse handles requests for a single time-lapse job
+// @Summary Get time-lapse
+// @Description Get the configuration and progress of a time-lapse job
+// @Tags timelapse
+// @Produce json
+// @Param id path string true "Time-lapse ID"
+// @Success 200 {object} types.TimelapseInfo
+// @Failure 404 "Time-lapse not found"
+// @Router /timelapses/{id} [get]
+func (h *Handler) HandleGetTimelapse(w http.ResponseWriter, r *http.Request) {
+	info, err := h.timelapses.Get(mux.Vars(r)["id"])
+	if err != nil {
+		timelapseError(w, err)
+		return
+	}
+
+	json.NewEncoder(w).Encode(info)
+}
+
+// HandleDeleteTimelapse handles requests to remove a time-lapse job
+// @Summary Delete time-lapse
+// @Description Stop a time-lapse job and remove its stills and videos
+// @Tags timelapse
+// @Param id path string true "Time-lapse ID"
+// @Success 200 "Time-lapse removed"
+// @Failure 404 "Time-lapse not found"
+// @Router /timelapses/{id} [delete]
+func (h *Handler) HandleDeleteTimelapse(w http.ResponseWriter, r *http.Request) {
+	if err := h.timelapses.Delete(mux.Vars(r)["id"]); err != nil {
+		timelapseError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// HandleAssembleTimelapse handles requests to assemble a video now
+// @Summary Assemble time-lapse
+// @Description Assemble the stills taken since the last assembly into a video
+// @Tags timelapse
+// @Produce json
+// @Param id path string true "Time-lapse ID"
+// @Success 201 {object} types.TimelapseVideo
+// @Failure 404 "Time-lapse not found"
+// @Failure 409 "No stills to assemble"
+// @Router /timelapses/{id}/assemble [post]
+func (h *Handler) HandleAssembleTimelapse(w http.ResponseWriter, r *http.Request) {
+	video, err := h.timelapses.Assemble(mux.Vars(r)["id"])
+	if err != nil {
+		timelapseError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(video)
+}
+
+// HandleListTimelapseVideos handles requests to list assembled videos
+// @Summary List time-lapse videos
+// @Description Get the assembled videos of a time-lapse job, most recent first
+// @Tags timelapse
+// @Produce json
+// @Param id path string true "Time-lapse ID"
+// @Success 200 {array} types.TimelapseVideo
+// @Failure 404 "Time-lapse not found"
+// @Router /timelapses/{id}/videos [get]
+func (h *Handler) HandleListTimelapseVideos(w http.ResponseWriter, r *http.Request) {
+	videos, err := h.timelapses.Videos(mux.Vars(r)["id"])
+	if err != nil {
+		timelapseError(w, err)
+		return
+	}
+
+	json.NewEncoder(w).Encode(videos)
+}
+
+// HandleDownloadTimelapseVideo handles requests for an assembled video file
+// @Summary Download time-lapse video
+// @Description Download an assembled time-lapse video as Motion JPEG AVI
+// @Tags timelapse
+// @Produce video/x-msvideo
+// @Param id path string true "Time-lapse ID"
+// @Param videoId path string true "Video ID"
+// @Success 200 {file} binary
+// @Failure 404 "Video not found"
+// @Router /timelapses/{id}/videos/{videoId} [get]
+func (h *Handler) HandleDownloadTimelapseVideo(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	video, path, err := h.timelapses.Video(vars["id"], vars["videoId"])
+	if err != nil {
+		timelapseError(w, err)
+		return
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer file.Close()
+
+	name := fmt.Sprintf("%s_%s.avi", video.Timelapse, video.From.UTC().Format("20060102T150405Z"))
+	w.Header().Set("Content-Type", "video/x-msvideo")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", name))
+	http.ServeContent(w, r, name, video.CreatedAt, file)
+}
+
+// HandleDeleteTimelapseVideo handles requests to remove an assembled video
+// @Summary Delete time-lapse video
+// @Description Remove an assembled time-lapse video
+// @Tags timelapse
+// @Param id path string true "Time-lapse ID"
+// @Param videoId path string true "Video ID"
+// @Success 200 "Video removed"
+// @Failure 404 "Video not found"
+// @Router /timelapses/{id}/videos/{videoId} [delete]
+func (h *Handler) HandleDeleteTimelapseVideo(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	if err := h.timelapses.DeleteVideo(vars["id"], vars["videoId"]); err != nil {
+		timelapseError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
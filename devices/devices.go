@@ -0,0 +1,31 @@
+// Package devices discovers local video capture devices
+package devices
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// ErrUnsupported is returned by List on platforms without V4L2
+var ErrUnsupported = errors.New("device discovery is only supported on Linux")
+
+// Index returns the OpenCV device index of a webcam URI, which is either an
+// index or a path to a device node such as /dev/v4l/by-id/...
+func Index(uri string) (int, error) {
+	if index, err := strconv.Atoi(uri); err == nil {
+		return index, nil
+	}
+
+	path, err := filepath.EvalSymlinks(uri)
+	if err != nil {
+		return 0, fmt.Errorf("invalid device %q: %v", uri, err)
+	}
+	index, err := strconv.Atoi(strings.TrimPrefix(filepath.Base(path), "video"))
+	if err != nil || !strings.HasPrefix(filepath.Base(path), "video") {
+		return 0, fmt.Errorf("%s is not a video device", uri)
+	}
+	return index, nil
+}
@@ -0,0 +1,11 @@
+//go:build !linux
+
+package devices
+
+import "github.com/Thivyesh/cameraServiceGo/types"
+
+// List enumerates local video devices. Discovery needs V4L2 and is only
+// available on Linux.
+func List() ([]types.VideoDevice, error) {
+	return nil, ErrUnsupported
+}
@@ -0,0 +1,60 @@
+package devices
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestIndex(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"video3", "video12", "media0", "videoX"} {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	byID := filepath.Join(dir, "by-id")
+	if err := os.Mkdir(byID, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	links := map[string]string{
+		"usb-Camera-video-index0": "../video3",
+		"usb-Camera-video-index1": "../video12",
+		"usb-Controller-index0":   "../media0",
+		"usb-Unplugged-index0":    "../video9",
+	}
+	for link, target := range links {
+		if err := os.Symlink(target, filepath.Join(byID, link)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		uri  string
+		want int
+		ok   bool
+	}{
+		{"0", 0, true},
+		{"2", 2, true},
+		{filepath.Join(dir, "video3"), 3, true},
+		{filepath.Join(byID, "usb-Camera-video-index0"), 3, true},
+		{filepath.Join(byID, "usb-Camera-video-index1"), 12, true},
+		{filepath.Join(byID, "usb-Controller-index0"), 0, false}, // Not a video node
+		{filepath.Join(byID, "usb-Unplugged-index0"), 0, false},  // Dangling link
+		{filepath.Join(dir, "media0"), 0, false},
+		{filepath.Join(dir, "videoX"), 0, false},
+		{filepath.Join(dir, "missing"), 0, false},
+		{"", 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.uri, func(t *testing.T) {
+			got, err := Index(tt.uri)
+			if tt.ok != (err == nil) {
+				t.Fatalf("Index(%q) error = %v, want ok %v", tt.uri, err, tt.ok)
+			}
+			if got != tt.want {
+				t.Errorf("Index(%q) = %d, want %d", tt.uri, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,193 @@
+package devices
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"syscall"
+	"unsafe"
+
+	"github.com/Thivyesh/cameraServiceGo/types"
+)
+
+// V4L2 ioctl requests and constants from linux/videodev2.h
+const (
+	vidiocQueryCap        = 0x80685600 // VIDIOC_QUERYCAP
+	vidiocEnumFmt         = 0xc0405602 // VIDIOC_ENUM_FMT
+	vidiocEnumFrameSizes  = 0xc02c564a // VIDIOC_ENUM_FRAMESIZES
+	bufTypeVideoCapture   = 1          // V4L2_BUF_TYPE_VIDEO_CAPTURE
+	capVideoCapture       = 0x00000001 // V4L2_CAP_VIDEO_CAPTURE
+	capDeviceCaps         = 0x80000000 // V4L2_CAP_DEVICE_CAPS
+	frameSizeTypeDiscrete = 1          // V4L2_FRMSIZE_TYPE_DISCRETE
+)
+
+// v4l2Capability mirrors struct v4l2_capability
+type v4l2Capability struct {
+	Driver       [16]byte
+	Card         [32]byte
+	BusInfo      [32]byte
+	Version      uint32
+	Capabilities uint32
+	DeviceCaps   uint32
+	Reserved     [3]uint32
+}
+
+// v4l2FmtDesc mirrors struct v4l2_fmtdesc
+type v4l2FmtDesc struct {
+	Index       uint32
+	Type        uint32
+	Flags       uint32
+	Description [32]byte
+	PixelFormat uint32
+	MbusCode    uint32
+	Reserved    [3]uint32
+}
+
+// v4l2FrameSizeEnum mirrors struct v4l2_frmsizeenum. The union holds either
+// a discrete width and height or min, max and step for both.
+type v4l2FrameSizeEnum struct {
+	Index       uint32
+	PixelFormat uint32
+	Type        uint32
+	Size        [6]uint32
+	Reserved    [2]uint32
+}
+
+// List enumerates /dev/video* devices with their capabilities and formats
+func List() ([]types.VideoDevice, error) {
+	paths, err := filepath.Glob("/dev/video*")
+	if err != nil {
+		return nil, err
+	}
+	links := deviceLinks()
+
+	devices := make([]types.VideoDevice, 0, len(paths))
+	for _, path := range paths {
+		index, err := Index(path)
+		if err != nil {
+			continue
+		}
+		device := types.VideoDevice{
+			Path:    path,
+			Index:   index,
+			Links:   links[path],
+			Formats: []types.VideoFormat{},
+			InUseBy: []string{},
+		}
+		if err := query(&device); err != nil {
+			// Devices we may not open still have a name in sysfs
+			name, _ := os.ReadFile(filepath.Join("/sys/class/video4linux", filepath.Base(path), "name"))
+			device.Name = strings.TrimSpace(string(name))
+		}
+		devices = append(devices, device)
+	}
+
+	slices.SortFunc(devices, func(a, b types.VideoDevice) int { return a.Index - b.Index })
+	return devices, nil
+}
+
+// query fills in device details using V4L2 ioctls
+func query(device *types.VideoDevice) error {
+	f, err := os.OpenFile(device.Path, os.O_RDWR|syscall.O_NONBLOCK, 0)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	fd := f.Fd()
+
+	var capability v4l2Capability
+	if err := ioctl(fd, vidiocQueryCap, unsafe.Pointer(&capability)); err != nil {
+		return err
+	}
+	device.Name = cString(capability.Card[:])
+	device.Driver = cString(capability.Driver[:])
+	device.BusInfo = cString(capability.BusInfo[:])
+	caps := capability.Capabilities
+	if caps&capDeviceCaps != 0 {
+		caps = capability.DeviceCaps
+	}
+	device.Capture = caps&capVideoCapture != 0
+	if !device.Capture {
+		return nil
+	}
+
+	for i := uint32(0); ; i++ {
+		desc := v4l2FmtDesc{Index: i, Type: bufTypeVideoCapture}
+		if ioctl(fd, vidiocEnumFmt, unsafe.Pointer(&desc)) != nil {
+			break
+		}
+		device.Formats = append(device.Formats, types.VideoFormat{
+			FourCC:      fourCC(desc.PixelFormat),
+			Description: cString(desc.Description[:]),
+		})
+		frameSizes(fd, desc.PixelFormat, &device.Formats[len(device.Formats)-1])
+	}
+	return nil
+}
+
+// frameSizes lists the resolutions of a pixel format
+func frameSizes(fd uintptr, pixelFormat uint32, format *types.VideoFormat) {
+	format.Resolutions = []types.Resolution{}
+	for i := uint32(0); ; i++ {
+		size := v4l2FrameSizeEnum{Index: i, PixelFormat: pixelFormat}
+		if ioctl(fd, vidiocEnumFrameSizes, unsafe.Pointer(&size)) != nil {
+			return
+		}
+		if size.Type == frameSizeTypeDiscrete {
+			format.Resolutions = append(format.Resolutions, types.Resolution{
+				Width: int(size.Size[0]), Height: int(size.Size[1]),
+			})
+			continue
+		}
+
+		// Stepwise and continuous ranges are reported as a single entry
+		format.Stepwise = true
+		format.Resolutions = append(format.Resolutions,
+			types.Resolution{Width: int(size.Size[0]), Height: int(size.Size[3])},
+			types.Resolution{Width: int(size.Size[1]), Height: int(size.Size[4])},
+		)
+		return
+	}
+}
+
+// deviceLinks maps device nodes to their stable udev symlinks
+func deviceLinks() map[string][]string {
+	links := make(map[string][]string)
+	for _, dir := range []string{"/dev/v4l/by-id", "/dev/v4l/by-path"} {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			link := filepath.Join(dir, entry.Name())
+			if target, err := filepath.EvalSymlinks(link); err == nil {
+				links[target] = append(links[target], link)
+			}
+		}
+	}
+	return links
+}
+
+func ioctl(fd uintptr, request uintptr, arg unsafe.Pointer) error {
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, fd, request, uintptr(arg)); errno != 0 {
+		return errno
+	}
+	return nil
+}
+
+// cString converts a NUL terminated byte array
+func cString(b []byte) string {
+	if i := bytes.IndexByte(b, 0); i >= 0 {
+		b = b[:i]
+	}
+	return string(b)
+}
+
+// fourCC converts a V4L2 pixel format code to its text form
+func fourCC(code uint32) string {
+	b := binary.LittleEndian.AppendUint32(nil, code)
+	return strings.TrimRight(string(b), " \x00")
+}
@@ -15,30 +15,283 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/sources": {
+        "/devices": {
             "get": {
-                "description": "Get a list of all configured video sources",
+                "description": "Enumerate local video capture devices with their formats and resolutions. A device's index or one of its stable links can be used as the URI of a webcam source.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "sources"
+                    "devices"
                 ],
-                "summary": "List all sources",
+                "summary": "List video devices",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
                             "type": "array",
                             "items": {
-                                "$ref": "#/definitions/types.SourceInfo"
+                                "$ref": "#/definitions/types.VideoDevice"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Devices could not be listed"
+                    },
+                    "501": {
+                        "description": "Device discovery is not supported on this platform"
+                    }
+                }
+            }
+        },
+        "/events": {
+            "get": {
+                "description": "Receive source lifecycle, subscriber, error and analytics events as a Server-Sent Events stream",
+                "produces": [
+                    "text/event-stream"
+                ],
+                "tags": [
+                    "events"
+                ],
+                "summary": "Stream service events",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Comma-separated source IDs to include",
+                        "name": "source",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma-separated event types to include, a trailing * matches a prefix",
+                        "name": "type",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Resume after this event ID",
+                        "name": "Last-Event-ID",
+                        "in": "header"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Resume after this event ID (for clients that cannot set headers)",
+                        "name": "last_event_id",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/types.Event"
+                        }
+                    }
+                }
+            }
+        },
+        "/exports": {
+            "get": {
+                "description": "Get running and finished clip exports, most recent first. Finished exports are kept for 24 hours.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "recording"
+                ],
+                "summary": "List exports",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/types.ExportJob"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/exports/{exportId}": {
+            "get": {
+                "description": "Get the status and progress of a clip export",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "recording"
+                ],
+                "summary": "Get export",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Export ID",
+                        "name": "exportId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/types.ExportJob"
+                        }
+                    },
+                    "404": {
+                        "description": "Export not found"
+                    }
+                }
+            },
+            "delete": {
+                "description": "Cancel a running clip export or remove a finished one",
+                "tags": [
+                    "recording"
+                ],
+                "summary": "Delete export",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Export ID",
+                        "name": "exportId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Export removed"
+                    },
+                    "404": {
+                        "description": "Export not found"
+                    }
+                }
+            }
+        },
+        "/exports/{exportId}/download": {
+            "get": {
+                "description": "Download the file of a finished clip export",
+                "produces": [
+                    "application/octet-stream"
+                ],
+                "tags": [
+                    "recording"
+                ],
+                "summary": "Download export",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Export ID",
+                        "name": "exportId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "404": {
+                        "description": "Export not found"
+                    },
+                    "409": {
+                        "description": "Export has not finished"
+                    }
+                }
+            }
+        },
+        "/health": {
+            "get": {
+                "description": "Get the overall status and readiness of the service and the health of every source. Sources are healthy while streaming frames no older than the configured age, while paused, or once a finite source has played all its frames. Critical sources, configured by camera name or source ID with CAMERA_CRITICAL_SOURCES, must be healthy for the service to be ready once they have been added; critical sources not added yet (pending) and other unhealthy sources degrade the status.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "health"
+                ],
+                "summary": "Get service health",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/types.HealthReport"
+                        }
+                    },
+                    "503": {
+                        "description": "Service not ready",
+                        "schema": {
+                            "$ref": "#/definitions/types.HealthReport"
+                        }
+                    }
+                }
+            }
+        },
+        "/media": {
+            "get": {
+                "description": "Get the videos in the media library, most recent first",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "media"
+                ],
+                "summary": "List media",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/types.MediaItem"
                             }
                         }
                     }
                 }
             },
             "post": {
-                "description": "Add a new camera or video source to the service",
+                "description": "Upload a video as the file field of a multipart form. The video is probed and rejected if it cannot be played. Large files can be uploaded resumably through /media/uploads.",
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "media"
+                ],
+                "summary": "Upload media",
+                "parameters": [
+                    {
+                        "type": "file",
+                        "description": "Video file",
+                        "name": "file",
+                        "in": "formData",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/types.MediaItem"
+                        }
+                    },
+                    "400": {
+                        "description": "Missing or unplayable video"
+                    },
+                    "413": {
+                        "description": "Video exceeds the maximum size"
+                    }
+                }
+            }
+        },
+        "/media/uploads": {
+            "post": {
+                "description": "Announce a video upload. Its chunks are then sent with PATCH requests, each starting at the offset reported by the previous one.",
                 "consumes": [
                     "application/json"
                 ],
@@ -46,87 +299,2359 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "sources"
+                    "media"
                 ],
-                "summary": "Add new video source",
+                "summary": "Start resumable upload",
                 "parameters": [
                     {
-                        "description": "Source configuration",
-                        "name": "config",
+                        "description": "File name and size",
+                        "name": "upload",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/types.SourceConfig"
+                            "$ref": "#/definitions/types.UploadRequest"
                         }
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "OK",
+                    "201": {
+                        "description": "Created",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/types.UploadInfo"
                         }
+                    },
+                    "413": {
+                        "description": "Video exceeds the maximum size"
                     }
                 }
             }
         },
-        "/sources/{id}": {
+        "/media/uploads/{uploadId}": {
+            "get": {
+                "description": "Get the offset an interrupted upload resumes from",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "media"
+                ],
+                "summary": "Get upload progress",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Upload ID",
+                        "name": "uploadId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/types.UploadInfo"
+                        }
+                    },
+                    "404": {
+                        "description": "Upload not found"
+                    }
+                }
+            },
             "delete": {
-                "description": "Remove a video source by its ID",
+                "description": "Discard an unfinished upload and the data received so far",
                 "tags": [
-                    "sources"
+                    "media"
                 ],
-                "summary": "Remove a video source",
+                "summary": "Cancel upload",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Source ID",
-                        "name": "id",
+                        "description": "Upload ID",
+                        "name": "uploadId",
                         "in": "path",
                         "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Source removed successfully"
+                        "description": "Upload cancelled"
+                    },
+                    "404": {
+                        "description": "Upload not found"
+                    }
+                }
+            },
+            "patch": {
+                "description": "Append a chunk to a resumable upload. The Upload-Offset header must match the current offset. After the last chunk the response carries the media ID of the new video.",
+                "consumes": [
+                    "application/octet-stream"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "media"
+                ],
+                "summary": "Upload chunk",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Upload ID",
+                        "name": "uploadId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Offset of the chunk in the file",
+                        "name": "Upload-Offset",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/types.UploadInfo"
+                        }
+                    },
+                    "404": {
+                        "description": "Upload not found"
+                    },
+                    "409": {
+                        "description": "Offset does not match the upload"
                     }
                 }
             }
         },
-        "/sources/{id}/stream": {
+        "/media/{id}": {
             "get": {
-                "description": "Get real-time video frames via WebSocket",
+                "description": "Get the stream properties of an uploaded video",
+                "produces": [
+                    "application/json"
+                ],
                 "tags": [
-                    "stream"
+                    "media"
                 ],
-                "summary": "Stream video frames",
+                "summary": "Get media",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Source ID",
+                        "description": "Media ID",
                         "name": "id",
                         "in": "path",
                         "required": true
                     }
                 ],
                 "responses": {
-                    "101": {
-                        "description": "Switching to WebSocket protocol"
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/types.MediaItem"
+                        }
+                    },
+                    "404": {
+                        "description": "Media not found"
+                    }
+                }
+            },
+            "delete": {
+                "description": "Remove a video from the media library. Videos played by a source cannot be removed.",
+                "tags": [
+                    "media"
+                ],
+                "summary": "Delete media",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Media ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Media removed"
+                    },
+                    "404": {
+                        "description": "Media not found"
+                    },
+                    "409": {
+                        "description": "Media is in use"
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "types.SourceConfig": {
+        },
+        "/schedules": {
+            "get": {
+                "description": "Get all scheduled actions with their next and last run",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "schedules"
+                ],
+                "summary": "List schedules",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/types.ScheduleInfo"
+                            }
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Run an action on a source whenever a cron expression matches, e.g. \"0 8 * * mon-fri\" with action start_recording. Schedules are persisted across restarts.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "schedules"
+                ],
+                "summary": "Create schedule",
+                "parameters": [
+                    {
+                        "description": "Schedule definition",
+                        "name": "schedule",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/types.Schedule"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/types.ScheduleInfo"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid schedule"
+                    }
+                }
+            }
+        },
+        "/schedules/{id}": {
+            "get": {
+                "description": "Get a scheduled action with its next and last run",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "schedules"
+                ],
+                "summary": "Get schedule",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Schedule ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/types.ScheduleInfo"
+                        }
+                    },
+                    "404": {
+                        "description": "Schedule not found"
+                    }
+                }
+            },
+            "put": {
+                "description": "Replace the definition of a scheduled action",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "schedules"
+                ],
+                "summary": "Update schedule",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Schedule ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Schedule definition",
+                        "name": "schedule",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/types.Schedule"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/types.ScheduleInfo"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid schedule"
+                    },
+                    "404": {
+                        "description": "Schedule not found"
+                    }
+                }
+            },
+            "delete": {
+                "description": "Remove a scheduled action. Its execution history is kept.",
+                "tags": [
+                    "schedules"
+                ],
+                "summary": "Delete schedule",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Schedule ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Schedule removed"
+                    },
+                    "404": {
+                        "description": "Schedule not found"
+                    }
+                }
+            }
+        },
+        "/schedules/{id}/history": {
+            "get": {
+                "description": "Get past executions of a schedule, most recent first",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "schedules"
+                ],
+                "summary": "Get schedule history",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Schedule ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum number of executions",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/types.ScheduleRun"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/schedules/{id}/run": {
+            "post": {
+                "description": "Run the action of a schedule immediately, even when it is disabled",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "schedules"
+                ],
+                "summary": "Run schedule",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Schedule ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/types.ScheduleRun"
+                        }
+                    },
+                    "404": {
+                        "description": "Schedule not found"
+                    }
+                }
+            }
+        },
+        "/schedules/{id}/snapshots/{name}": {
+            "get": {
+                "description": "Get a still stored by a snapshot action, named in the execution history",
+                "produces": [
+                    "image/jpeg"
+                ],
+                "tags": [
+                    "schedules"
+                ],
+                "summary": "Get scheduled snapshot",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Schedule ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Snapshot file name",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "404": {
+                        "description": "Snapshot not found"
+                    }
+                }
+            }
+        },
+        "/sources": {
+            "get": {
+                "description": "Get a list of all configured video sources",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "sources"
+                ],
+                "summary": "List all sources",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/types.SourceInfo"
+                            }
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Add a new camera or video source to the service. Push sources also return their ingest URL and token.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "sources"
+                ],
+                "summary": "Add new video source",
+                "parameters": [
+                    {
+                        "description": "Source configuration",
+                        "name": "config",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/types.SourceConfig"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/sources/{id}": {
+            "delete": {
+                "description": "Remove a video source by its ID",
+                "tags": [
+                    "sources"
+                ],
+                "summary": "Remove a video source",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Source ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Source removed successfully"
+                    }
+                }
+            }
+        },
+        "/sources/{id}/codes": {
+            "get": {
+                "description": "Get QR codes decoded by the qrcode stage of a source, newest first. Repeated reads within the stage's window are reported once.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "analytics"
+                ],
+                "summary": "List decoded codes",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Source ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only codes read after this RFC 3339 time",
+                        "name": "since",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum number of codes to return",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/types.CodeRead"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/sources/{id}/export": {
+            "get": {
+                "description": "Start assembling the frames of a source captured in a time range into a file. Frames come from recordings and from the in-memory pre-roll buffer kept for every source. The export runs in the background; poll the returned job and download it once done. AVI exports play at the average frame rate of the range.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "recording"
+                ],
+                "summary": "Export clip",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Source ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Start of the range (RFC 3339)",
+                        "name": "from",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "End of the range (RFC 3339), defaults to now",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "default": "avi",
+                        "description": "Output format: avi, zip of JPEG frames or mjpeg",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Burn the capture time and camera name into the frames",
+                        "name": "osd",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "OSD text template, see the osd pipeline stage",
+                        "name": "osd_template",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "$ref": "#/definitions/types.ExportJob"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid parameters"
+                    }
+                }
+            }
+        },
+        "/sources/{id}/health/reference": {
+            "post": {
+                "description": "Use the next analysed frame as the reference scene for tamper detection, e.g. after a camera was intentionally moved",
+                "tags": [
+                    "analytics"
+                ],
+                "summary": "Reset tamper reference",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Source ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Reference will be captured from the next analysed frame"
+                    }
+                }
+            }
+        },
+        "/sources/{id}/ingest": {
+            "get": {
+                "description": "Upload JPEG frames to a push source as binary WebSocket messages. Rejected frames are reported as text messages.",
+                "tags": [
+                    "ingest"
+                ],
+                "summary": "Upload frames over WebSocket",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Source ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Ingest token, alternatively sent as a Bearer token",
+                        "name": "token",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "101": {
+                        "description": "Switching to WebSocket protocol"
+                    },
+                    "403": {
+                        "description": "Ingest access denied"
+                    }
+                }
+            },
+            "post": {
+                "description": "Upload one JPEG frame to a push source. Frames are processed like captured frames; a frame arriving before the previous one was processed replaces it.",
+                "consumes": [
+                    "image/jpeg"
+                ],
+                "tags": [
+                    "ingest"
+                ],
+                "summary": "Upload a frame",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Source ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Ingest token, alternatively sent as a Bearer token",
+                        "name": "token",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Frame accepted"
+                    },
+                    "400": {
+                        "description": "Frame is not a JPEG image"
+                    },
+                    "403": {
+                        "description": "Ingest access denied"
+                    }
+                }
+            }
+        },
+        "/sources/{id}/metadata": {
+            "get": {
+                "description": "Get metadata of the latest frame of a source, such as detections attached by analytics stages",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "pipeline"
+                ],
+                "summary": "Get latest frame metadata",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Source ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/types.FrameInfo"
+                        }
+                    }
+                }
+            }
+        },
+        "/sources/{id}/pause": {
+            "post": {
+                "description": "Stop capturing and release the device while keeping the source and its subscribers",
+                "tags": [
+                    "sources"
+                ],
+                "summary": "Pause source",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Source ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Source paused"
+                    },
+                    "409": {
+                        "description": "Source not found or already paused"
+                    }
+                }
+            }
+        },
+        "/sources/{id}/pipeline": {
+            "get": {
+                "description": "Get the frame processing stages of a source with per-stage timings",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "pipeline"
+                ],
+                "summary": "Get source pipeline",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Source ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/types.PipelineInfo"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Replace the ordered frame processing stages of a running source",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "pipeline"
+                ],
+                "summary": "Replace source pipeline",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Source ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Ordered stage configuration",
+                        "name": "stages",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/types.StageConfig"
+                            }
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/types.PipelineInfo"
+                        }
+                    }
+                }
+            }
+        },
+        "/sources/{id}/playback": {
+            "get": {
+                "description": "Stream the footage of a source from a point in time at the pace it was captured. WebSocket requests receive every frame as a binary JPEG message like /sources/{id}/stream, other requests receive an MJPEG stream (multipart/x-mixed-replace). Breaks in the footage are skipped and the stream ends with the range.",
+                "produces": [
+                    "multipart/x-mixed-replace"
+                ],
+                "tags": [
+                    "recording"
+                ],
+                "summary": "Play back footage",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Source ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Start of playback (RFC 3339)",
+                        "name": "from",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "End of playback (RFC 3339), defaults to now",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "default": 1,
+                        "description": "Playback speed, up to 16",
+                        "name": "speed",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid parameters"
+                    }
+                }
+            },
+            "patch": {
+                "description": "Continue a playback source at another capture time or change its speed. Position and speed can be changed together; a paused playback source applies them when resumed.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "recording"
+                ],
+                "summary": "Control playback source",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Playback source ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "New position and/or speed",
+                        "name": "control",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/types.PlaybackControl"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/types.PlaybackStatus"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid position or speed, or not a playback source"
+                    }
+                }
+            }
+        },
+        "/sources/{id}/privacy-zones": {
+            "get": {
+                "description": "Get the privacy masking zones of a source",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "privacy"
+                ],
+                "summary": "List privacy zones",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Source ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/types.PrivacyZone"
+                            }
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Replace all privacy masking zones of a source. Changes apply from the next frame.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "privacy"
+                ],
+                "summary": "Replace privacy zones",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Source ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Privacy zones",
+                        "name": "zones",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/types.PrivacyZone"
+                            }
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/types.PrivacyZone"
+                            }
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Add a privacy masking zone to a source. The zone applies from the next frame.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "privacy"
+                ],
+                "summary": "Add privacy zone",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Source ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Privacy zone",
+                        "name": "zone",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/types.PrivacyZone"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/types.PrivacyZone"
+                        }
+                    }
+                }
+            }
+        },
+        "/sources/{id}/privacy-zones/{zoneId}": {
+            "delete": {
+                "description": "Remove a privacy masking zone from a source",
+                "tags": [
+                    "privacy"
+                ],
+                "summary": "Remove privacy zone",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Source ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Zone ID",
+                        "name": "zoneId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Zone removed successfully"
+                    }
+                }
+            }
+        },
+        "/sources/{id}/recording": {
+            "post": {
+                "description": "Record the processed frames of a source to disk so they can be exported later",
+                "tags": [
+                    "recording"
+                ],
+                "summary": "Start recording",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Source ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Recording started"
+                    },
+                    "409": {
+                        "description": "Source is already being recorded"
+                    }
+                }
+            },
+            "delete": {
+                "description": "Stop recording a source. Footage recorded so far is kept.",
+                "tags": [
+                    "recording"
+                ],
+                "summary": "Stop recording",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Source ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Recording stopped"
+                    },
+                    "409": {
+                        "description": "Source is not being recorded"
+                    }
+                }
+            }
+        },
+        "/sources/{id}/resume": {
+            "post": {
+                "description": "Reopen the device of a paused source and continue streaming",
+                "tags": [
+                    "sources"
+                ],
+                "summary": "Resume source",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Source ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Source resumed"
+                    },
+                    "409": {
+                        "description": "Source not found or not paused"
+                    }
+                }
+            }
+        },
+        "/sources/{id}/snapshot": {
+            "get": {
+                "description": "Get the next processed frame of a source as JPEG, with privacy zones and pipeline stages applied",
+                "produces": [
+                    "image/jpeg"
+                ],
+                "tags": [
+                    "stream"
+                ],
+                "summary": "Get snapshot",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Source ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "file"
+                        }
+                    }
+                }
+            }
+        },
+        "/sources/{id}/stream": {
+            "get": {
+                "description": "Get real-time video frames via WebSocket. Frames are anonymized unless raw access is requested with the source's raw access token.",
+                "tags": [
+                    "stream"
+                ],
+                "summary": "Stream video frames",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Source ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Stream frames before anonymization",
+                        "name": "raw",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Raw access token, alternatively sent as a Bearer token",
+                        "name": "token",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "101": {
+                        "description": "Switching to WebSocket protocol"
+                    },
+                    "403": {
+                        "description": "Raw access denied"
+                    }
+                }
+            }
+        },
+        "/sources/{id}/timeline": {
+            "get": {
+                "description": "Get the periods of a time range covered by footage of a source, the gaps between them and event markers such as detections and tamper alerts, e.g. to render a scrub bar. Footage includes recordings and the pre-roll buffer; breaks shorter than 5 seconds are not reported as gaps. Up to 5000 events are returned.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "recording"
+                ],
+                "summary": "Get timeline",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Source ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Start of the range (RFC 3339), defaults to 24 hours before to",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "End of the range (RFC 3339), defaults to now",
+                        "name": "to",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/types.Timeline"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid parameters"
+                    }
+                }
+            }
+        },
+        "/timelapses": {
+            "get": {
+                "description": "Get all time-lapse jobs and their progress",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "timelapse"
+                ],
+                "summary": "List time-lapses",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/types.TimelapseInfo"
+                            }
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Start taking a still of a source at a fixed interval. Stills are assembled into a video daily at assemble_at and on demand. Jobs resume after restarts.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "timelapse"
+                ],
+                "summary": "Create time-lapse",
+                "parameters": [
+                    {
+                        "description": "Time-lapse configuration",
+                        "name": "config",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/types.TimelapseConfig"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/types.TimelapseInfo"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid configuration"
+                    }
+                }
+            }
+        },
+        "/timelapses/{id}": {
+            "get": {
+                "description": "Get the configuration and progress of a time-lapse job",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "timelapse"
+                ],
+                "summary": "Get time-lapse",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Time-lapse ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/types.TimelapseInfo"
+                        }
+                    },
+                    "404": {
+                        "description": "Time-lapse not found"
+                    }
+                }
+            },
+            "delete": {
+                "description": "Stop a time-lapse job and remove its stills and videos",
+                "tags": [
+                    "timelapse"
+                ],
+                "summary": "Delete time-lapse",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Time-lapse ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Time-lapse removed"
+                    },
+                    "404": {
+                        "description": "Time-lapse not found"
+                    }
+                }
+            }
+        },
+        "/timelapses/{id}/assemble": {
+            "post": {
+                "description": "Assemble the stills taken since the last assembly into a video",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "timelapse"
+                ],
+                "summary": "Assemble time-lapse",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Time-lapse ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/types.TimelapseVideo"
+                        }
+                    },
+                    "404": {
+                        "description": "Time-lapse not found"
+                    },
+                    "409": {
+                        "description": "No stills to assemble"
+                    }
+                }
+            }
+        },
+        "/timelapses/{id}/videos": {
+            "get": {
+                "description": "Get the assembled videos of a time-lapse job, most recent first",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "timelapse"
+                ],
+                "summary": "List time-lapse videos",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Time-lapse ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/types.TimelapseVideo"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Time-lapse not found"
+                    }
+                }
+            }
+        },
+        "/timelapses/{id}/videos/{videoId}": {
+            "get": {
+                "description": "Download an assembled time-lapse video as Motion JPEG AVI",
+                "produces": [
+                    "video/x-msvideo"
+                ],
+                "tags": [
+                    "timelapse"
+                ],
+                "summary": "Download time-lapse video",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Time-lapse ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Video ID",
+                        "name": "videoId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "404": {
+                        "description": "Video not found"
+                    }
+                }
+            },
+            "delete": {
+                "description": "Remove an assembled time-lapse video",
+                "tags": [
+                    "timelapse"
+                ],
+                "summary": "Delete time-lapse video",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Time-lapse ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Video ID",
+                        "name": "videoId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Video removed"
+                    },
+                    "404": {
+                        "description": "Video not found"
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "types.Box": {
+            "description": "Normalized bounding box",
+            "type": "object",
+            "properties": {
+                "height": {
+                    "description": "@Description Height relative to the image height",
+                    "type": "number"
+                },
+                "width": {
+                    "description": "@Description Width relative to the image width",
+                    "type": "number"
+                },
+                "x": {
+                    "description": "@Description Left edge",
+                    "type": "number"
+                },
+                "y": {
+                    "description": "@Description Top edge",
+                    "type": "number"
+                }
+            }
+        },
+        "types.CodeRead": {
+            "description": "Decoded QR code",
+            "type": "object",
+            "properties": {
+                "frame_id": {
+                    "description": "@Description Frame the code was read from",
+                    "type": "integer"
+                },
+                "payload": {
+                    "description": "@Description Decoded payload",
+                    "type": "string"
+                },
+                "points": {
+                    "description": "@Description Corners of the code in normalized coordinates",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/types.Point"
+                    }
+                },
+                "timestamp": {
+                    "description": "@Description When the code was read",
+                    "type": "string"
+                }
+            }
+        },
+        "types.CompositeConfig": {
+            "description": "Composite (mosaic) source configuration",
+            "type": "object",
+            "properties": {
+                "fps": {
+                    "description": "@Description Output frame rate",
+                    "type": "number"
+                },
+                "height": {
+                    "description": "@Description Output height in pixels",
+                    "type": "integer"
+                },
+                "layout": {
+                    "description": "@Description Grid layout as COLUMNSxROWS (e.g. 2x2), derived from the source count when empty",
+                    "type": "string"
+                },
+                "mode": {
+                    "description": "@Description Composition mode (grid, pip), defaults to grid",
+                    "type": "string"
+                },
+                "pip": {
+                    "description": "@Description Picture-in-picture settings (pip mode)",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/types.PipConfig"
+                        }
+                    ]
+                },
+                "show_timestamps": {
+                    "description": "@Description Draw the capture time of each input on its tile",
+                    "type": "boolean"
+                },
+                "sources": {
+                    "description": "@Description IDs of the sources to combine, in tile order (grid mode)",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "width": {
+                    "description": "@Description Output width in pixels",
+                    "type": "integer"
+                }
+            }
+        },
+        "types.DerivedConfig": {
+            "description": "Derived view source configuration",
+            "type": "object",
+            "properties": {
+                "crop": {
+                    "description": "@Description Region of the parent frame in normalized coordinates",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/types.Box"
+                        }
+                    ]
+                },
+                "flip": {
+                    "description": "@Description Flip direction (horizontal, vertical, both)",
+                    "type": "string"
+                },
+                "parent": {
+                    "description": "@Description ID of the source whose frames are transformed",
+                    "type": "string"
+                },
+                "rotate": {
+                    "description": "@Description Clockwise rotation in degrees",
+                    "type": "number"
+                },
+                "scale": {
+                    "description": "@Description Scale factor applied last, e.g. 2 for a digital zoom of a crop",
+                    "type": "number"
+                }
+            }
+        },
+        "types.Event": {
+            "description": "Service event delivered over the event stream",
+            "type": "object",
+            "properties": {
+                "data": {
+                    "description": "@Description Event specific payload"
+                },
+                "id": {
+                    "description": "@Description Monotonically increasing event identifier",
+                    "type": "integer"
+                },
+                "source": {
+                    "description": "@Description Source the event relates to, if any",
+                    "type": "string"
+                },
+                "timestamp": {
+                    "description": "@Description When the event occurred",
+                    "type": "string"
+                },
+                "type": {
+                    "description": "@Description Event type (e.g. source.added, subscriber.connected)",
+                    "type": "string"
+                }
+            }
+        },
+        "types.ExportJob": {
+            "description": "Clip export job",
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "description": "@Description When the export was requested",
+                    "type": "string"
+                },
+                "error": {
+                    "description": "@Description Reason the export failed",
+                    "type": "string"
+                },
+                "finished_at": {
+                    "description": "@Description When the export finished",
+                    "type": "string"
+                },
+                "format": {
+                    "description": "@Description Output format (avi, zip or mjpeg)",
+                    "type": "string"
+                },
+                "frames": {
+                    "description": "@Description Number of frames in the range",
+                    "type": "integer"
+                },
+                "from": {
+                    "description": "@Description Start of the exported time range",
+                    "type": "string"
+                },
+                "id": {
+                    "description": "@Description Export identifier",
+                    "type": "string"
+                },
+                "progress": {
+                    "description": "@Description Fraction of frames written, from 0 to 1",
+                    "type": "number"
+                },
+                "size": {
+                    "description": "@Description Size of the output file in bytes",
+                    "type": "integer"
+                },
+                "source": {
+                    "description": "@Description Source the frames were recorded from",
+                    "type": "string"
+                },
+                "status": {
+                    "description": "@Description Job state (running, done, failed or cancelled)",
+                    "type": "string"
+                },
+                "to": {
+                    "description": "@Description End of the exported time range",
+                    "type": "string"
+                }
+            }
+        },
+        "types.FrameInfo": {
+            "description": "Frame metadata without image data",
+            "type": "object",
+            "properties": {
+                "id": {
+                    "description": "@Description Frame identifier",
+                    "type": "integer"
+                },
+                "metadata": {
+                    "description": "@Description Values attached by pipeline stages",
+                    "type": "object",
+                    "additionalProperties": true
+                },
+                "timestamp": {
+                    "description": "@Description When the frame was captured",
+                    "type": "string"
+                }
+            }
+        },
+        "types.HTTPConfig": {
+            "description": "HTTP source connection settings",
+            "type": "object",
+            "properties": {
+                "connect_timeout_ms": {
+                    "description": "@Description Time allowed to connect and receive response headers in milliseconds, defaults to 10000",
+                    "type": "integer"
+                },
+                "password": {
+                    "description": "@Description Password for basic or digest authentication",
+                    "type": "string"
+                },
+                "read_timeout_ms": {
+                    "description": "@Description Longest gap between frames in milliseconds before reconnecting, defaults to 10000",
+                    "type": "integer"
+                },
+                "username": {
+                    "description": "@Description User name for basic or digest authentication",
+                    "type": "string"
+                }
+            }
+        },
+        "types.HealthReport": {
+            "description": "Service health",
+            "type": "object",
+            "properties": {
+                "max_frame_age_seconds": {
+                    "description": "@Description Oldest last frame allowed before a source counts as stalled, in seconds",
+                    "type": "number"
+                },
+                "problems": {
+                    "description": "@Description Reasons the service is not ready",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "ready": {
+                    "description": "@Description Whether the service is ready to serve, see /readyz",
+                    "type": "boolean"
+                },
+                "sources": {
+                    "description": "@Description Health of every source and of missing critical sources",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/types.SourceHealth"
+                    }
+                },
+                "started_at": {
+                    "description": "@Description When the service started",
+                    "type": "string"
+                },
+                "status": {
+                    "description": "@Description Overall status (ok, degraded or unavailable)",
+                    "type": "string"
+                }
+            }
+        },
+        "types.ImageHealth": {
+            "description": "Image health metrics and active tamper alerts",
+            "type": "object",
+            "properties": {
+                "alerts": {
+                    "description": "@Description Active alerts (too_dark, too_bright, defocused, frozen, scene_changed)",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "brightness": {
+                    "description": "@Description Mean brightness (0-255)",
+                    "type": "number"
+                },
+                "frozen_frames": {
+                    "description": "@Description Number of consecutive identical frames",
+                    "type": "integer"
+                },
+                "scene_change": {
+                    "description": "@Description Fraction of the image that differs from the reference (0-1)",
+                    "type": "number"
+                },
+                "sharpness": {
+                    "description": "@Description Variance of the Laplacian, low values indicate defocus",
+                    "type": "number"
+                },
+                "updated_at": {
+                    "description": "@Description When the metrics were last computed",
+                    "type": "string"
+                }
+            }
+        },
+        "types.MediaItem": {
+            "description": "Media library video",
+            "type": "object",
+            "properties": {
+                "codec": {
+                    "description": "@Description Video codec four character code",
+                    "type": "string"
+                },
+                "duration": {
+                    "description": "@Description Duration in seconds",
+                    "type": "number"
+                },
+                "fps": {
+                    "description": "@Description Frame rate",
+                    "type": "number"
+                },
+                "frames": {
+                    "description": "@Description Number of frames",
+                    "type": "integer"
+                },
+                "height": {
+                    "description": "@Description Frame height in pixels",
+                    "type": "integer"
+                },
+                "id": {
+                    "description": "@Description Media identifier, usable as media_id of a file source",
+                    "type": "string"
+                },
+                "name": {
+                    "description": "@Description Original file name",
+                    "type": "string"
+                },
+                "size": {
+                    "description": "@Description File size in bytes",
+                    "type": "integer"
+                },
+                "uploaded_at": {
+                    "description": "@Description When the upload completed",
+                    "type": "string"
+                },
+                "width": {
+                    "description": "@Description Frame width in pixels",
+                    "type": "integer"
+                }
+            }
+        },
+        "types.PipConfig": {
+            "description": "Picture-in-picture configuration",
+            "type": "object",
+            "properties": {
+                "border": {
+                    "description": "@Description Inset border width in pixels, defaults to 2",
+                    "type": "integer"
+                },
+                "border_color": {
+                    "description": "@Description Inset border color as #RRGGBB, defaults to #FFFFFF",
+                    "type": "string"
+                },
+                "margin": {
+                    "description": "@Description Distance of the inset from the output edges in pixels, defaults to 16",
+                    "type": "integer"
+                },
+                "position": {
+                    "description": "@Description Inset corner (top_left, top_right, bottom_left, bottom_right), defaults to bottom_right",
+                    "type": "string"
+                },
+                "primary": {
+                    "description": "@Description Source filling the output",
+                    "type": "string"
+                },
+                "secondary": {
+                    "description": "@Description Source shown in the inset",
+                    "type": "string"
+                },
+                "size": {
+                    "description": "@Description Inset size as a fraction of the output size, defaults to 0.3",
+                    "type": "number"
+                }
+            }
+        },
+        "types.PipeConfig": {
+            "description": "Pipe source configuration",
+            "type": "object",
+            "properties": {
+                "command": {
+                    "description": "@Description Command line to run, frames are read from its standard output",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "format": {
+                    "description": "@Description Frame format (mjpeg, raw), defaults to mjpeg",
+                    "type": "string"
+                },
+                "height": {
+                    "description": "@Description Raw frame height in pixels",
+                    "type": "integer"
+                },
+                "path": {
+                    "description": "@Description Named pipe (FIFO) to read frames from instead of running a command",
+                    "type": "string"
+                },
+                "pixel_format": {
+                    "description": "@Description Raw pixel format (bgr24, rgb24, gray), defaults to bgr24",
+                    "type": "string"
+                },
+                "width": {
+                    "description": "@Description Raw frame width in pixels",
+                    "type": "integer"
+                }
+            }
+        },
+        "types.PipelineInfo": {
+            "description": "Processing pipeline configuration and per-stage timings",
+            "type": "object",
+            "properties": {
+                "stages": {
+                    "description": "@Description Configured stages in execution order",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/types.StageConfig"
+                    }
+                },
+                "stats": {
+                    "description": "@Description Timings per stage followed by JPEG encoding",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/types.StageStats"
+                    }
+                }
+            }
+        },
+        "types.PlaybackConfig": {
+            "description": "Playback source configuration",
+            "type": "object",
+            "properties": {
+                "from": {
+                    "description": "@Description Start of the played time range",
+                    "type": "string"
+                },
+                "mode": {
+                    "description": "@Description Playback mode (once, loop), defaults to once",
+                    "type": "string"
+                },
+                "source": {
+                    "description": "@Description Source whose recordings are played",
+                    "type": "string"
+                },
+                "speed": {
+                    "description": "@Description Playback speed, up to 16, defaults to 1",
+                    "type": "number"
+                },
+                "to": {
+                    "description": "@Description End of the played time range, defaults to when the playback source is added",
+                    "type": "string"
+                }
+            }
+        },
+        "types.PlaybackControl": {
+            "description": "Playback source control request",
+            "type": "object",
+            "properties": {
+                "position": {
+                    "description": "@Description Capture time to continue playback at",
+                    "type": "string"
+                },
+                "speed": {
+                    "description": "@Description New playback speed, up to 16",
+                    "type": "number"
+                }
+            }
+        },
+        "types.PlaybackStatus": {
+            "description": "Playback source status",
+            "type": "object",
+            "properties": {
+                "from": {
+                    "description": "@Description Start of the played time range",
+                    "type": "string"
+                },
+                "mode": {
+                    "description": "@Description Playback mode (once, loop)",
+                    "type": "string"
+                },
+                "position": {
+                    "description": "@Description Capture time of the last played frame",
+                    "type": "string"
+                },
+                "source": {
+                    "description": "@Description Source whose recordings are played",
+                    "type": "string"
+                },
+                "speed": {
+                    "description": "@Description Playback speed, 1 is real time",
+                    "type": "number"
+                },
+                "to": {
+                    "description": "@Description End of the played time range",
+                    "type": "string"
+                }
+            }
+        },
+        "types.Point": {
+            "description": "Normalized image coordinate",
+            "type": "object",
+            "properties": {
+                "x": {
+                    "description": "@Description Horizontal position, 0 is the left and 1 the right edge",
+                    "type": "number"
+                },
+                "y": {
+                    "description": "@Description Vertical position, 0 is the top and 1 the bottom edge",
+                    "type": "number"
+                }
+            }
+        },
+        "types.PrivacyZone": {
+            "description": "Privacy masking zone",
+            "type": "object",
+            "properties": {
+                "color": {
+                    "description": "@Description Fill color as #RRGGBB for fill mode",
+                    "type": "string"
+                },
+                "id": {
+                    "description": "@Description Zone identifier, assigned when omitted",
+                    "type": "string"
+                },
+                "mode": {
+                    "description": "@Description Masking mode (fill, pixelate, blur)",
+                    "type": "string"
+                },
+                "name": {
+                    "description": "@Description Optional description of the zone",
+                    "type": "string"
+                },
+                "points": {
+                    "description": "@Description Polygon vertices in normalized coordinates",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/types.Point"
+                    }
+                }
+            }
+        },
+        "types.ProcessInfo": {
+            "description": "Pipe source process status",
+            "type": "object",
+            "properties": {
+                "last_exit": {
+                    "description": "@Description How the command last exited",
+                    "type": "string"
+                },
+                "pid": {
+                    "description": "@Description Process ID of the running command",
+                    "type": "integer"
+                },
+                "restarts": {
+                    "description": "@Description Number of times the command was restarted",
+                    "type": "integer"
+                },
+                "running": {
+                    "description": "@Description Whether the command is running",
+                    "type": "boolean"
+                },
+                "stderr": {
+                    "description": "@Description Most recent lines written to standard error",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "types.Resolution": {
+            "description": "Frame size",
+            "type": "object",
+            "properties": {
+                "height": {
+                    "description": "@Description Height in pixels",
+                    "type": "integer"
+                },
+                "width": {
+                    "description": "@Description Width in pixels",
+                    "type": "integer"
+                }
+            }
+        },
+        "types.Schedule": {
+            "description": "Scheduled action",
+            "type": "object",
+            "properties": {
+                "action": {
+                    "description": "@Description Action (start_capture, stop_capture, start_recording, stop_recording, snapshot, set_pipeline)",
+                    "type": "string"
+                },
+                "cron": {
+                    "description": "@Description Five field cron expression (minute hour day-of-month month day-of-week) or a macro such as @daily",
+                    "type": "string"
+                },
+                "disabled": {
+                    "description": "@Description Whether the schedule is suspended",
+                    "type": "boolean"
+                },
+                "id": {
+                    "description": "@Description Schedule identifier, assigned when the schedule is created",
+                    "type": "string"
+                },
+                "name": {
+                    "description": "@Description Human readable schedule name",
+                    "type": "string"
+                },
+                "pipeline": {
+                    "description": "@Description Stages applied by set_pipeline",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/types.StageConfig"
+                    }
+                },
+                "source": {
+                    "description": "@Description Source the action targets",
+                    "type": "string"
+                },
+                "time_zone": {
+                    "description": "@Description IANA time zone the expression is evaluated in, defaults to the server time zone",
+                    "type": "string"
+                }
+            }
+        },
+        "types.ScheduleInfo": {
+            "description": "Schedule status",
+            "type": "object",
+            "properties": {
+                "last_run": {
+                    "description": "@Description Most recent execution",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/types.ScheduleRun"
+                        }
+                    ]
+                },
+                "next_run": {
+                    "description": "@Description Next time the action runs",
+                    "type": "string"
+                },
+                "schedule": {
+                    "description": "@Description Schedule definition",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/types.Schedule"
+                        }
+                    ]
+                }
+            }
+        },
+        "types.ScheduleRun": {
+            "description": "Schedule execution",
+            "type": "object",
+            "properties": {
+                "action": {
+                    "description": "@Description Action that ran",
+                    "type": "string"
+                },
+                "error": {
+                    "description": "@Description Reason the action failed",
+                    "type": "string"
+                },
+                "manual": {
+                    "description": "@Description Whether the run was triggered manually",
+                    "type": "boolean"
+                },
+                "schedule": {
+                    "description": "@Description Schedule that ran",
+                    "type": "string"
+                },
+                "snapshot": {
+                    "description": "@Description File name of the still taken by a snapshot action",
+                    "type": "string"
+                },
+                "source": {
+                    "description": "@Description Source the action targeted",
+                    "type": "string"
+                },
+                "success": {
+                    "description": "@Description Whether the action succeeded",
+                    "type": "boolean"
+                },
+                "time": {
+                    "description": "@Description When the action ran",
+                    "type": "string"
+                }
+            }
+        },
+        "types.SequenceConfig": {
+            "description": "Image sequence source configuration",
+            "type": "object",
+            "properties": {
+                "fps": {
+                    "description": "@Description Playback frame rate, defaults to 10",
+                    "type": "number"
+                },
+                "mode": {
+                    "description": "@Description Playback mode (loop, once), defaults to loop",
+                    "type": "string"
+                },
+                "order": {
+                    "description": "@Description Frame order (name, timestamp), defaults to name",
+                    "type": "string"
+                },
+                "timestamp_layout": {
+                    "description": "@Description Go time layout of the timestamp in file names, e.g. 20060102_150405.000; Unix seconds or milliseconds are detected when empty",
+                    "type": "string"
+                }
+            }
+        },
+        "types.SourceConfig": {
             "description": "Configuration for a video source",
             "type": "object",
             "properties": {
+                "composite": {
+                    "description": "@Description Layout of a composite source",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/types.CompositeConfig"
+                        }
+                    ]
+                },
+                "derived": {
+                    "description": "@Description Parent and transform of a derived source",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/types.DerivedConfig"
+                        }
+                    ]
+                },
+                "http": {
+                    "description": "@Description Credentials and timeouts of an HTTP source",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/types.HTTPConfig"
+                        }
+                    ]
+                },
+                "ingest_token": {
+                    "description": "@Description Token required to upload frames to a push source, generated when empty",
+                    "type": "string"
+                },
+                "media_id": {
+                    "description": "@Description Media library item to play, sets type file and the URI when given",
+                    "type": "string"
+                },
+                "name": {
+                    "description": "For files: path to video file\nFor webcam: device ID (e.g., \"0\" for default camera)\nFor IP camera: RTSP/HTTP URL\n@Description Human readable camera name, used by on-screen display",
+                    "type": "string"
+                },
+                "pipe": {
+                    "description": "@Description Command or named pipe and frame format of a pipe source",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/types.PipeConfig"
+                        }
+                    ]
+                },
+                "pipeline": {
+                    "description": "@Description Ordered frame processing stages applied before encoding",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/types.StageConfig"
+                    }
+                },
+                "playback": {
+                    "description": "@Description Recorded footage played by a playback source",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/types.PlaybackConfig"
+                        }
+                    ]
+                },
+                "privacy_zones": {
+                    "description": "@Description Regions masked on every frame before any other processing",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/types.PrivacyZone"
+                    }
+                },
+                "raw_access_token": {
+                    "description": "@Description Token required to stream frames before anonymization, empty disables raw access",
+                    "type": "string"
+                },
+                "sequence": {
+                    "description": "@Description Playback settings of an image sequence source, whose URI is a directory or glob pattern",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/types.SequenceConfig"
+                        }
+                    ]
+                },
                 "type": {
-                    "description": "@Description Type of video source (webcam, file, ip_camera)",
+                    "description": "@Description Type of video source (webcam, file, ip_camera, gstreamer, mjpeg_http, push, image_sequence, pipe, composite, derived, playback)",
                     "type": "string"
                 },
                 "uri": {
@@ -135,18 +2660,129 @@ const docTemplate = `{
                 }
             }
         },
+        "types.SourceHealth": {
+            "description": "Source health",
+            "type": "object",
+            "properties": {
+                "alerts": {
+                    "description": "@Description Active tamper alerts of the source",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "critical": {
+                    "description": "@Description Whether the service is only ready while this source is healthy",
+                    "type": "boolean"
+                },
+                "healthy": {
+                    "description": "@Description Whether the source is streaming or deliberately paused",
+                    "type": "boolean"
+                },
+                "id": {
+                    "description": "@Description Source identifier",
+                    "type": "string"
+                },
+                "last_frame": {
+                    "description": "@Description When the source last produced a frame",
+                    "type": "string"
+                },
+                "last_frame_age_seconds": {
+                    "description": "@Description Seconds since the source last produced a frame",
+                    "type": "number"
+                },
+                "name": {
+                    "description": "@Description Human readable camera name",
+                    "type": "string"
+                },
+                "status": {
+                    "description": "@Description Source status (streaming, paused, finished, waiting, stalled, stopped, pending or missing)",
+                    "type": "string"
+                },
+                "type": {
+                    "description": "@Description Type of video source",
+                    "type": "string"
+                }
+            }
+        },
         "types.SourceInfo": {
             "description": "Information about a video source",
             "type": "object",
             "properties": {
+                "finished": {
+                    "description": "@Description Whether a once-mode sequence or playback source played all its frames",
+                    "type": "boolean"
+                },
+                "health": {
+                    "description": "@Description Image health metrics, present when a tamper stage is configured",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/types.ImageHealth"
+                        }
+                    ]
+                },
                 "id": {
                     "description": "@Description Unique identifier for the source",
                     "type": "string"
                 },
+                "inputs": {
+                    "description": "@Description Sources a virtual source consumes",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
                 "is_streaming": {
                     "description": "@Description Whether the source is currently streaming",
                     "type": "boolean"
                 },
+                "last_frame": {
+                    "description": "@Description When the source last produced a frame",
+                    "type": "string"
+                },
+                "lineage": {
+                    "description": "@Description Ancestors of a derived view, starting with the capturing source",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "media_id": {
+                    "description": "@Description Media library item played by a file source",
+                    "type": "string"
+                },
+                "name": {
+                    "description": "@Description Human readable camera name",
+                    "type": "string"
+                },
+                "parent": {
+                    "description": "@Description Source a derived view is taken from",
+                    "type": "string"
+                },
+                "paused": {
+                    "description": "@Description Whether capture is paused, e.g. by a schedule",
+                    "type": "boolean"
+                },
+                "playback": {
+                    "description": "@Description Position and speed of a playback source",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/types.PlaybackStatus"
+                        }
+                    ]
+                },
+                "process": {
+                    "description": "@Description External process of a pipe source",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/types.ProcessInfo"
+                        }
+                    ]
+                },
+                "recording": {
+                    "description": "@Description Whether frames are being recorded to disk",
+                    "type": "boolean"
+                },
                 "type": {
                     "description": "@Description Type of video source",
                     "type": "string"
@@ -156,6 +2792,354 @@ const docTemplate = `{
                     "type": "string"
                 }
             }
+        },
+        "types.StageConfig": {
+            "description": "Configuration for a frame processing stage",
+            "type": "object",
+            "properties": {
+                "options": {
+                    "description": "@Description Stage specific options",
+                    "type": "object"
+                },
+                "type": {
+                    "description": "@Description Stage type (resize, crop, rotate, flip, color, denoise, osd, detect, face_blur, qrcode, tamper or a registered custom type)",
+                    "type": "string"
+                }
+            }
+        },
+        "types.StageStats": {
+            "description": "Timing statistics for a pipeline stage",
+            "type": "object",
+            "properties": {
+                "avg_ms": {
+                    "description": "@Description Average duration in milliseconds",
+                    "type": "number"
+                },
+                "errors": {
+                    "description": "@Description Number of frames the stage failed on",
+                    "type": "integer"
+                },
+                "frames": {
+                    "description": "@Description Number of frames processed by the stage",
+                    "type": "integer"
+                },
+                "last_ms": {
+                    "description": "@Description Duration of the most recent invocation in milliseconds",
+                    "type": "number"
+                },
+                "max_ms": {
+                    "description": "@Description Longest duration in milliseconds",
+                    "type": "number"
+                },
+                "type": {
+                    "description": "@Description Stage type",
+                    "type": "string"
+                }
+            }
+        },
+        "types.TimeRange": {
+            "description": "Time range",
+            "type": "object",
+            "properties": {
+                "from": {
+                    "description": "@Description Start of the period",
+                    "type": "string"
+                },
+                "to": {
+                    "description": "@Description End of the period",
+                    "type": "string"
+                }
+            }
+        },
+        "types.TimelapseConfig": {
+            "description": "Time-lapse job configuration",
+            "type": "object",
+            "properties": {
+                "assemble_at": {
+                    "description": "@Description Daily time (HH:MM) stills are assembled into a video, empty to only assemble on demand",
+                    "type": "string"
+                },
+                "fps": {
+                    "description": "@Description Frame rate of assembled videos, defaults to 25",
+                    "type": "number"
+                },
+                "id": {
+                    "description": "@Description Time-lapse identifier, assigned when the job is created",
+                    "type": "string"
+                },
+                "interval_seconds": {
+                    "description": "@Description Seconds between stills",
+                    "type": "integer"
+                },
+                "keep_stills": {
+                    "description": "@Description Keep stills after they were assembled",
+                    "type": "boolean"
+                },
+                "name": {
+                    "description": "@Description Human readable job name",
+                    "type": "string"
+                },
+                "source": {
+                    "description": "@Description Source stills are taken from",
+                    "type": "string"
+                },
+                "time_zone": {
+                    "description": "@Description IANA time zone of assemble_at, defaults to the server time zone",
+                    "type": "string"
+                }
+            }
+        },
+        "types.TimelapseInfo": {
+            "description": "Time-lapse job status",
+            "type": "object",
+            "properties": {
+                "config": {
+                    "description": "@Description Job configuration",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/types.TimelapseConfig"
+                        }
+                    ]
+                },
+                "last_capture": {
+                    "description": "@Description Time of the most recent still",
+                    "type": "string"
+                },
+                "last_error": {
+                    "description": "@Description Reason the last capture or assembly failed",
+                    "type": "string"
+                },
+                "next_assembly": {
+                    "description": "@Description Time of the next scheduled assembly",
+                    "type": "string"
+                },
+                "stills": {
+                    "description": "@Description Stills waiting to be assembled",
+                    "type": "integer"
+                },
+                "videos": {
+                    "description": "@Description Number of assembled videos",
+                    "type": "integer"
+                }
+            }
+        },
+        "types.TimelapseVideo": {
+            "description": "Time-lapse video",
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "description": "@Description When the video was assembled",
+                    "type": "string"
+                },
+                "fps": {
+                    "description": "@Description Frame rate",
+                    "type": "number"
+                },
+                "frames": {
+                    "description": "@Description Number of stills in the video",
+                    "type": "integer"
+                },
+                "from": {
+                    "description": "@Description Time of the first still",
+                    "type": "string"
+                },
+                "id": {
+                    "description": "@Description Video identifier",
+                    "type": "string"
+                },
+                "size": {
+                    "description": "@Description File size in bytes",
+                    "type": "integer"
+                },
+                "timelapse": {
+                    "description": "@Description Time-lapse job the video belongs to",
+                    "type": "string"
+                },
+                "to": {
+                    "description": "@Description Time of the last still",
+                    "type": "string"
+                }
+            }
+        },
+        "types.Timeline": {
+            "description": "Recorded footage and event markers of a source",
+            "type": "object",
+            "properties": {
+                "events": {
+                    "description": "@Description Events of the source, oldest first",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/types.TimelineEvent"
+                    }
+                },
+                "events_truncated": {
+                    "description": "@Description Whether further events in the range were left out",
+                    "type": "boolean"
+                },
+                "from": {
+                    "description": "@Description Start of the requested time range",
+                    "type": "string"
+                },
+                "gaps": {
+                    "description": "@Description Periods without footage, oldest first",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/types.TimeRange"
+                    }
+                },
+                "ranges": {
+                    "description": "@Description Periods with footage, oldest first",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/types.TimeRange"
+                    }
+                },
+                "source": {
+                    "description": "@Description Source the footage was recorded from",
+                    "type": "string"
+                },
+                "to": {
+                    "description": "@Description End of the requested time range",
+                    "type": "string"
+                }
+            }
+        },
+        "types.TimelineEvent": {
+            "description": "Timeline event marker",
+            "type": "object",
+            "properties": {
+                "data": {
+                    "description": "@Description Event specific payload"
+                },
+                "id": {
+                    "description": "@Description Event identifier",
+                    "type": "integer"
+                },
+                "time": {
+                    "description": "@Description When the event occurred",
+                    "type": "string"
+                },
+                "type": {
+                    "description": "@Description Event type (e.g. analytics.detection, tamper.alert)",
+                    "type": "string"
+                }
+            }
+        },
+        "types.UploadInfo": {
+            "description": "Resumable upload status",
+            "type": "object",
+            "properties": {
+                "filename": {
+                    "description": "@Description Name of the file being uploaded",
+                    "type": "string"
+                },
+                "id": {
+                    "description": "@Description Upload identifier",
+                    "type": "string"
+                },
+                "media_id": {
+                    "description": "@Description Media item created when the upload completed",
+                    "type": "string"
+                },
+                "offset": {
+                    "description": "@Description Bytes received so far, where the next chunk must start",
+                    "type": "integer"
+                },
+                "size": {
+                    "description": "@Description Total size in bytes",
+                    "type": "integer"
+                }
+            }
+        },
+        "types.UploadRequest": {
+            "description": "Resumable upload request",
+            "type": "object",
+            "properties": {
+                "filename": {
+                    "description": "@Description Name of the file being uploaded",
+                    "type": "string"
+                },
+                "size": {
+                    "description": "@Description Total size in bytes",
+                    "type": "integer"
+                }
+            }
+        },
+        "types.VideoDevice": {
+            "description": "Local video device",
+            "type": "object",
+            "properties": {
+                "bus_info": {
+                    "description": "@Description Bus the device is attached to",
+                    "type": "string"
+                },
+                "capture": {
+                    "description": "@Description Whether the device node captures video, metadata nodes do not",
+                    "type": "boolean"
+                },
+                "driver": {
+                    "description": "@Description Kernel driver",
+                    "type": "string"
+                },
+                "formats": {
+                    "description": "@Description Supported pixel formats and resolutions",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/types.VideoFormat"
+                    }
+                },
+                "in_use_by": {
+                    "description": "@Description Sources currently capturing from the device",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "index": {
+                    "description": "@Description Index to use as webcam URI",
+                    "type": "integer"
+                },
+                "links": {
+                    "description": "@Description Stable symlinks to the device, usable as webcam URI",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "name": {
+                    "description": "@Description Device name reported by the driver",
+                    "type": "string"
+                },
+                "path": {
+                    "description": "@Description Device node, e.g. /dev/video0",
+                    "type": "string"
+                }
+            }
+        },
+        "types.VideoFormat": {
+            "description": "Video device pixel format",
+            "type": "object",
+            "properties": {
+                "description": {
+                    "description": "@Description Format description reported by the driver",
+                    "type": "string"
+                },
+                "fourcc": {
+                    "description": "@Description Four character code, e.g. MJPG or YUYV",
+                    "type": "string"
+                },
+                "resolutions": {
+                    "description": "@Description Supported frame sizes; for stepwise formats the minimum and maximum",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/types.Resolution"
+                    }
+                },
+                "stepwise": {
+                    "description": "@Description Whether any size between the minimum and maximum is supported",
+                    "type": "boolean"
+                }
+            }
         }
     }
 }`
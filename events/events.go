@@ -0,0 +1,218 @@
+// Package events provides a publish/subscribe bus for service events
+package events
+
+import (
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Thivyesh/cameraServiceGo/types"
+)
+
+// Event types published by the service
+const (
+	SourceAdded            = "source.added"
+	SourceRemoved          = "source.removed"
+	SourceStarted          = "source.started"
+	SourceStopped          = "source.stopped"
+	SourcePaused           = "source.paused"
+	SourceResumed          = "source.resumed"
+	SourceError            = "source.error"
+	SourceReconnecting     = "source.reconnecting"
+	SourceReconnected      = "source.reconnected"
+	SubscriberConnected    = "subscriber.connected"
+	SubscriberDisconnected = "subscriber.disconnected"
+	AnalyticsDetection     = "analytics.detection"
+	AnalyticsCode          = "analytics.code"
+	TamperAlert            = "tamper.alert"
+	TamperCleared          = "tamper.cleared"
+	RecordingStarted       = "recording.started"
+	RecordingStopped       = "recording.stopped"
+)
+
+// Filter selects which events a subscription receives
+type Filter struct {
+	Sources []string // Source IDs to include, empty for all
+	Types   []string // Event types to include, a trailing * matches a prefix
+}
+
+// Match reports whether an event passes the filter
+func (f Filter) Match(event types.Event) bool {
+	if len(f.Sources) > 0 && !slices.Contains(f.Sources, event.Source) {
+		return false
+	}
+	if len(f.Types) == 0 {
+		return true
+	}
+	for _, t := range f.Types {
+		if prefix, ok := strings.CutSuffix(t, "*"); ok {
+			if strings.HasPrefix(event.Type, prefix) {
+				return true
+			}
+		} else if t == event.Type {
+			return true
+		}
+	}
+	return false
+}
+
+// Subscription receives events matching its filter
+type Subscription struct {
+	C      <-chan types.Event // Channel delivering live events
+	ch     chan types.Event   // Writable side of C
+	filter Filter             // Events to deliver
+	queue  *eventQueue        // Undelivered events of a lossless subscription, nil otherwise
+}
+
+// eventQueue buffers events of a lossless subscription without bound
+type eventQueue struct {
+	mu     sync.Mutex    // Protects events
+	events []types.Event // Events waiting for delivery
+	ready  chan struct{} // Signalled when events are queued
+	done   chan struct{} // Closed on unsubscribe
+}
+
+// push queues an event without blocking
+func (q *eventQueue) push(event types.Event) {
+	q.mu.Lock()
+	q.events = append(q.events, event)
+	q.mu.Unlock()
+	select {
+	case q.ready <- struct{}{}:
+	default:
+	}
+}
+
+// forward delivers queued events to ch in order until done is closed
+func (q *eventQueue) forward(ch chan<- types.Event) {
+	for {
+		select {
+		case <-q.done:
+			return
+		case <-q.ready:
+		}
+		q.mu.Lock()
+		pending := q.events
+		q.events = nil
+		q.mu.Unlock()
+
+		for _, event := range pending {
+			select {
+			case ch <- event:
+			case <-q.done:
+				return
+			}
+		}
+	}
+}
+
+// Bus distributes events to subscribers and keeps a bounded history
+type Bus struct {
+	mu          sync.Mutex                 // Protects shared state
+	nextID      int64                      // ID assigned to the next event
+	history     []types.Event              // Ring buffer of recent events
+	start       int                        // Index of the oldest event in history
+	size        int                        // Maximum history length
+	subscribers map[*Subscription]struct{} // Active subscriptions
+}
+
+// NewBus creates an event bus remembering up to historySize events
+func NewBus(historySize int) *Bus {
+	return &Bus{
+		nextID:      1,
+		history:     make([]types.Event, 0, historySize),
+		size:        historySize,
+		subscribers: make(map[*Subscription]struct{}),
+	}
+}
+
+// Publish records an event and delivers it to matching subscribers
+func (b *Bus) Publish(eventType, sourceID string, data interface{}) types.Event {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	event := types.Event{
+		ID:        b.nextID,
+		Type:      eventType,
+		Source:    sourceID,
+		Timestamp: time.Now(),
+		Data:      data,
+	}
+	b.nextID++
+
+	// Append to history, overwriting the oldest entry once full
+	if len(b.history) < b.size {
+		b.history = append(b.history, event)
+	} else if b.size > 0 {
+		b.history[b.start] = event
+		b.start = (b.start + 1) % b.size
+	}
+
+	for sub := range b.subscribers {
+		if !sub.filter.Match(event) {
+			continue
+		}
+		if sub.queue != nil {
+			sub.queue.push(event)
+			continue
+		}
+		select {
+		case sub.ch <- event:
+		default:
+			// Skip if subscriber is not keeping up
+		}
+	}
+
+	return event
+}
+
+// Subscribe registers a new subscription. Events from history with an ID
+// greater than lastID are returned for replay before live delivery starts.
+func (b *Bus) Subscribe(filter Filter, lastID int64) ([]types.Event, *Subscription) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var replay []types.Event
+	if lastID > 0 {
+		for i := range b.history {
+			event := b.history[(b.start+i)%len(b.history)]
+			if event.ID > lastID && filter.Match(event) {
+				replay = append(replay, event)
+			}
+		}
+	}
+
+	ch := make(chan types.Event, 64)
+	sub := &Subscription{C: ch, ch: ch, filter: filter}
+	b.subscribers[sub] = struct{}{}
+
+	return replay, sub
+}
+
+// SubscribeLossless registers a subscription that never drops events.
+// Events the subscriber has not received yet are queued without bound, so
+// it is meant for internal consumers that always keep draining C, such as
+// the recording timeline index.
+func (b *Bus) SubscribeLossless(filter Filter) *Subscription {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	ch := make(chan types.Event, 64)
+	queue := &eventQueue{ready: make(chan struct{}, 1), done: make(chan struct{})}
+	sub := &Subscription{C: ch, ch: ch, filter: filter, queue: queue}
+	b.subscribers[sub] = struct{}{}
+	go queue.forward(ch)
+
+	return sub
+}
+
+// Unsubscribe stops delivery to a subscription
+func (b *Bus) Unsubscribe(sub *Subscription) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if _, ok := b.subscribers[sub]; ok && sub.queue != nil {
+		close(sub.queue.done)
+	}
+	delete(b.subscribers, sub)
+}
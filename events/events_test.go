@@ -0,0 +1,56 @@
+package events
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSubscribeLosslessKeepsEvents(t *testing.T) {
+	bus := NewBus(10)
+	sub := bus.SubscribeLossless(Filter{Types: []string{"analytics.*"}})
+	defer bus.Unsubscribe(sub)
+	_, dropping := bus.Subscribe(Filter{}, 0)
+
+	// Far more events than channel buffers hold, published before any are read
+	const count = 1000
+	for range count {
+		bus.Publish(AnalyticsDetection, "cam", nil)
+		bus.Publish(SourceStarted, "cam", nil)
+	}
+
+	var lastID int64
+	for i := range count {
+		select {
+		case event := <-sub.C:
+			if event.Type != AnalyticsDetection {
+				t.Fatalf("event %d has type %s", i, event.Type)
+			}
+			if event.ID <= lastID {
+				t.Fatalf("event %d arrived after event %d", event.ID, lastID)
+			}
+			lastID = event.ID
+		case <-time.After(time.Second):
+			t.Fatalf("received %d of %d events", i, count)
+		}
+	}
+	if n := len(dropping.C); n != cap(dropping.C) {
+		t.Errorf("regular subscription holds %d events, want its full buffer of %d", n, cap(dropping.C))
+	}
+}
+
+func TestUnsubscribeLosslessStopsDelivery(t *testing.T) {
+	bus := NewBus(10)
+	sub := bus.SubscribeLossless(Filter{})
+	bus.Publish(SourceAdded, "cam", nil)
+	bus.Unsubscribe(sub)
+	bus.Unsubscribe(sub)
+	bus.Publish(SourceRemoved, "cam", nil)
+
+	select {
+	case event := <-sub.C:
+		if event.Type != SourceAdded {
+			t.Errorf("received %s after unsubscribing", event.Type)
+		}
+	case <-time.After(100 * time.Millisecond):
+	}
+}
@@ -0,0 +1,182 @@
+// Package health reports whether the service is alive and ready to serve,
+// based on the sources it is required to stream
+package health
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Thivyesh/cameraServiceGo/types"
+)
+
+// Overall service statuses
+const (
+	StatusOK          = "ok"          // Ready and every source healthy
+	StatusDegraded    = "degraded"    // Ready, but some non-critical source is not
+	StatusUnavailable = "unavailable" // Not ready
+)
+
+// Sources lists the sources whose health is reported. CameraService
+// satisfies it.
+type Sources interface {
+	ListSources() []types.SourceInfo
+}
+
+// Checker evaluates the health of the service. The service is ready once
+// startup has completed and every critical source is streaming frames no
+// older than the allowed age. Paused sources count as healthy, so sources
+// paused by a schedule do not take the service out of rotation, and so do
+// finite sources that played all their frames.
+//
+// Critical sources are named by source ID or camera name. Sources are added
+// through the API after startup, so a critical source only counts towards
+// readiness once it has been added; until then it degrades the status.
+type Checker struct {
+	sources     Sources         // Sources to check
+	critical    []string        // IDs or names of sources required for readiness
+	maxFrameAge time.Duration   // Oldest last frame of a streaming source
+	started     time.Time       // When the checker was created
+	ready       atomic.Bool     // Whether startup has completed
+	mu          sync.Mutex      // Protects added
+	added       map[string]bool // Critical entries that matched a source
+}
+
+// NewChecker creates a checker requiring the critical sources to have
+// produced a frame within maxFrameAge
+func NewChecker(sources Sources, critical []string, maxFrameAge time.Duration) *Checker {
+	return &Checker{
+		sources:     sources,
+		critical:    critical,
+		maxFrameAge: maxFrameAge,
+		started:     time.Now(),
+		added:       make(map[string]bool),
+	}
+}
+
+// SetReady marks startup, i.e. opening the stores and loading schedules and
+// time-lapses, as complete
+func (c *Checker) SetReady() {
+	c.ready.Store(true)
+}
+
+// Report evaluates the health of the service and every source
+func (c *Checker) Report() types.HealthReport {
+	report := types.HealthReport{
+		Status:             StatusOK,
+		Ready:              true,
+		StartedAt:          c.started,
+		MaxFrameAgeSeconds: c.maxFrameAge.Seconds(),
+		Sources:            []types.SourceHealth{},
+	}
+	if !c.ready.Load() {
+		report.Problems = append(report.Problems, "service is starting")
+	}
+
+	now := time.Now()
+	sources := c.sources.ListSources()
+	slices.SortFunc(sources, func(a, b types.SourceInfo) int {
+		return strings.Compare(a.ID, b.ID)
+	})
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	seen := make(map[string]bool, len(sources))
+	for _, info := range sources {
+		if key := c.criticalKey(info); key != "" {
+			seen[key] = true
+			c.added[key] = true
+		}
+		source := c.check(info, now)
+		report.Sources = append(report.Sources, source)
+		if source.Healthy {
+			continue
+		}
+		if source.Critical {
+			report.Problems = append(report.Problems,
+				fmt.Sprintf("critical source %s is %s", source.ID, source.Status))
+		} else {
+			report.Status = StatusDegraded
+		}
+	}
+
+	// Critical sources that were never added or have been removed
+	for _, key := range c.critical {
+		if seen[key] {
+			continue
+		}
+		if !c.added[key] {
+			report.Sources = append(report.Sources, types.SourceHealth{
+				ID:       key,
+				Status:   "pending",
+				Critical: true,
+			})
+			report.Status = StatusDegraded
+			continue
+		}
+		report.Sources = append(report.Sources, types.SourceHealth{
+			ID:       key,
+			Status:   "missing",
+			Critical: true,
+		})
+		report.Problems = append(report.Problems, fmt.Sprintf("critical source %s is missing", key))
+	}
+
+	if len(report.Problems) > 0 {
+		report.Ready = false
+		report.Status = StatusUnavailable
+	}
+	return report
+}
+
+// criticalKey returns the critical entry naming a source, matching its ID
+// first and then its camera name, or "" if the source is not critical
+func (c *Checker) criticalKey(info types.SourceInfo) string {
+	if slices.Contains(c.critical, info.ID) {
+		return info.ID
+	}
+	if info.Name != "" && slices.Contains(c.critical, info.Name) {
+		return info.Name
+	}
+	return ""
+}
+
+// check evaluates the health of a single source
+func (c *Checker) check(info types.SourceInfo, now time.Time) types.SourceHealth {
+	source := types.SourceHealth{
+		ID:        info.ID,
+		Name:      info.Name,
+		Type:      info.Type,
+		Critical:  c.criticalKey(info) != "",
+		LastFrame: info.LastFrame,
+	}
+	if info.Health != nil {
+		source.Alerts = info.Health.Alerts
+	}
+	if info.LastFrame != nil {
+		source.LastFrameAgeSeconds = now.Sub(*info.LastFrame).Seconds()
+	}
+
+	switch {
+	case info.Paused:
+		source.Status = "paused"
+		source.Healthy = true
+	case info.Finished:
+		source.Status = "finished"
+		source.Healthy = true
+	case !info.IsStreaming:
+		source.Status = "stopped"
+	case info.LastFrame == nil:
+		source.Status = "waiting"
+	case now.Sub(*info.LastFrame) > c.maxFrameAge:
+		source.Status = "stalled"
+	default:
+		source.Status = "streaming"
+		source.Healthy = true
+	}
+	return source
+}
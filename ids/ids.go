@@ -0,0 +1,24 @@
+// Package ids generates random identifiers and secrets
+package ids
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+)
+
+// New generates a random identifier with a prefix, e.g. media_1f2e3d4c5b6a7980
+func New(prefix string) string {
+	return prefix + randomHex(8)
+}
+
+// Token generates a random secret for access tokens
+func Token() string {
+	return randomHex(16)
+}
+
+// randomHex returns size random bytes hex encoded
+func randomHex(size int) string {
+	b := make([]byte, size)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -11,23 +11,130 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"strconv"
+	"strings"
 	"syscall"
 	"time"
 
 	"github.com/Thivyesh/cameraServiceGo/api"
 	_ "github.com/Thivyesh/cameraServiceGo/docs"
+	"github.com/Thivyesh/cameraServiceGo/health"
+	"github.com/Thivyesh/cameraServiceGo/media"
+	"github.com/Thivyesh/cameraServiceGo/metrics"
+	"github.com/Thivyesh/cameraServiceGo/recording"
+	"github.com/Thivyesh/cameraServiceGo/scheduler"
 	"github.com/Thivyesh/cameraServiceGo/service"
+	"github.com/Thivyesh/cameraServiceGo/source"
+	"github.com/Thivyesh/cameraServiceGo/timelapse"
 	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/rs/cors"
 	httpSwagger "github.com/swaggo/http-swagger/v2"
 )
 
 func main() {
+	// Commands run with the service's privileges, so pipe sources may only
+	// start them when explicitly allowed
+	source.AllowPipeCommands = os.Getenv("CAMERA_ALLOW_PIPE_COMMANDS") == "true"
+
+	// GStreamer elements such as filesrc and filesink access files on the
+	// host, so only elements reading streams and devices are allowed unless
+	// any pipeline is explicitly allowed
+	source.AllowGStreamerPipelines = os.Getenv("CAMERA_ALLOW_GSTREAMER") == "true"
+
+	// Open the library of uploaded videos
+	mediaDir := os.Getenv("CAMERA_MEDIA_DIR")
+	if mediaDir == "" {
+		mediaDir = "data/media"
+	}
+	var maxMediaSize int64
+	if value := os.Getenv("CAMERA_MAX_UPLOAD_MB"); value != "" {
+		mb, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || mb < 1 {
+			log.Fatalf("Invalid CAMERA_MAX_UPLOAD_MB: %q", value)
+		}
+		maxMediaSize = mb << 20
+	}
+	library, err := media.NewLibrary(mediaDir, maxMediaSize)
+	if err != nil {
+		log.Fatalf("Error opening media library: %v", err)
+	}
+
+	// Open the recordings, keeping a pre-roll buffer of every source for
+	// exports of footage that was not being recorded
+	recordingDir := os.Getenv("CAMERA_RECORDING_DIR")
+	if recordingDir == "" {
+		recordingDir = "data/recordings"
+	}
+	preRoll := 30 * time.Second
+	if value := os.Getenv("CAMERA_PREROLL_SECONDS"); value != "" {
+		seconds, err := strconv.Atoi(value)
+		if err != nil || seconds < 0 {
+			log.Fatalf("Invalid CAMERA_PREROLL_SECONDS: %q", value)
+		}
+		preRoll = time.Duration(seconds) * time.Second
+	}
+	recordings, err := recording.NewStore(recordingDir, preRoll)
+	if err != nil {
+		log.Fatalf("Error opening recordings: %v", err)
+	}
+	defer recordings.Close()
+	metrics.RegisterDiskUsage(recordings.DiskUsage)
+
+	exportDir := os.Getenv("CAMERA_EXPORT_DIR")
+	if exportDir == "" {
+		exportDir = "data/exports"
+	}
+	exports, err := recording.NewExporter(recordings, exportDir)
+	if err != nil {
+		log.Fatalf("Error opening export directory: %v", err)
+	}
+
 	// Create a new camera service
-	cameraService := service.NewCameraService()
+	cameraService := service.NewCameraService(library, recordings, exports)
+
+	// Resume time-lapse jobs, which take their stills as snapshots
+	timelapseDir := os.Getenv("CAMERA_TIMELAPSE_DIR")
+	if timelapseDir == "" {
+		timelapseDir = "data/timelapses"
+	}
+	timelapses, err := timelapse.NewManager(timelapseDir, cameraService)
+	if err != nil {
+		log.Fatalf("Error opening time-lapses: %v", err)
+	}
+	defer timelapses.Close()
+
+	// Start scheduled actions
+	scheduleDir := os.Getenv("CAMERA_SCHEDULE_DIR")
+	if scheduleDir == "" {
+		scheduleDir = "data/schedules"
+	}
+	schedules, err := scheduler.New(scheduleDir, cameraService)
+	if err != nil {
+		log.Fatalf("Error opening schedules: %v", err)
+	}
+	defer schedules.Close()
+
+	// Readiness requires the critical sources, given by camera name or source
+	// ID, to stream recent frames once they have been added
+	var critical []string
+	for _, id := range strings.Split(os.Getenv("CAMERA_CRITICAL_SOURCES"), ",") {
+		if id = strings.TrimSpace(id); id != "" {
+			critical = append(critical, id)
+		}
+	}
+	maxFrameAge := 10 * time.Second
+	if value := os.Getenv("CAMERA_MAX_FRAME_AGE_SECONDS"); value != "" {
+		seconds, err := strconv.ParseFloat(value, 64)
+		if err != nil || seconds <= 0 {
+			log.Fatalf("Invalid CAMERA_MAX_FRAME_AGE_SECONDS: %q", value)
+		}
+		maxFrameAge = time.Duration(seconds * float64(time.Second))
+	}
+	checker := health.NewChecker(cameraService, critical, maxFrameAge)
 
 	// Create a http handler
-	handler := api.NewHandler(cameraService)
+	handler := api.NewHandler(cameraService, timelapses, schedules, checker)
 
 	// Create router and register routes
 	router := mux.NewRouter()
@@ -37,17 +144,75 @@ func main() {
 		httpSwagger.URL("http://localhost:8080/swagger/doc.json"), // The URL pointing to API definition
 	))
 
+	// Prometheus metrics
+	router.Handle("/metrics", promhttp.Handler()).Methods("GET")
+
+	// Kubernetes probes
+	router.HandleFunc("/healthz", handler.HandleLiveness).Methods("GET")
+	router.HandleFunc("/readyz", handler.HandleReadiness).Methods("GET")
+
 	// API routes
 	apiRouter := router.PathPrefix("/api").Subrouter()
 	apiRouter.HandleFunc("/sources", handler.HandleListSources).Methods("GET")
 	apiRouter.HandleFunc("/sources", handler.HandleAddSource).Methods("POST")
 	apiRouter.HandleFunc("/sources/{id}", handler.HandleRemoveSource).Methods("DELETE")
 	apiRouter.HandleFunc("/sources/{id}/stream", handler.HandleStreamFrames)
+	apiRouter.HandleFunc("/sources/{id}/pipeline", handler.HandleGetPipeline).Methods("GET")
+	apiRouter.HandleFunc("/sources/{id}/pipeline", handler.HandleSetPipeline).Methods("PUT")
+	apiRouter.HandleFunc("/sources/{id}/metadata", handler.HandleGetFrameInfo).Methods("GET")
+	apiRouter.HandleFunc("/sources/{id}/codes", handler.HandleListCodes).Methods("GET")
+	apiRouter.HandleFunc("/sources/{id}/health/reference", handler.HandleResetHealthReference).Methods("POST")
+	apiRouter.HandleFunc("/sources/{id}/privacy-zones", handler.HandleListPrivacyZones).Methods("GET")
+	apiRouter.HandleFunc("/sources/{id}/privacy-zones", handler.HandleSetPrivacyZones).Methods("PUT")
+	apiRouter.HandleFunc("/sources/{id}/privacy-zones", handler.HandleAddPrivacyZone).Methods("POST")
+	apiRouter.HandleFunc("/sources/{id}/privacy-zones/{zoneId}", handler.HandleRemovePrivacyZone).Methods("DELETE")
+	apiRouter.HandleFunc("/sources/{id}/snapshot", handler.HandleSnapshot).Methods("GET")
+	apiRouter.HandleFunc("/sources/{id}/ingest", handler.HandleIngestFrame).Methods("POST")
+	apiRouter.HandleFunc("/sources/{id}/ingest", handler.HandleIngestStream).Methods("GET")
+	apiRouter.HandleFunc("/sources/{id}/pause", handler.HandlePauseSource).Methods("POST")
+	apiRouter.HandleFunc("/sources/{id}/resume", handler.HandleResumeSource).Methods("POST")
+	apiRouter.HandleFunc("/sources/{id}/recording", handler.HandleStartRecording).Methods("POST")
+	apiRouter.HandleFunc("/sources/{id}/recording", handler.HandleStopRecording).Methods("DELETE")
+	apiRouter.HandleFunc("/sources/{id}/export", handler.HandleExport).Methods("GET")
+	apiRouter.HandleFunc("/sources/{id}/timeline", handler.HandleTimeline).Methods("GET")
+	apiRouter.HandleFunc("/sources/{id}/playback", handler.HandlePlayback).Methods("GET")
+	apiRouter.HandleFunc("/sources/{id}/playback", handler.HandleControlPlayback).Methods("PATCH")
+	apiRouter.HandleFunc("/exports", handler.HandleListExports).Methods("GET")
+	apiRouter.HandleFunc("/exports/{exportId}", handler.HandleGetExport).Methods("GET")
+	apiRouter.HandleFunc("/exports/{exportId}", handler.HandleDeleteExport).Methods("DELETE")
+	apiRouter.HandleFunc("/exports/{exportId}/download", handler.HandleDownloadExport).Methods("GET")
+	apiRouter.HandleFunc("/schedules", handler.HandleListSchedules).Methods("GET")
+	apiRouter.HandleFunc("/schedules", handler.HandleCreateSchedule).Methods("POST")
+	apiRouter.HandleFunc("/schedules/{id}", handler.HandleGetSchedule).Methods("GET")
+	apiRouter.HandleFunc("/schedules/{id}", handler.HandleUpdateSchedule).Methods("PUT")
+	apiRouter.HandleFunc("/schedules/{id}", handler.HandleDeleteSchedule).Methods("DELETE")
+	apiRouter.HandleFunc("/schedules/{id}/run", handler.HandleRunSchedule).Methods("POST")
+	apiRouter.HandleFunc("/schedules/{id}/history", handler.HandleScheduleHistory).Methods("GET")
+	apiRouter.HandleFunc("/schedules/{id}/snapshots/{name}", handler.HandleScheduleSnapshot).Methods("GET")
+	apiRouter.HandleFunc("/timelapses", handler.HandleListTimelapses).Methods("GET")
+	apiRouter.HandleFunc("/timelapses", handler.HandleCreateTimelapse).Methods("POST")
+	apiRouter.HandleFunc("/timelapses/{id}", handler.HandleGetTimelapse).Methods("GET")
+	apiRouter.HandleFunc("/timelapses/{id}", handler.HandleDeleteTimelapse).Methods("DELETE")
+	apiRouter.HandleFunc("/timelapses/{id}/assemble", handler.HandleAssembleTimelapse).Methods("POST")
+	apiRouter.HandleFunc("/timelapses/{id}/videos", handler.HandleListTimelapseVideos).Methods("GET")
+	apiRouter.HandleFunc("/timelapses/{id}/videos/{videoId}", handler.HandleDownloadTimelapseVideo).Methods("GET")
+	apiRouter.HandleFunc("/timelapses/{id}/videos/{videoId}", handler.HandleDeleteTimelapseVideo).Methods("DELETE")
+	apiRouter.HandleFunc("/events", handler.HandleEvents).Methods("GET")
+	apiRouter.HandleFunc("/health", handler.HandleHealth).Methods("GET")
+	apiRouter.HandleFunc("/devices", handler.HandleListDevices).Methods("GET")
+	apiRouter.HandleFunc("/media", handler.HandleListMedia).Methods("GET")
+	apiRouter.HandleFunc("/media", handler.HandleUploadMedia).Methods("POST")
+	apiRouter.HandleFunc("/media/uploads", handler.HandleCreateUpload).Methods("POST")
+	apiRouter.HandleFunc("/media/uploads/{uploadId}", handler.HandleGetUpload).Methods("GET")
+	apiRouter.HandleFunc("/media/uploads/{uploadId}", handler.HandleWriteUpload).Methods("PATCH")
+	apiRouter.HandleFunc("/media/uploads/{uploadId}", handler.HandleCancelUpload).Methods("DELETE")
+	apiRouter.HandleFunc("/media/{id}", handler.HandleGetMedia).Methods("GET")
+	apiRouter.HandleFunc("/media/{id}", handler.HandleDeleteMedia).Methods("DELETE")
 
 	// Create CORS handler
 	c := cors.New(cors.Options{
 		AllowedOrigins:   []string{"*"},
-		AllowedMethods:   []string{"GET", "POST", "DELETE", "OPTIONS"},
+		AllowedMethods:   []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
 		AllowedHeaders:   []string{"*"},
 		AllowCredentials: true,
 	})
@@ -61,6 +226,9 @@ func main() {
 	stop := make(chan os.Signal, 1)
 	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
 
+	// Everything is loaded, readiness now depends on the critical sources
+	checker.SetReady()
+
 	go func() {
 		log.Printf("Starting server on %s", srv.Addr)
 		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
@@ -0,0 +1,448 @@
+// Package media manages the library of uploaded video files
+package media
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Thivyesh/cameraServiceGo/ids"
+	"github.com/Thivyesh/cameraServiceGo/jsonfile"
+	"github.com/Thivyesh/cameraServiceGo/types"
+	"gocv.io/x/gocv"
+)
+
+// DefaultMaxSize bounds the size of a single video unless configured
+const DefaultMaxSize = 8 << 30
+
+// uploadExpiry is how long an unfinished upload is kept without progress
+const uploadExpiry = 24 * time.Hour
+
+// File name extensions used in the library directory
+const (
+	metadataExt = ".json"
+	partialExt  = ".part"
+)
+
+// extPattern restricts stored file extensions, which are kept so the
+// demuxer can recognise formats without a container header
+var extPattern = regexp.MustCompile(`^\.[a-z0-9]{1,8}$`)
+
+// Errors callers may want to tell apart
+var (
+	ErrNotFound       = errors.New("not found")
+	ErrOffsetMismatch = errors.New("upload offset mismatch")
+	ErrTooLarge       = errors.New("video too large")
+)
+
+// entry is a library item and the file holding it
+type entry struct {
+	Item types.MediaItem `json:"item"` // Item metadata
+	File string          `json:"file"` // Video file name in the library directory
+}
+
+// upload is an unfinished resumable upload
+type upload struct {
+	mu      sync.Mutex       // Serializes chunks
+	info    types.UploadInfo // Progress
+	updated time.Time        // Time of the last chunk
+}
+
+// Library stores uploaded videos in a directory. Files are named after
+// generated IDs; uploaded file names are only kept as metadata and never
+// used as paths.
+type Library struct {
+	dir     string             // Absolute library directory
+	maxSize int64              // Largest accepted video in bytes
+	mu      sync.Mutex         // Protects shared state
+	items   map[string]entry   // Completed videos by media ID
+	uploads map[string]*upload // Unfinished uploads by upload ID
+}
+
+// NewLibrary opens or creates a library in dir accepting videos of up to
+// maxSize bytes, or DefaultMaxSize when zero. Uploads interrupted by a
+// restart are discarded.
+func NewLibrary(dir string, maxSize int64) (*Library, error) {
+	if maxSize < 0 {
+		return nil, fmt.Errorf("maximum video size must not be negative")
+	}
+	if maxSize == 0 {
+		maxSize = DefaultMaxSize
+	}
+
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create media directory: %v", err)
+	}
+
+	l := &Library{
+		dir:     dir,
+		maxSize: maxSize,
+		items:   make(map[string]entry),
+		uploads: make(map[string]*upload),
+	}
+
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range files {
+		switch filepath.Ext(file.Name()) {
+		case partialExt:
+			os.Remove(filepath.Join(dir, file.Name()))
+		case metadataExt:
+			if e, err := l.load(file.Name()); err == nil {
+				l.items[e.Item.ID] = e
+			}
+		}
+	}
+	return l, nil
+}
+
+// load reads an item's metadata and checks that its video exists
+func (l *Library) load(name string) (entry, error) {
+	var e entry
+	data, err := os.ReadFile(filepath.Join(l.dir, name))
+	if err != nil {
+		return e, err
+	}
+	if err := json.Unmarshal(data, &e); err != nil {
+		return e, err
+	}
+	if e.Item.ID+metadataExt != name {
+		return e, fmt.Errorf("metadata %s belongs to %s", name, e.Item.ID)
+	}
+	path, err := l.path(e.File)
+	if err != nil {
+		return e, err
+	}
+	if _, err := os.Stat(path); err != nil {
+		return e, err
+	}
+	return e, nil
+}
+
+// path returns the location of a library file, refusing names that would
+// resolve outside the library directory
+func (l *Library) path(name string) (string, error) {
+	path := filepath.Join(l.dir, name)
+	if name == "" || filepath.Dir(path) != l.dir {
+		return "", fmt.Errorf("invalid media file name: %q", name)
+	}
+	return path, nil
+}
+
+// List returns all videos, most recently uploaded first
+func (l *Library) List() []types.MediaItem {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	items := make([]types.MediaItem, 0, len(l.items))
+	for _, e := range l.items {
+		items = append(items, e.Item)
+	}
+	slices.SortFunc(items, func(a, b types.MediaItem) int {
+		return b.UploadedAt.Compare(a.UploadedAt)
+	})
+	return items
+}
+
+// Get returns a video's metadata
+func (l *Library) Get(id string) (types.MediaItem, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	e, ok := l.items[id]
+	if !ok {
+		return types.MediaItem{}, fmt.Errorf("media %w: %s", ErrNotFound, id)
+	}
+	return e.Item, nil
+}
+
+// Path returns the file of a video for playback
+func (l *Library) Path(id string) (string, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	e, ok := l.items[id]
+	if !ok {
+		return "", fmt.Errorf("media %w: %s", ErrNotFound, id)
+	}
+	return l.path(e.File)
+}
+
+// Delete removes a video and its metadata
+func (l *Library) Delete(id string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	e, ok := l.items[id]
+	if !ok {
+		return fmt.Errorf("media %w: %s", ErrNotFound, id)
+	}
+	if path, err := l.path(e.File); err == nil {
+		os.Remove(path)
+	}
+	os.Remove(filepath.Join(l.dir, id+metadataExt))
+	delete(l.items, id)
+	return nil
+}
+
+// MaxSize returns the largest accepted video in bytes
+func (l *Library) MaxSize() int64 {
+	return l.maxSize
+}
+
+// Add stores a complete video read from r
+func (l *Library) Add(filename string, r io.Reader) (types.MediaItem, error) {
+	partial, err := l.path(ids.New("upload_") + partialExt)
+	if err != nil {
+		return types.MediaItem{}, err
+	}
+	f, err := os.Create(partial)
+	if err != nil {
+		return types.MediaItem{}, err
+	}
+
+	n, err := io.Copy(f, io.LimitReader(r, l.maxSize+1))
+	f.Close()
+	if err == nil && n > l.maxSize {
+		err = fmt.Errorf("%w: the limit is %d bytes", ErrTooLarge, l.maxSize)
+	}
+	if err != nil {
+		os.Remove(partial)
+		return types.MediaItem{}, err
+	}
+	return l.finish(filename, partial)
+}
+
+// CreateUpload starts a resumable upload of size bytes
+func (l *Library) CreateUpload(filename string, size int64) (types.UploadInfo, error) {
+	if size < 1 {
+		return types.UploadInfo{}, fmt.Errorf("size must be at least 1 byte")
+	}
+	if size > l.maxSize {
+		return types.UploadInfo{}, fmt.Errorf("%w: the limit is %d bytes", ErrTooLarge, l.maxSize)
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.expireUploads()
+
+	info := types.UploadInfo{ID: ids.New("upload_"), Filename: displayName(filename), Size: size}
+	partial, err := l.path(info.ID + partialExt)
+	if err != nil {
+		return types.UploadInfo{}, err
+	}
+	f, err := os.Create(partial)
+	if err != nil {
+		return types.UploadInfo{}, err
+	}
+	f.Close()
+
+	l.uploads[info.ID] = &upload{info: info, updated: time.Now()}
+	return info, nil
+}
+
+// GetUpload returns the progress of an upload
+func (l *Library) GetUpload(id string) (types.UploadInfo, error) {
+	u, err := l.upload(id)
+	if err != nil {
+		return types.UploadInfo{}, err
+	}
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	return u.info, nil
+}
+
+// WriteUpload appends a chunk starting at offset. Data beyond the declared
+// size is ignored. When the last byte arrives the video is added to the
+// library and its ID reported in the returned progress.
+func (l *Library) WriteUpload(id string, offset int64, r io.Reader) (types.UploadInfo, error) {
+	u, err := l.upload(id)
+	if err != nil {
+		return types.UploadInfo{}, err
+	}
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	if offset != u.info.Offset {
+		return u.info, fmt.Errorf("%w: expected %d, got %d", ErrOffsetMismatch, u.info.Offset, offset)
+	}
+	partial, err := l.path(u.info.ID + partialExt)
+	if err != nil {
+		return u.info, err
+	}
+	f, err := os.OpenFile(partial, os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		return u.info, err
+	}
+
+	// Keep whatever arrived so an interrupted chunk can be resumed
+	remaining := u.info.Size - u.info.Offset
+	n, err := io.Copy(f, io.LimitReader(r, remaining))
+	f.Close()
+	u.info.Offset += n
+	u.updated = time.Now()
+	if err != nil {
+		return u.info, err
+	}
+	if u.info.Offset < u.info.Size {
+		return u.info, nil
+	}
+
+	l.mu.Lock()
+	delete(l.uploads, id)
+	l.mu.Unlock()
+
+	item, err := l.finish(u.info.Filename, partial)
+	if err != nil {
+		return u.info, err
+	}
+	u.info.MediaID = item.ID
+	return u.info, nil
+}
+
+// CancelUpload discards an unfinished upload
+func (l *Library) CancelUpload(id string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if _, ok := l.uploads[id]; !ok {
+		return fmt.Errorf("upload %w: %s", ErrNotFound, id)
+	}
+	if partial, err := l.path(id + partialExt); err == nil {
+		os.Remove(partial)
+	}
+	delete(l.uploads, id)
+	return nil
+}
+
+func (l *Library) upload(id string) (*upload, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	u, ok := l.uploads[id]
+	if !ok {
+		return nil, fmt.Errorf("upload %w: %s", ErrNotFound, id)
+	}
+	return u, nil
+}
+
+// expireUploads discards uploads without recent progress. The caller must
+// hold the lock.
+func (l *Library) expireUploads() {
+	for id, u := range l.uploads {
+		if u.mu.TryLock() {
+			if time.Since(u.updated) > uploadExpiry {
+				if partial, err := l.path(id + partialExt); err == nil {
+					os.Remove(partial)
+				}
+				delete(l.uploads, id)
+			}
+			u.mu.Unlock()
+		}
+	}
+}
+
+// finish probes a received file and adds it to the library
+func (l *Library) finish(filename, partial string) (types.MediaItem, error) {
+	id := ids.New("media_")
+	file := id + extension(filename)
+	path, err := l.path(file)
+	if err == nil {
+		err = os.Rename(partial, path)
+	}
+	if err != nil {
+		os.Remove(partial)
+		return types.MediaItem{}, err
+	}
+
+	item, err := probe(path)
+	if err != nil {
+		os.Remove(path)
+		return types.MediaItem{}, err
+	}
+	stat, err := os.Stat(path)
+	if err != nil {
+		os.Remove(path)
+		return types.MediaItem{}, err
+	}
+	item.ID = id
+	item.Name = displayName(filename)
+	item.Size = stat.Size()
+	item.UploadedAt = time.Now()
+
+	e := entry{Item: item, File: file}
+	if err := jsonfile.Write(filepath.Join(l.dir, id+metadataExt), e); err != nil {
+		os.Remove(path)
+		return types.MediaItem{}, fmt.Errorf("failed to save media metadata: %v", err)
+	}
+
+	l.mu.Lock()
+	l.items[id] = e
+	l.mu.Unlock()
+	return item, nil
+}
+
+// probe reads the stream properties of a video, rejecting files OpenCV
+// cannot decode
+func probe(path string) (types.MediaItem, error) {
+	vc, err := gocv.OpenVideoCapture(path)
+	if err != nil {
+		if vc != nil {
+			vc.Close()
+		}
+		return types.MediaItem{}, fmt.Errorf("file is not a playable video")
+	}
+	defer vc.Close()
+
+	img := gocv.NewMat()
+	defer img.Close()
+	if !vc.Read(&img) || img.Empty() {
+		return types.MediaItem{}, fmt.Errorf("file is not a playable video")
+	}
+
+	item := types.MediaItem{
+		FPS:    vc.Get(gocv.VideoCaptureFPS),
+		Frames: int(vc.Get(gocv.VideoCaptureFrameCount)),
+		Width:  img.Cols(),
+		Height: img.Rows(),
+		Codec:  strings.TrimRight(vc.CodecString(), "\x00 "),
+	}
+	if item.FPS > 0 && item.Frames > 0 {
+		item.Duration = float64(item.Frames) / item.FPS
+	}
+	return item, nil
+}
+
+// displayName strips any directories from an uploaded file name
+func displayName(filename string) string {
+	name := filepath.Base(strings.ReplaceAll(filename, `\`, "/"))
+	if name == "." || name == "/" {
+		return ""
+	}
+	return name
+}
+
+// extension returns the sanitized extension of an uploaded file name.
+// Extensions the library uses for its own files are replaced, a video
+// stored as <id>.json would be overwritten by its metadata.
+func extension(filename string) string {
+	ext := strings.ToLower(filepath.Ext(displayName(filename)))
+	if !extPattern.MatchString(ext) || ext == metadataExt || ext == partialExt {
+		return ".video"
+	}
+	return ext
+}
@@ -0,0 +1,26 @@
+package media
+
+import "testing"
+
+func TestExtension(t *testing.T) {
+	tests := []struct {
+		filename, want string
+	}{
+		{"clip.mp4", ".mp4"},
+		{"Clip.MKV", ".mkv"},
+		{"clip", ".video"},
+		{"clip.tar.gz", ".gz"},
+		{"clip.mp4 ", ".video"},
+		{"../../etc/clip.avi", ".avi"},
+		{"clip.verylongext", ".video"},
+		// Extensions of the library's own files
+		{"clip.json", ".video"},
+		{"clip.JSON", ".video"},
+		{"clip.part", ".video"},
+	}
+	for _, tt := range tests {
+		if got := extension(tt.filename); got != tt.want {
+			t.Errorf("extension(%q) = %q, want %q", tt.filename, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,220 @@
+// Package metrics exposes service statistics in the Prometheus format. Series
+// are labelled with the source ID so stalled or struggling cameras can be
+// alerted on.
+package metrics
+
+import (
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// namespace prefixes every metric name
+const namespace = "camera"
+
+var (
+	// FramesCaptured counts frames produced by a source
+	FramesCaptured = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "frames_captured_total",
+		Help:      "Frames produced by a source.",
+	}, []string{"source"})
+
+	// LastFrame is the time a source last produced a frame
+	LastFrame = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "last_frame_timestamp_seconds",
+		Help:      "Unix time of the last frame produced by a source.",
+	}, []string{"source"})
+
+	// EncodeDuration measures running the pipeline and JPEG encoding a frame
+	EncodeDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "encode_duration_seconds",
+		Help:      "Time to process and JPEG encode a frame. Frames passed through as JPEG are not measured.",
+		Buckets:   prometheus.ExponentialBuckets(0.001, 2, 12),
+	}, []string{"source"})
+
+	// FrameSize measures the size of encoded frames
+	FrameSize = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "frame_size_bytes",
+		Help:      "Size of JPEG frames produced by a source.",
+		Buckets:   prometheus.ExponentialBuckets(4<<10, 2, 11),
+	}, []string{"source"})
+
+	// SourceDropped counts frames dropped because the source buffer was full
+	SourceDropped = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "source_frames_dropped_total",
+		Help:      "Frames dropped because the frame buffer of a source was full.",
+	}, []string{"source"})
+
+	// SubscriberDropped counts frames not delivered to slow subscribers
+	SubscriberDropped = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "subscriber_frames_dropped_total",
+		Help:      "Frames skipped for subscribers of a source that were not keeping up, summed over subscribers.",
+	}, []string{"source"})
+
+	// RecordingDropped counts frames not buffered or recorded because the
+	// writer was not keeping up
+	RecordingDropped = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "recording_frames_dropped_total",
+		Help:      "Frames of a source not kept for exports or recordings because writing to disk was not keeping up.",
+	}, []string{"source"})
+
+	// Subscribers is the number of subscribers of a source
+	Subscribers = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "subscribers",
+		Help:      "Active frame subscribers of a source, including WebSocket clients and virtual sources.",
+	}, []string{"source"})
+
+	// Reconnects counts successful reconnects of a source
+	Reconnects = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "reconnects_total",
+		Help:      "Times a source was reopened after failing.",
+	}, []string{"source"})
+
+	// ReconnectAttempts counts attempts to reopen a failed source
+	ReconnectAttempts = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "reconnect_attempts_total",
+		Help:      "Attempts to reopen a source after it failed.",
+	}, []string{"source"})
+
+	// WebSocketBytes counts bytes of frames sent to WebSocket clients
+	WebSocketBytes = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "websocket_sent_bytes_total",
+		Help:      "Frame bytes of live video sent to WebSocket clients of a source.",
+	}, []string{"source"})
+
+	// PlaybackWebSocketBytes counts bytes of recorded frames sent to WebSocket
+	// clients. It has no source label, playback may name removed sources.
+	PlaybackWebSocketBytes = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "playback_websocket_sent_bytes_total",
+		Help:      "Frame bytes of recorded footage sent to WebSocket clients.",
+	})
+)
+
+// sourceVecs are the metrics labelled by source that are dropped with it
+var sourceVecs = []interface {
+	DeleteLabelValues(...string) bool
+}{
+	FramesCaptured, LastFrame, EncodeDuration, FrameSize,
+	SourceDropped, SubscriberDropped, RecordingDropped, Subscribers, Reconnects, ReconnectAttempts,
+	WebSocketBytes,
+}
+
+// fpsWindow is the period the capture frame rate is measured over
+const fpsWindow = time.Second
+
+// captureRate reports the frame rate of every source over the last second.
+// It is computed when scraped, so a stalled source drops to zero.
+type captureRate struct {
+	desc   *prometheus.Desc
+	mu     sync.Mutex             // Protects frames
+	frames map[string][]time.Time // Recent capture times by source ID
+}
+
+var fps = &captureRate{
+	desc: prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "", "capture_fps"),
+		"Frames produced by a source per second, measured over the last second.",
+		[]string{"source"}, nil,
+	),
+	frames: make(map[string][]time.Time),
+}
+
+func init() {
+	prometheus.MustRegister(fps)
+}
+
+// FrameCaptured records a frame produced by a source
+func FrameCaptured(sourceID string, size int, at time.Time) {
+	FramesCaptured.WithLabelValues(sourceID).Inc()
+	FrameSize.WithLabelValues(sourceID).Observe(float64(size))
+	LastFrame.WithLabelValues(sourceID).Set(float64(at.UnixNano()) / 1e9)
+
+	fps.mu.Lock()
+	defer fps.mu.Unlock()
+	fps.frames[sourceID] = trimWindow(append(fps.frames[sourceID], at), at)
+}
+
+// trimWindow drops capture times older than the window ending at now
+func trimWindow(times []time.Time, now time.Time) []time.Time {
+	i := 0
+	for i < len(times) && now.Sub(times[i]) > fpsWindow {
+		i++
+	}
+	return times[i:]
+}
+
+func (r *captureRate) Describe(ch chan<- *prometheus.Desc) {
+	ch <- r.desc
+}
+
+func (r *captureRate) Collect(ch chan<- prometheus.Metric) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	for sourceID, times := range r.frames {
+		times = trimWindow(times, now)
+		r.frames[sourceID] = times
+		ch <- prometheus.MustNewConstMetric(r.desc, prometheus.GaugeValue,
+			float64(len(times))/fpsWindow.Seconds(), sourceID)
+	}
+}
+
+// RemoveSource drops the series of a removed source
+func RemoveSource(sourceID string) {
+	for _, vec := range sourceVecs {
+		vec.DeleteLabelValues(sourceID)
+	}
+
+	fps.mu.Lock()
+	defer fps.mu.Unlock()
+	delete(fps.frames, sourceID)
+}
+
+// diskUsage reports the recording disk usage of every source at scrape time
+type diskUsage struct {
+	desc  *prometheus.Desc
+	usage func() (map[string]int64, error)
+}
+
+// RegisterDiskUsage exports the size of recorded footage by source ID as
+// reported by usage on every scrape
+func RegisterDiskUsage(usage func() (map[string]int64, error)) {
+	prometheus.MustRegister(&diskUsage{
+		desc: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "recording", "disk_usage_bytes"),
+			"Size of the recorded segments of a source.",
+			[]string{"source"}, nil,
+		),
+		usage: usage,
+	})
+}
+
+func (d *diskUsage) Describe(ch chan<- *prometheus.Desc) {
+	ch <- d.desc
+}
+
+func (d *diskUsage) Collect(ch chan<- prometheus.Metric) {
+	usage, err := d.usage()
+	if err != nil {
+		ch <- prometheus.NewInvalidMetric(d.desc, err)
+		return
+	}
+	for sourceID, size := range usage {
+		ch <- prometheus.MustNewConstMetric(d.desc, prometheus.GaugeValue, float64(size), sourceID)
+	}
+}
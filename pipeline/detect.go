@@ -0,0 +1,311 @@
+package pipeline
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/color"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/Thivyesh/cameraServiceGo/events"
+	"github.com/Thivyesh/cameraServiceGo/types"
+	"gocv.io/x/gocv"
+)
+
+func init() {
+	Register("detect", newDetect)
+}
+
+// detectOptions configures the object detection stage
+type detectOptions struct {
+	Model      string    `json:"model"`       // Model file (.onnx, .caffemodel, .weights, .pb)
+	Config     string    `json:"config"`      // Network description (.prototxt, .cfg), if required
+	Format     string    `json:"format"`      // Output layout: ssd or yolo
+	Labels     []string  `json:"labels"`      // Class names indexed by class ID
+	LabelsFile string    `json:"labels_file"` // File with one class name per line
+	Classes    []string  `json:"classes"`     // Only report these labels, empty for all
+	Confidence float64   `json:"confidence"`  // Minimum confidence to report
+	NMS        float64   `json:"nms"`         // Non-maximum suppression threshold (yolo)
+	Every      int       `json:"every"`       // Run inference on every Nth frame
+	Width      int       `json:"width"`       // Network input width
+	Height     int       `json:"height"`      // Network input height
+	Scale      float64   `json:"scale"`       // Pixel scale factor
+	Mean       []float64 `json:"mean"`        // Mean subtracted per channel
+	SwapRB     bool      `json:"swap_rb"`     // Swap red and blue channels
+	Draw       bool      `json:"draw"`        // Draw boxes and labels on the frame
+	Color      string    `json:"color"`       // Box color as #RRGGBB
+}
+
+type detectStage struct {
+	opts       detectOptions
+	net        gocv.Net
+	outputs    []string // Output layer names
+	sourceID   string
+	events     *events.Bus
+	color      color.RGBA
+	count      int               // Frames seen, for inference rate
+	detections []types.Detection // Latest inference results
+}
+
+func newDetect(env *Env, options json.RawMessage) (Processor, error) {
+	opts := detectOptions{
+		Format:     "ssd",
+		Confidence: 0.5,
+		NMS:        0.4,
+		Every:      1,
+		Color:      "#00ff00",
+	}
+	if err := decodeOptions(options, &opts); err != nil {
+		return nil, err
+	}
+
+	// Apply format specific input defaults
+	switch opts.Format {
+	case "ssd":
+		if opts.Scale == 0 {
+			opts.Scale = 1.0 / 127.5
+		}
+		if opts.Mean == nil {
+			opts.Mean = []float64{127.5, 127.5, 127.5}
+		}
+		if opts.Width == 0 || opts.Height == 0 {
+			opts.Width, opts.Height = 300, 300
+		}
+	case "yolo":
+		if opts.Scale == 0 {
+			opts.Scale = 1.0 / 255
+		}
+		if opts.Width == 0 || opts.Height == 0 {
+			opts.Width, opts.Height = 416, 416
+		}
+	default:
+		return nil, fmt.Errorf("unsupported format: %s", opts.Format)
+	}
+	if opts.Every < 1 {
+		return nil, fmt.Errorf("every must be at least 1")
+	}
+	if len(opts.Mean) != 0 && len(opts.Mean) != 3 {
+		return nil, fmt.Errorf("mean must have three values")
+	}
+
+	if opts.Model == "" {
+		return nil, fmt.Errorf("model is required")
+	}
+	if _, err := os.Stat(opts.Model); err != nil {
+		return nil, fmt.Errorf("model not found: %v", err)
+	}
+
+	if opts.LabelsFile != "" {
+		labels, err := readLabels(opts.LabelsFile)
+		if err != nil {
+			return nil, err
+		}
+		opts.Labels = labels
+	}
+
+	c, err := ParseColor(opts.Color)
+	if err != nil {
+		return nil, err
+	}
+
+	net := gocv.ReadNet(opts.Model, opts.Config)
+	if net.Empty() {
+		return nil, fmt.Errorf("failed to load model: %s", opts.Model)
+	}
+	net.SetPreferableBackend(gocv.NetBackendDefault)
+	net.SetPreferableTarget(gocv.NetTargetCPU)
+
+	// Collect output layer names for multi-output models
+	var outputs []string
+	names := net.GetLayerNames()
+	for _, id := range net.GetUnconnectedOutLayers() {
+		if id > 0 && id <= len(names) {
+			outputs = append(outputs, names[id-1])
+		}
+	}
+
+	return &detectStage{
+		opts:     opts,
+		net:      net,
+		outputs:  outputs,
+		sourceID: env.SourceID,
+		events:   env.Events,
+		color:    c,
+	}, nil
+}
+
+func (s *detectStage) Process(frame *Frame) error {
+	if s.count%s.opts.Every == 0 {
+		s.detections = s.infer(frame.Mat)
+		if len(s.detections) > 0 && s.events != nil {
+			s.events.Publish(events.AnalyticsDetection, s.sourceID, map[string]interface{}{
+				"frame_id":   frame.Data.ID,
+				"detections": s.detections,
+			})
+		}
+	}
+	s.count++
+
+	frame.SetMetadata("detections", s.detections)
+	if s.opts.Draw {
+		s.draw(&frame.Mat)
+	}
+	return nil
+}
+
+// infer runs the network on an image and returns filtered detections
+func (s *detectStage) infer(img gocv.Mat) []types.Detection {
+	mean := gocv.NewScalar(0, 0, 0, 0)
+	if len(s.opts.Mean) == 3 {
+		mean = gocv.NewScalar(s.opts.Mean[0], s.opts.Mean[1], s.opts.Mean[2], 0)
+	}
+
+	blob := gocv.BlobFromImage(img, s.opts.Scale, image.Pt(s.opts.Width, s.opts.Height),
+		mean, s.opts.SwapRB, false)
+	defer blob.Close()
+	s.net.SetInput(blob, "")
+
+	var results []types.Detection
+	if s.opts.Format == "yolo" {
+		outputs := s.net.ForwardLayers(s.outputs)
+		results = s.parseYOLO(outputs)
+		for _, out := range outputs {
+			out.Close()
+		}
+	} else {
+		out := s.net.Forward("")
+		results = s.parseSSD(out)
+		out.Close()
+	}
+	return results
+}
+
+// parseSSD reads an SSD style [1, 1, N, 7] output blob
+func (s *detectStage) parseSSD(out gocv.Mat) []types.Detection {
+	rows := out.Reshape(1, out.Total()/7)
+	defer rows.Close()
+
+	var results []types.Detection
+	for i := 0; i < rows.Rows(); i++ {
+		confidence := float64(rows.GetFloatAt(i, 2))
+		if confidence < s.opts.Confidence {
+			continue
+		}
+		left := clamp01(float64(rows.GetFloatAt(i, 3)))
+		top := clamp01(float64(rows.GetFloatAt(i, 4)))
+		right := clamp01(float64(rows.GetFloatAt(i, 5)))
+		bottom := clamp01(float64(rows.GetFloatAt(i, 6)))
+		if right <= left || bottom <= top {
+			continue
+		}
+		s.appendDetection(&results, int(rows.GetFloatAt(i, 1)), confidence,
+			types.Box{X: left, Y: top, Width: right - left, Height: bottom - top})
+	}
+	return results
+}
+
+// parseYOLO reads YOLO style outputs where each row holds a normalized
+// center, size, objectness and per-class scores
+func (s *detectStage) parseYOLO(outputs []gocv.Mat) []types.Detection {
+	const scale = 10000 // Fixed point scale for NMS on normalized boxes
+
+	var boxes []types.Box
+	var rects []image.Rectangle
+	var scores []float32
+	var classes []int
+
+	for _, out := range outputs {
+		for i := 0; i < out.Rows(); i++ {
+			classID, best := -1, float32(0)
+			for j := 5; j < out.Cols(); j++ {
+				if score := out.GetFloatAt(i, j); score > best {
+					classID, best = j-5, score
+				}
+			}
+			confidence := out.GetFloatAt(i, 4) * best
+			if classID < 0 || float64(confidence) < s.opts.Confidence {
+				continue
+			}
+
+			cx, cy := float64(out.GetFloatAt(i, 0)), float64(out.GetFloatAt(i, 1))
+			w, h := float64(out.GetFloatAt(i, 2)), float64(out.GetFloatAt(i, 3))
+			box := types.Box{X: clamp01(cx - w/2), Y: clamp01(cy - h/2)}
+			box.Width = clamp01(cx+w/2) - box.X
+			box.Height = clamp01(cy+h/2) - box.Y
+
+			boxes = append(boxes, box)
+			rects = append(rects, image.Rect(int(box.X*scale), int(box.Y*scale),
+				int((box.X+box.Width)*scale), int((box.Y+box.Height)*scale)))
+			scores = append(scores, confidence)
+			classes = append(classes, classID)
+		}
+	}
+	if len(rects) == 0 {
+		return nil
+	}
+
+	var results []types.Detection
+	for _, i := range gocv.NMSBoxes(rects, scores, float32(s.opts.Confidence), float32(s.opts.NMS)) {
+		s.appendDetection(&results, classes[i], float64(scores[i]), boxes[i])
+	}
+	return results
+}
+
+// appendDetection labels a detection and applies the class filter
+func (s *detectStage) appendDetection(results *[]types.Detection, classID int, confidence float64, box types.Box) {
+	label := strconv.Itoa(classID)
+	if classID >= 0 && classID < len(s.opts.Labels) {
+		label = s.opts.Labels[classID]
+	}
+	if len(s.opts.Classes) > 0 && !slices.Contains(s.opts.Classes, label) {
+		return
+	}
+	*results = append(*results, types.Detection{
+		Label:      label,
+		ClassID:    classID,
+		Confidence: confidence,
+		Box:        box,
+	})
+}
+
+// draw renders the latest detections onto the image
+func (s *detectStage) draw(img *gocv.Mat) {
+	cols, rows := float64(img.Cols()), float64(img.Rows())
+	for _, d := range s.detections {
+		rect := image.Rect(int(d.Box.X*cols), int(d.Box.Y*rows),
+			int((d.Box.X+d.Box.Width)*cols), int((d.Box.Y+d.Box.Height)*rows))
+		gocv.Rectangle(img, rect, s.color, 2)
+		text := fmt.Sprintf("%s %.0f%%", d.Label, d.Confidence*100)
+		gocv.PutText(img, text, image.Pt(rect.Min.X, max(rect.Min.Y-5, 12)),
+			gocv.FontHersheySimplex, 0.5, s.color, 1)
+	}
+}
+
+// Close releases the network
+func (s *detectStage) Close() error {
+	return s.net.Close()
+}
+
+// readLabels loads class names, one per line
+func readLabels(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open labels: %v", err)
+	}
+	defer file.Close()
+
+	var labels []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		labels = append(labels, strings.TrimSpace(scanner.Text()))
+	}
+	return labels, scanner.Err()
+}
+
+func clamp01(v float64) float64 {
+	return min(max(v, 0), 1)
+}
@@ -0,0 +1,163 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"fmt"
+	"image"
+	"os"
+
+	"github.com/Thivyesh/cameraServiceGo/types"
+	"gocv.io/x/gocv"
+)
+
+func init() {
+	Register("face_blur", newFaceBlur)
+}
+
+// faceBlurOptions configures the face anonymization stage
+type faceBlurOptions struct {
+	Cascade      string  `json:"cascade"`       // Haar cascade XML file
+	Mode         string  `json:"mode"`          // blur or pixelate
+	ScaleFactor  float64 `json:"scale_factor"`  // Image pyramid scale step
+	MinNeighbors int     `json:"min_neighbors"` // Required neighbouring detections
+	MinSize      int     `json:"min_size"`      // Smallest face in pixels
+	Every        int     `json:"every"`         // Detect on every Nth frame
+	Padding      float64 `json:"padding"`       // Box growth relative to face size
+	Anonymize    *bool   `json:"anonymize"`     // Blur faces, false only reports them
+}
+
+// FaceInfo is the metadata attached by the face_blur stage
+type FaceInfo struct {
+	Count int         `json:"count"` // Number of faces found
+	Boxes []types.Box `json:"boxes"` // Face locations in normalized coordinates
+}
+
+type faceBlurStage struct {
+	opts       faceBlurOptions
+	classifier gocv.CascadeClassifier
+	anonymize  bool              // Whether faces are masked
+	keepRaw    bool              // Whether the unmasked frame is encoded
+	count      int               // Frames seen, for detection rate
+	faces      []image.Rectangle // Latest detections in pixels
+	size       image.Point       // Frame size of the latest detections
+}
+
+func newFaceBlur(env *Env, options json.RawMessage) (Processor, error) {
+	opts := faceBlurOptions{
+		Mode:         "blur",
+		ScaleFactor:  1.1,
+		MinNeighbors: 4,
+		MinSize:      24,
+		Every:        1,
+		Padding:      0.15,
+	}
+	if err := decodeOptions(options, &opts); err != nil {
+		return nil, err
+	}
+	if opts.Mode != "blur" && opts.Mode != "pixelate" {
+		return nil, fmt.Errorf("unsupported mode: %s", opts.Mode)
+	}
+	if opts.ScaleFactor <= 1 {
+		return nil, fmt.Errorf("scale_factor must be greater than 1")
+	}
+	if opts.Every < 1 {
+		return nil, fmt.Errorf("every must be at least 1")
+	}
+
+	if opts.Cascade == "" {
+		return nil, fmt.Errorf("cascade is required")
+	}
+	if _, err := os.Stat(opts.Cascade); err != nil {
+		return nil, fmt.Errorf("cascade not found: %v", err)
+	}
+	classifier := gocv.NewCascadeClassifier()
+	if !classifier.Load(opts.Cascade) {
+		classifier.Close()
+		return nil, fmt.Errorf("failed to load cascade: %s", opts.Cascade)
+	}
+
+	stage := &faceBlurStage{
+		opts:       opts,
+		classifier: classifier,
+		anonymize:  opts.Anonymize == nil || *opts.Anonymize,
+	}
+	stage.keepRaw = stage.anonymize && env.Config.RawAccessToken != ""
+	return stage, nil
+}
+
+func (s *faceBlurStage) Process(frame *Frame) error {
+	size := image.Pt(frame.Mat.Cols(), frame.Mat.Rows())
+	if s.count%s.opts.Every == 0 || size != s.size {
+		s.faces = s.detect(frame.Mat)
+		s.size = size
+	}
+	s.count++
+
+	info := FaceInfo{Count: len(s.faces), Boxes: make([]types.Box, 0, len(s.faces))}
+	for _, face := range s.faces {
+		info.Boxes = append(info.Boxes, types.Box{
+			X:      float64(face.Min.X) / float64(size.X),
+			Y:      float64(face.Min.Y) / float64(size.Y),
+			Width:  float64(face.Dx()) / float64(size.X),
+			Height: float64(face.Dy()) / float64(size.Y),
+		})
+	}
+	frame.SetMetadata("faces", info)
+
+	if !s.anonymize || len(s.faces) == 0 {
+		return nil
+	}
+
+	// Keep an unmasked copy for authorized raw streams
+	if s.keepRaw {
+		buf, err := gocv.IMEncode(".jpg", frame.Mat)
+		if err != nil {
+			return fmt.Errorf("failed to encode raw frame: %v", err)
+		}
+		frame.Data.Raw = buf.GetBytes()
+		buf.Close()
+	}
+
+	frameRect := image.Rect(0, 0, size.X, size.Y)
+	for _, face := range s.faces {
+		// Grow the box so hair and chin are covered too
+		pad := image.Pt(int(float64(face.Dx())*s.opts.Padding), int(float64(face.Dy())*s.opts.Padding))
+		rect := image.Rectangle{Min: face.Min.Sub(pad), Max: face.Max.Add(pad)}.Intersect(frameRect)
+		if rect.Empty() {
+			continue
+		}
+
+		region := frame.Mat.Region(rect)
+		masked := gocv.NewMat()
+		if s.opts.Mode == "pixelate" {
+			pixelate(region, &masked)
+		} else {
+			blur(region, &masked)
+		}
+		masked.CopyTo(&region)
+		masked.Close()
+		region.Close()
+	}
+	return nil
+}
+
+// detect finds faces on an equalized grayscale copy of the image
+func (s *faceBlurStage) detect(img gocv.Mat) []image.Rectangle {
+	gray := gocv.NewMat()
+	defer gray.Close()
+	if img.Channels() == 1 {
+		img.CopyTo(&gray)
+	} else {
+		gocv.CvtColor(img, &gray, gocv.ColorBGRToGray)
+	}
+	gocv.EqualizeHist(gray, &gray)
+
+	minSize := image.Pt(s.opts.MinSize, s.opts.MinSize)
+	return s.classifier.DetectMultiScaleWithParams(gray, s.opts.ScaleFactor,
+		s.opts.MinNeighbors, 0, minSize, image.Pt(0, 0))
+}
+
+// Close releases the classifier
+func (s *faceBlurStage) Close() error {
+	return s.classifier.Close()
+}
@@ -0,0 +1,247 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/color"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Thivyesh/cameraServiceGo/types"
+	"gocv.io/x/gocv"
+)
+
+func init() {
+	Register("osd", newOSD)
+}
+
+// osdOptions configures the on-screen display stage
+type osdOptions struct {
+	// Text template. Supports strftime directives (%Y, %m, %d, %H, %M, %S,
+	// %L, ...) and the placeholders {name}, {id}, {source}, {frame} and
+	// {metadata.<key>}. Newlines start a new line of text.
+	Template   string  `json:"template"`
+	Position   string  `json:"position"`    // top-left, top-right, bottom-left or bottom-right
+	Margin     int     `json:"margin"`      // Distance from the image edge in pixels
+	FontScale  float64 `json:"font_scale"`  // OpenCV font scale
+	Thickness  int     `json:"thickness"`   // Stroke thickness in pixels
+	Color      string  `json:"color"`       // Text color as #RRGGBB
+	Background string  `json:"background"`  // Box color as #RRGGBB, empty for none
+	Padding    int     `json:"padding"`     // Space between text and box edge
+	TimeZone   string  `json:"time_zone"`   // IANA time zone for timestamps
+	LineHeight float64 `json:"line_height"` // Line spacing relative to text height
+}
+
+type osdStage struct {
+	opts       osdOptions
+	name       string         // Camera name for {name}
+	sourceID   string         // Source ID for {id}
+	location   *time.Location // Time zone for strftime directives
+	color      color.RGBA     // Text color
+	background *color.RGBA    // Optional box color
+}
+
+func newOSD(env *Env, options json.RawMessage) (Processor, error) {
+	opts := osdOptions{
+		Template:   "%Y-%m-%d %H:%M:%S {name}",
+		Position:   "top-left",
+		Margin:     10,
+		FontScale:  0.6,
+		Thickness:  1,
+		Color:      "#ffffff",
+		Background: "#000000",
+		Padding:    4,
+		LineHeight: 1.5,
+	}
+	if err := decodeOptions(options, &opts); err != nil {
+		return nil, err
+	}
+
+	switch opts.Position {
+	case "top-left", "top-right", "bottom-left", "bottom-right":
+	default:
+		return nil, fmt.Errorf("unsupported position: %s", opts.Position)
+	}
+	if opts.FontScale <= 0 || opts.Thickness <= 0 {
+		return nil, fmt.Errorf("font_scale and thickness must be positive")
+	}
+
+	stage := &osdStage{
+		opts:     opts,
+		name:     env.Config.Name,
+		sourceID: env.SourceID,
+		location: time.Local,
+	}
+	if stage.name == "" {
+		stage.name = env.SourceID
+	}
+
+	if opts.TimeZone != "" {
+		loc, err := time.LoadLocation(opts.TimeZone)
+		if err != nil {
+			return nil, fmt.Errorf("invalid time zone: %v", err)
+		}
+		stage.location = loc
+	}
+
+	var err error
+	if stage.color, err = ParseColor(opts.Color); err != nil {
+		return nil, err
+	}
+	if opts.Background != "" {
+		bg, err := ParseColor(opts.Background)
+		if err != nil {
+			return nil, err
+		}
+		stage.background = &bg
+	}
+
+	return stage, nil
+}
+
+func (s *osdStage) Process(frame *Frame) error {
+	text := s.render(frame.Data)
+	if text == "" {
+		return nil
+	}
+	lines := strings.Split(text, "\n")
+
+	// Measure the text block
+	font := gocv.FontHersheySimplex
+	width, lineHeight, baseline := 0, 0, 0
+	for _, line := range lines {
+		size, base := gocv.GetTextSizeWithBaseline(line, font, s.opts.FontScale, s.opts.Thickness)
+		width = max(width, size.X)
+		lineHeight = max(lineHeight, size.Y)
+		baseline = max(baseline, base)
+	}
+	step := int(float64(lineHeight) * s.opts.LineHeight)
+	height := step*(len(lines)-1) + lineHeight + baseline
+
+	// Anchor the block to the configured corner
+	pad, margin := s.opts.Padding, s.opts.Margin
+	x, y := margin+pad, margin+pad
+	if strings.HasSuffix(s.opts.Position, "right") {
+		x = frame.Mat.Cols() - margin - pad - width
+	}
+	if strings.HasPrefix(s.opts.Position, "bottom") {
+		y = frame.Mat.Rows() - margin - pad - height
+	}
+
+	if s.background != nil {
+		box := image.Rect(x-pad, y-pad, x+width+pad, y+height+pad)
+		gocv.Rectangle(&frame.Mat, box, *s.background, -1)
+	}
+	for i, line := range lines {
+		origin := image.Pt(x, y+lineHeight+i*step)
+		gocv.PutTextWithParams(&frame.Mat, line, origin, font, s.opts.FontScale,
+			s.color, s.opts.Thickness, gocv.LineAA, false)
+	}
+	return nil
+}
+
+var placeholderPattern = regexp.MustCompile(`\{([a-z_.A-Z0-9]+)\}`)
+
+// render expands the template for a frame
+func (s *osdStage) render(data *types.FrameData) string {
+	text := strftime(s.opts.Template, data.Timestamp.In(s.location))
+	return placeholderPattern.ReplaceAllStringFunc(text, func(match string) string {
+		key := match[1 : len(match)-1]
+		switch key {
+		case "name":
+			return s.name
+		case "id":
+			return s.sourceID
+		case "source":
+			return data.Source
+		case "frame":
+			return strconv.FormatInt(data.ID, 10)
+		}
+		if metaKey, ok := strings.CutPrefix(key, "metadata."); ok {
+			if value, ok := data.Metadata[metaKey]; ok {
+				return fmt.Sprint(value)
+			}
+			return ""
+		}
+		return match
+	})
+}
+
+// strftime formats a time using C strftime style directives
+func strftime(format string, t time.Time) string {
+	var b strings.Builder
+	for i := 0; i < len(format); i++ {
+		c := format[i]
+		if c != '%' || i == len(format)-1 {
+			b.WriteByte(c)
+			continue
+		}
+		i++
+		switch format[i] {
+		case 'Y':
+			b.WriteString(t.Format("2006"))
+		case 'y':
+			b.WriteString(t.Format("06"))
+		case 'm':
+			b.WriteString(t.Format("01"))
+		case 'd':
+			b.WriteString(t.Format("02"))
+		case 'e':
+			b.WriteString(t.Format("_2"))
+		case 'H':
+			b.WriteString(t.Format("15"))
+		case 'I':
+			b.WriteString(t.Format("03"))
+		case 'M':
+			b.WriteString(t.Format("04"))
+		case 'S':
+			b.WriteString(t.Format("05"))
+		case 'L':
+			b.WriteString(fmt.Sprintf("%03d", t.Nanosecond()/int(time.Millisecond)))
+		case 'p':
+			b.WriteString(t.Format("PM"))
+		case 'a':
+			b.WriteString(t.Format("Mon"))
+		case 'A':
+			b.WriteString(t.Format("Monday"))
+		case 'b':
+			b.WriteString(t.Format("Jan"))
+		case 'B':
+			b.WriteString(t.Format("January"))
+		case 'j':
+			b.WriteString(fmt.Sprintf("%03d", t.YearDay()))
+		case 'z':
+			b.WriteString(t.Format("-0700"))
+		case 'Z':
+			b.WriteString(t.Format("MST"))
+		case 'F':
+			b.WriteString(t.Format("2006-01-02"))
+		case 'T':
+			b.WriteString(t.Format("15:04:05"))
+		case 's':
+			b.WriteString(strconv.FormatInt(t.Unix(), 10))
+		case '%':
+			b.WriteByte('%')
+		default:
+			b.WriteByte('%')
+			b.WriteByte(format[i])
+		}
+	}
+	return b.String()
+}
+
+// ParseColor parses a #RRGGBB color string
+func ParseColor(value string) (color.RGBA, error) {
+	hex := strings.TrimPrefix(value, "#")
+	if len(hex) != 6 {
+		return color.RGBA{}, fmt.Errorf("invalid color %q, expected #RRGGBB", value)
+	}
+	rgb, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil {
+		return color.RGBA{}, fmt.Errorf("invalid color %q, expected #RRGGBB", value)
+	}
+	return color.RGBA{R: uint8(rgb >> 16), G: uint8(rgb >> 8), B: uint8(rgb), A: 255}, nil
+}
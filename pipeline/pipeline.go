@@ -0,0 +1,307 @@
+// Package pipeline implements the per-source frame processing stages that run
+// between frame capture and JPEG encoding
+package pipeline
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Thivyesh/cameraServiceGo/events"
+	"github.com/Thivyesh/cameraServiceGo/types"
+	"gocv.io/x/gocv"
+)
+
+// ErrClosed is returned when running a pipeline that has been closed, e.g.
+// because the stages of its source were replaced
+var ErrClosed = errors.New("pipeline is closed")
+
+// Frame is the unit of work passed through the pipeline stages
+type Frame struct {
+	Mat   gocv.Mat         // Image being processed
+	Data  *types.FrameData // Frame metadata, filled in as stages run
+	owned bool             // Whether Mat was allocated by a stage
+}
+
+// NewFrame wraps a captured image. The image remains owned by the caller.
+func NewFrame(img gocv.Mat, data *types.FrameData) *Frame {
+	return &Frame{Mat: img, Data: data}
+}
+
+// Replace swaps the frame image for one produced by a stage
+func (f *Frame) Replace(img gocv.Mat) {
+	if f.owned {
+		f.Mat.Close()
+	}
+	f.Mat = img
+	f.owned = true
+}
+
+// SetMetadata attaches a value to the frame metadata
+func (f *Frame) SetMetadata(key string, value interface{}) {
+	if f.Data.Metadata == nil {
+		f.Data.Metadata = make(map[string]interface{})
+	}
+	f.Data.Metadata[key] = value
+}
+
+// Close releases any image allocated by stages
+func (f *Frame) Close() {
+	if f.owned {
+		f.Mat.Close()
+		f.owned = false
+	}
+}
+
+// Processor is a single pipeline stage. Stages holding native resources
+// should also implement io.Closer.
+type Processor interface {
+	Process(frame *Frame) error
+}
+
+// Env carries per-source context available to every stage
+type Env struct {
+	SourceID string             // Identifier of the source
+	Config   types.SourceConfig // Source configuration
+	Events   *events.Bus        // Bus for stage events
+	Codes    *CodeLog           // History of decoded codes, kept across pipeline changes
+	Health   *HealthMonitor     // Image health status, kept across pipeline changes
+}
+
+// Factory builds a stage from its JSON options
+type Factory func(env *Env, options json.RawMessage) (Processor, error)
+
+var (
+	registryMu sync.RWMutex
+	registry   = make(map[string]Factory)
+)
+
+// Register makes a stage type available to pipelines. It can be used to add
+// custom stages and panics if the type is already registered.
+func Register(stageType string, factory Factory) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	if _, exists := registry[stageType]; exists {
+		panic(fmt.Sprintf("pipeline stage already registered: %s", stageType))
+	}
+	registry[stageType] = factory
+}
+
+// Types returns the registered stage types
+func Types() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// stage is a configured processor with its timing statistics
+type stage struct {
+	config    types.StageConfig // Stage configuration
+	processor Processor         // Stage implementation
+	stats     timer             // Processing time
+}
+
+// Pipeline runs an ordered list of stages and encodes the result
+type Pipeline struct {
+	mu     sync.Mutex // Serializes Run and Close
+	statMu sync.Mutex // Protects timing statistics
+	stages []*stage   // Configured stages in order
+	encode timer      // JPEG encoding time
+	closed bool       // Whether Close released the stages
+}
+
+// New builds a pipeline from stage configurations
+func New(env *Env, configs []types.StageConfig) (*Pipeline, error) {
+	p := &Pipeline{encode: timer{name: "encode"}}
+
+	// Privacy zones are masked before any configured stage runs
+	if zones := env.Config.PrivacyZones; len(zones) > 0 {
+		privacy, err := newPrivacy(zones)
+		if err != nil {
+			return nil, fmt.Errorf("privacy zones: %v", err)
+		}
+		p.stages = append(p.stages, &stage{
+			config:    types.StageConfig{Type: "privacy"},
+			processor: privacy,
+			stats:     timer{name: "privacy"},
+		})
+	}
+
+	for i, config := range configs {
+		registryMu.RLock()
+		factory, ok := registry[config.Type]
+		registryMu.RUnlock()
+		if !ok {
+			p.Close()
+			return nil, fmt.Errorf("stage %d: unknown stage type: %s", i, config.Type)
+		}
+
+		processor, err := factory(env, config.Options)
+		if err != nil {
+			p.Close()
+			return nil, fmt.Errorf("stage %d (%s): %v", i, config.Type, err)
+		}
+		p.stages = append(p.stages, &stage{
+			config:    config,
+			processor: processor,
+			stats:     timer{name: config.Type},
+		})
+	}
+	return p, nil
+}
+
+// Run passes a frame through all stages and returns the JPEG encoded result
+func (p *Pipeline) Run(frame *Frame) ([]byte, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	// Encoding without the released stages could skip privacy masking
+	if p.closed {
+		return nil, ErrClosed
+	}
+	if err := p.process(frame); err != nil {
+		return nil, err
+	}
+
+	// Encode frame to JPEG
+	start := time.Now()
+	buf, err := gocv.IMEncode(".jpg", frame.Mat)
+	p.record(&p.encode, start, err)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode frame: %v", err)
+	}
+
+	// Convert NativeByteBuffer to []byte
+	frameBytes := buf.GetBytes()
+	buf.Close()
+
+	return frameBytes, nil
+}
+
+// Process passes a frame through all stages without encoding it
+func (p *Pipeline) Process(frame *Frame) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return ErrClosed
+	}
+	return p.process(frame)
+}
+
+func (p *Pipeline) process(frame *Frame) error {
+	for _, st := range p.stages {
+		start := time.Now()
+		err := st.processor.Process(frame)
+		p.record(&st.stats, start, err)
+		if err != nil {
+			return fmt.Errorf("stage %s: %v", st.config.Type, err)
+		}
+	}
+	return nil
+}
+
+// record adds a timing sample under the statistics lock
+func (p *Pipeline) record(t *timer, start time.Time, err error) {
+	p.statMu.Lock()
+	defer p.statMu.Unlock()
+	t.add(time.Since(start), err)
+}
+
+// Empty reports whether the pipeline has no stages. A closed pipeline is
+// not empty, frames must not bypass the stages it had.
+func (p *Pipeline) Empty() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return !p.closed && len(p.stages) == 0
+}
+
+// Stats returns timing statistics for each stage followed by encoding
+func (p *Pipeline) Stats() []types.StageStats {
+	p.statMu.Lock()
+	defer p.statMu.Unlock()
+
+	stats := make([]types.StageStats, 0, len(p.stages)+1)
+	for _, st := range p.stages {
+		stats = append(stats, st.stats.snapshot())
+	}
+	return append(stats, p.encode.snapshot())
+}
+
+// Close releases native resources held by stages. Later runs fail with
+// ErrClosed.
+func (p *Pipeline) Close() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.closed {
+		return
+	}
+	p.closed = true
+	for _, st := range p.stages {
+		if closer, ok := st.processor.(io.Closer); ok {
+			closer.Close()
+		}
+	}
+}
+
+// timer accumulates durations for a stage
+type timer struct {
+	name   string        // Stage type reported in statistics
+	frames int64         // Number of samples
+	errors int64         // Number of failed invocations
+	last   time.Duration // Most recent sample
+	total  time.Duration // Sum of all samples
+	max    time.Duration // Largest sample
+}
+
+func (t *timer) add(d time.Duration, err error) {
+	t.frames++
+	if err != nil {
+		t.errors++
+	}
+	t.last = d
+	t.total += d
+	if d > t.max {
+		t.max = d
+	}
+}
+
+func (t *timer) snapshot() types.StageStats {
+	stats := types.StageStats{
+		Type:   t.name,
+		Frames: t.frames,
+		Errors: t.errors,
+		LastMs: milliseconds(t.last),
+		MaxMs:  milliseconds(t.max),
+	}
+	if t.frames > 0 {
+		stats.AvgMs = milliseconds(t.total) / float64(t.frames)
+	}
+	return stats
+}
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+// decodeOptions unmarshals stage options, allowing them to be omitted
+func decodeOptions(options json.RawMessage, v interface{}) error {
+	if len(options) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(options, v); err != nil {
+		return fmt.Errorf("invalid options: %v", err)
+	}
+	return nil
+}
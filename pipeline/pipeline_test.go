@@ -0,0 +1,44 @@
+package pipeline
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Thivyesh/cameraServiceGo/types"
+	"gocv.io/x/gocv"
+)
+
+func TestClosedPipeline(t *testing.T) {
+	p, err := New(&Env{SourceID: "cam"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !p.Empty() {
+		t.Error("pipeline without stages is not empty")
+	}
+
+	p.Close()
+	p.Close()
+	if p.Empty() {
+		t.Error("closed pipeline reports empty, frames would bypass its stages")
+	}
+	frame := NewFrame(gocv.NewMat(), &types.FrameData{})
+	if _, err := p.Run(frame); !errors.Is(err, ErrClosed) {
+		t.Errorf("Run on a closed pipeline returned %v, want ErrClosed", err)
+	}
+	if err := p.Process(frame); !errors.Is(err, ErrClosed) {
+		t.Errorf("Process on a closed pipeline returned %v, want ErrClosed", err)
+	}
+}
+
+func TestHealthMonitorClear(t *testing.T) {
+	m := NewHealthMonitor()
+	m.set(types.ImageHealth{Alerts: []string{AlertFrozen}})
+	if health := m.Health(); health == nil || len(health.Alerts) != 1 {
+		t.Fatalf("health = %+v, want the frozen alert", health)
+	}
+	m.Clear()
+	if health := m.Health(); health != nil {
+		t.Errorf("health = %+v after clearing", health)
+	}
+}
@@ -0,0 +1,181 @@
+package pipeline
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+
+	"github.com/Thivyesh/cameraServiceGo/types"
+	"gocv.io/x/gocv"
+)
+
+// ValidatePrivacyZones checks zone geometry and masking modes
+func ValidatePrivacyZones(zones []types.PrivacyZone) error {
+	seen := make(map[string]bool)
+	for i, zone := range zones {
+		if zone.ID == "" {
+			return fmt.Errorf("zone %d: id is required", i)
+		}
+		if seen[zone.ID] {
+			return fmt.Errorf("duplicate zone id: %s", zone.ID)
+		}
+		seen[zone.ID] = true
+
+		if len(zone.Points) < 3 {
+			return fmt.Errorf("zone %s: at least three points are required", zone.ID)
+		}
+		for _, pt := range zone.Points {
+			if pt.X < 0 || pt.X > 1 || pt.Y < 0 || pt.Y > 1 {
+				return fmt.Errorf("zone %s: points must lie within normalized bounds 0..1", zone.ID)
+			}
+		}
+		switch zone.Mode {
+		case "", "fill", "pixelate", "blur":
+		default:
+			return fmt.Errorf("zone %s: unsupported mode: %s", zone.ID, zone.Mode)
+		}
+		if zone.Color != "" {
+			if _, err := ParseColor(zone.Color); err != nil {
+				return fmt.Errorf("zone %s: %v", zone.ID, err)
+			}
+		}
+	}
+	return nil
+}
+
+// privacyStage masks privacy zones. It is always the first stage of a
+// pipeline so zones stay anchored to the captured image.
+type privacyStage struct {
+	zones []types.PrivacyZone
+	size  image.Point   // Frame size the cached masks were built for
+	masks []privacyMask // Cached masks per zone
+}
+
+// privacyMask is a zone rasterized for a particular frame size
+type privacyMask struct {
+	mode   string          // Masking mode
+	color  color.RGBA      // Fill color
+	points [][]image.Point // Polygon in pixel coordinates
+	bounds image.Rectangle // Bounding box of the polygon
+	mask   gocv.Mat        // Polygon mask cropped to bounds
+}
+
+func newPrivacy(zones []types.PrivacyZone) (*privacyStage, error) {
+	if err := ValidatePrivacyZones(zones); err != nil {
+		return nil, err
+	}
+	return &privacyStage{zones: zones}, nil
+}
+
+func (s *privacyStage) Process(frame *Frame) error {
+	size := image.Pt(frame.Mat.Cols(), frame.Mat.Rows())
+	if size != s.size {
+		s.rasterize(size)
+	}
+
+	for _, m := range s.masks {
+		if m.bounds.Empty() {
+			continue
+		}
+		if m.mode == "fill" {
+			pv := gocv.NewPointsVectorFromPoints(m.points)
+			gocv.FillPoly(&frame.Mat, pv, m.color)
+			pv.Close()
+			continue
+		}
+
+		region := frame.Mat.Region(m.bounds)
+		masked := gocv.NewMat()
+		if m.mode == "pixelate" {
+			pixelate(region, &masked)
+		} else {
+			blur(region, &masked)
+		}
+		masked.CopyToWithMask(&region, m.mask)
+		masked.Close()
+		region.Close()
+	}
+	return nil
+}
+
+// rasterize converts zones to pixel masks for a frame size
+func (s *privacyStage) rasterize(size image.Point) {
+	s.Close()
+	s.size = size
+	frameRect := image.Rect(0, 0, size.X, size.Y)
+
+	for _, zone := range s.zones {
+		m := privacyMask{mode: zone.Mode, color: color.RGBA{A: 255}}
+		if m.mode == "" {
+			m.mode = "fill"
+		}
+		if zone.Color != "" {
+			m.color, _ = ParseColor(zone.Color)
+		}
+
+		polygon := make([]image.Point, len(zone.Points))
+		for i, pt := range zone.Points {
+			polygon[i] = image.Pt(
+				int(math.Round(pt.X*float64(size.X))),
+				int(math.Round(pt.Y*float64(size.Y))),
+			)
+			m.bounds = m.bounds.Union(image.Rectangle{Min: polygon[i], Max: polygon[i].Add(image.Pt(1, 1))})
+		}
+		m.points = [][]image.Point{polygon}
+		m.bounds = m.bounds.Intersect(frameRect)
+
+		if m.mode != "fill" && !m.bounds.Empty() {
+			// Draw the polygon relative to its bounding box
+			local := make([]image.Point, len(polygon))
+			for i, pt := range polygon {
+				local[i] = pt.Sub(m.bounds.Min)
+			}
+			m.mask = gocv.Zeros(m.bounds.Dy(), m.bounds.Dx(), gocv.MatTypeCV8UC1)
+			pv := gocv.NewPointsVectorFromPoints([][]image.Point{local})
+			gocv.FillPoly(&m.mask, pv, color.RGBA{R: 255, G: 255, B: 255, A: 255})
+			pv.Close()
+		}
+		s.masks = append(s.masks, m)
+	}
+}
+
+// Close releases cached masks
+func (s *privacyStage) Close() error {
+	for _, m := range s.masks {
+		if m.mode != "fill" && !m.bounds.Empty() {
+			m.mask.Close()
+		}
+	}
+	s.masks = nil
+	return nil
+}
+
+// Pixelation block size: pixelBlocks blocks across the shorter side of a
+// region, but never blocks smaller than minPixelBlock pixels
+const (
+	pixelBlocks   = 12
+	minPixelBlock = 4
+)
+
+// pixelate replaces a region with coarse blocks
+func pixelate(src gocv.Mat, dst *gocv.Mat) {
+	cols, rows := src.Cols(), src.Rows()
+	small := gocv.NewMat()
+	defer small.Close()
+	gocv.Resize(src, &small, pixelGrid(cols, rows), 0, 0, gocv.InterpolationLinear)
+	gocv.Resize(small, dst, image.Pt(cols, rows), 0, 0, gocv.InterpolationNearestNeighbor)
+}
+
+// pixelGrid returns the number of square blocks a region is pixelated into,
+// at least one and never more than the region has pixels
+func pixelGrid(cols, rows int) image.Point {
+	size := max(minPixelBlock, min(cols, rows)/pixelBlocks)
+	return image.Pt(max(1, min(cols, cols/size)), max(1, min(rows, rows/size)))
+}
+
+// blur applies a strong blur scaled to the region size
+func blur(src gocv.Mat, dst *gocv.Mat) {
+	k := max(src.Cols(), src.Rows())/4 | 1
+	gocv.GaussianBlur(src, dst, image.Pt(k, k), 0, 0, gocv.BorderDefault)
+}
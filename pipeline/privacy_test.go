@@ -0,0 +1,32 @@
+package pipeline
+
+import (
+	"image"
+	"testing"
+)
+
+func TestPixelGrid(t *testing.T) {
+	tests := []struct {
+		cols, rows int
+		want       image.Point
+	}{
+		{120, 120, image.Pt(12, 12)},
+		{1920, 240, image.Pt(96, 12)},
+		{240, 1920, image.Pt(12, 96)},
+		// Narrow regions use the minimum block size instead of upscaling
+		{8, 200, image.Pt(2, 50)},
+		{200, 8, image.Pt(50, 2)},
+		{30, 30, image.Pt(7, 7)},
+		{3, 3, image.Pt(1, 1)},
+		{1, 1, image.Pt(1, 1)},
+	}
+	for _, tt := range tests {
+		got := pixelGrid(tt.cols, tt.rows)
+		if got != tt.want {
+			t.Errorf("pixelGrid(%d, %d) = %v, want %v", tt.cols, tt.rows, got, tt.want)
+		}
+		if got.X > tt.cols || got.Y > tt.rows {
+			t.Errorf("pixelGrid(%d, %d) = %v upscales the region", tt.cols, tt.rows, got)
+		}
+	}
+}
@@ -0,0 +1,175 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/Thivyesh/cameraServiceGo/events"
+	"github.com/Thivyesh/cameraServiceGo/types"
+	"gocv.io/x/gocv"
+)
+
+func init() {
+	Register("qrcode", newQRCode)
+}
+
+// CodeLog keeps a bounded history of decoded codes for a source and
+// suppresses repeated reads of the same payload
+type CodeLog struct {
+	mu       sync.Mutex           // Protects shared state
+	reads    []types.CodeRead     // Reads in arrival order
+	size     int                  // Maximum number of reads kept
+	lastSeen map[string]time.Time // Latest sighting per payload
+}
+
+// NewCodeLog creates a code history holding up to size reads
+func NewCodeLog(size int) *CodeLog {
+	return &CodeLog{
+		size:     size,
+		lastSeen: make(map[string]time.Time),
+	}
+}
+
+// Add records a read unless the same payload was seen within the window.
+// It reports whether the read was recorded.
+func (l *CodeLog) Add(read types.CodeRead, window time.Duration) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	last, seen := l.lastSeen[read.Payload]
+	l.lastSeen[read.Payload] = read.Timestamp
+	if seen && read.Timestamp.Sub(last) < window {
+		return false
+	}
+
+	// Forget payloads that have been out of view for a while
+	if len(l.lastSeen) > l.size {
+		for payload, t := range l.lastSeen {
+			if read.Timestamp.Sub(t) >= window {
+				delete(l.lastSeen, payload)
+			}
+		}
+	}
+
+	l.reads = append(l.reads, read)
+	if len(l.reads) > l.size {
+		l.reads = append([]types.CodeRead(nil), l.reads[len(l.reads)-l.size:]...)
+	}
+	return true
+}
+
+// List returns reads newer than since, newest first, up to limit entries
+func (l *CodeLog) List(since time.Time, limit int) []types.CodeRead {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	reads := make([]types.CodeRead, 0)
+	for i := len(l.reads) - 1; i >= 0; i-- {
+		if !l.reads[i].Timestamp.After(since) || (limit > 0 && len(reads) >= limit) {
+			break
+		}
+		reads = append(reads, l.reads[i])
+	}
+	return reads
+}
+
+// qrCodeOptions configures the QR code stage
+type qrCodeOptions struct {
+	Every    int `json:"every"`     // Scan every Nth frame
+	WindowMs int `json:"window_ms"` // Suppress repeated payloads seen within this window
+}
+
+type qrCodeStage struct {
+	opts     qrCodeOptions
+	detector gocv.QRCodeDetector
+	sourceID string
+	events   *events.Bus
+	codes    *CodeLog
+	count    int // Frames seen, for scan rate
+}
+
+func newQRCode(env *Env, options json.RawMessage) (Processor, error) {
+	opts := qrCodeOptions{Every: 1, WindowMs: 5000}
+	if err := decodeOptions(options, &opts); err != nil {
+		return nil, err
+	}
+	if opts.Every < 1 {
+		return nil, fmt.Errorf("every must be at least 1")
+	}
+	if opts.WindowMs < 0 {
+		return nil, fmt.Errorf("window_ms must not be negative")
+	}
+
+	codes := env.Codes
+	if codes == nil {
+		codes = NewCodeLog(100)
+	}
+	return &qrCodeStage{
+		opts:     opts,
+		detector: gocv.NewQRCodeDetector(),
+		sourceID: env.SourceID,
+		events:   env.Events,
+		codes:    codes,
+	}, nil
+}
+
+func (s *qrCodeStage) Process(frame *Frame) error {
+	s.count++
+	if (s.count-1)%s.opts.Every != 0 {
+		return nil
+	}
+
+	var decoded []string
+	var straight []gocv.Mat
+	points := gocv.NewMat()
+	defer points.Close()
+	found := s.detector.DetectAndDecodeMulti(frame.Mat, &decoded, &points, &straight)
+	for _, m := range straight {
+		m.Close()
+	}
+	if !found {
+		return nil
+	}
+
+	cols, rows := float64(frame.Mat.Cols()), float64(frame.Mat.Rows())
+	window := time.Duration(s.opts.WindowMs) * time.Millisecond
+	var reads []types.CodeRead
+	for i, payload := range decoded {
+		// Codes that were located but could not be decoded are skipped
+		if payload == "" {
+			continue
+		}
+
+		read := types.CodeRead{
+			Payload:   payload,
+			FrameID:   frame.Data.ID,
+			Timestamp: frame.Data.Timestamp,
+		}
+		if i < points.Rows() {
+			for j := 0; j < 4 && j < points.Cols(); j++ {
+				pt := points.GetVecfAt(i, j)
+				read.Points = append(read.Points, types.Point{
+					X: clamp01(float64(pt[0]) / cols),
+					Y: clamp01(float64(pt[1]) / rows),
+				})
+			}
+		}
+		reads = append(reads, read)
+
+		if s.codes.Add(read, window) && s.events != nil {
+			s.events.Publish(events.AnalyticsCode, s.sourceID, read)
+		}
+	}
+
+	if len(reads) > 0 {
+		frame.SetMetadata("codes", reads)
+	}
+	return nil
+}
+
+// Close releases the detector
+func (s *qrCodeStage) Close() error {
+	return s.detector.Close()
+}
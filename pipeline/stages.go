@@ -0,0 +1,294 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"fmt"
+	"image"
+	"math"
+
+	"gocv.io/x/gocv"
+)
+
+func init() {
+	Register("resize", newResize)
+	Register("crop", newCrop)
+	Register("rotate", newRotate)
+	Register("flip", newFlip)
+	Register("color", newColor)
+	Register("denoise", newDenoise)
+}
+
+// resizeOptions configures the resize stage. Either a target size or a scale
+// factor must be given; a missing width or height preserves the aspect ratio.
+type resizeOptions struct {
+	Width  int     `json:"width"`
+	Height int     `json:"height"`
+	Scale  float64 `json:"scale"`
+}
+
+type resizeStage struct {
+	opts resizeOptions
+}
+
+func newResize(env *Env, options json.RawMessage) (Processor, error) {
+	var opts resizeOptions
+	if err := decodeOptions(options, &opts); err != nil {
+		return nil, err
+	}
+	if opts.Width < 0 || opts.Height < 0 || opts.Scale < 0 {
+		return nil, fmt.Errorf("size and scale must not be negative")
+	}
+	if opts.Width == 0 && opts.Height == 0 && opts.Scale == 0 {
+		return nil, fmt.Errorf("width, height or scale is required")
+	}
+	return &resizeStage{opts: opts}, nil
+}
+
+func (s *resizeStage) Process(frame *Frame) error {
+	cols, rows := frame.Mat.Cols(), frame.Mat.Rows()
+	width, height := s.opts.Width, s.opts.Height
+	switch {
+	case s.opts.Scale > 0:
+		width = int(float64(cols) * s.opts.Scale)
+		height = int(float64(rows) * s.opts.Scale)
+	case width == 0:
+		width = cols * height / rows
+	case height == 0:
+		height = rows * width / cols
+	}
+	if width < 1 || height < 1 {
+		return fmt.Errorf("invalid target size %dx%d", width, height)
+	}
+
+	interp := gocv.InterpolationLinear
+	if width < cols {
+		interp = gocv.InterpolationArea
+	}
+
+	dst := gocv.NewMat()
+	gocv.Resize(frame.Mat, &dst, image.Pt(width, height), 0, 0, interp)
+	frame.Replace(dst)
+	return nil
+}
+
+// Rect is a rectangle in normalized coordinates, where 0,0 is the top-left
+// and 1,1 the bottom-right corner of the image
+type Rect struct {
+	X      float64 `json:"x"`
+	Y      float64 `json:"y"`
+	Width  float64 `json:"width"`
+	Height float64 `json:"height"`
+}
+
+// Validate checks that the rectangle lies within the image
+func (r Rect) Validate() error {
+	if r.X < 0 || r.Y < 0 || r.Width <= 0 || r.Height <= 0 ||
+		r.X+r.Width > 1 || r.Y+r.Height > 1 {
+		return fmt.Errorf("rectangle must lie within normalized bounds 0..1")
+	}
+	return nil
+}
+
+// Pixels converts the rectangle to pixel coordinates for an image size
+func (r Rect) Pixels(cols, rows int) image.Rectangle {
+	rect := image.Rect(
+		int(math.Round(r.X*float64(cols))),
+		int(math.Round(r.Y*float64(rows))),
+		int(math.Round((r.X+r.Width)*float64(cols))),
+		int(math.Round((r.Y+r.Height)*float64(rows))),
+	)
+	return rect.Intersect(image.Rect(0, 0, cols, rows))
+}
+
+type cropStage struct {
+	rect Rect
+}
+
+func newCrop(env *Env, options json.RawMessage) (Processor, error) {
+	var rect Rect
+	if err := decodeOptions(options, &rect); err != nil {
+		return nil, err
+	}
+	if err := rect.Validate(); err != nil {
+		return nil, err
+	}
+	return &cropStage{rect: rect}, nil
+}
+
+func (s *cropStage) Process(frame *Frame) error {
+	rect := s.rect.Pixels(frame.Mat.Cols(), frame.Mat.Rows())
+	if rect.Empty() {
+		return fmt.Errorf("crop region is empty")
+	}
+	region := frame.Mat.Region(rect)
+	defer region.Close()
+	frame.Replace(region.Clone())
+	return nil
+}
+
+// rotateOptions configures the rotate stage. Angles are in degrees clockwise.
+type rotateOptions struct {
+	Angle float64 `json:"angle"`
+}
+
+type rotateStage struct {
+	angle float64
+}
+
+func newRotate(env *Env, options json.RawMessage) (Processor, error) {
+	var opts rotateOptions
+	if err := decodeOptions(options, &opts); err != nil {
+		return nil, err
+	}
+	angle := math.Mod(opts.Angle, 360)
+	if angle < 0 {
+		angle += 360
+	}
+	return &rotateStage{angle: angle}, nil
+}
+
+func (s *rotateStage) Process(frame *Frame) error {
+	if s.angle == 0 {
+		return nil
+	}
+
+	dst := gocv.NewMat()
+	switch s.angle {
+	case 90:
+		gocv.Rotate(frame.Mat, &dst, gocv.Rotate90Clockwise)
+	case 180:
+		gocv.Rotate(frame.Mat, &dst, gocv.Rotate180Clockwise)
+	case 270:
+		gocv.Rotate(frame.Mat, &dst, gocv.Rotate90CounterClockwise)
+	default:
+		// Arbitrary angles keep the original frame size
+		size := image.Pt(frame.Mat.Cols(), frame.Mat.Rows())
+		matrix := gocv.GetRotationMatrix2D(image.Pt(size.X/2, size.Y/2), -s.angle, 1)
+		gocv.WarpAffine(frame.Mat, &dst, matrix, size)
+		matrix.Close()
+	}
+	frame.Replace(dst)
+	return nil
+}
+
+// flipOptions configures the flip stage
+type flipOptions struct {
+	Mode string `json:"mode"` // horizontal, vertical or both
+}
+
+type flipStage struct {
+	code int
+}
+
+func newFlip(env *Env, options json.RawMessage) (Processor, error) {
+	opts := flipOptions{Mode: "horizontal"}
+	if err := decodeOptions(options, &opts); err != nil {
+		return nil, err
+	}
+	switch opts.Mode {
+	case "horizontal":
+		return &flipStage{code: 1}, nil
+	case "vertical":
+		return &flipStage{code: 0}, nil
+	case "both":
+		return &flipStage{code: -1}, nil
+	default:
+		return nil, fmt.Errorf("unsupported flip mode: %s", opts.Mode)
+	}
+}
+
+func (s *flipStage) Process(frame *Frame) error {
+	dst := gocv.NewMat()
+	gocv.Flip(frame.Mat, &dst, s.code)
+	frame.Replace(dst)
+	return nil
+}
+
+// colorOptions configures the color conversion stage
+type colorOptions struct {
+	Conversion string `json:"conversion"` // gray, rgb, hsv, lab or ycrcb
+}
+
+var colorConversions = map[string]gocv.ColorConversionCode{
+	"gray":  gocv.ColorBGRToGray,
+	"rgb":   gocv.ColorBGRToRGB,
+	"hsv":   gocv.ColorBGRToHSV,
+	"lab":   gocv.ColorBGRToLab,
+	"ycrcb": gocv.ColorBGRToYCrCb,
+}
+
+type colorStage struct {
+	code gocv.ColorConversionCode
+}
+
+func newColor(env *Env, options json.RawMessage) (Processor, error) {
+	opts := colorOptions{Conversion: "gray"}
+	if err := decodeOptions(options, &opts); err != nil {
+		return nil, err
+	}
+	code, ok := colorConversions[opts.Conversion]
+	if !ok {
+		return nil, fmt.Errorf("unsupported color conversion: %s", opts.Conversion)
+	}
+	return &colorStage{code: code}, nil
+}
+
+func (s *colorStage) Process(frame *Frame) error {
+	if frame.Mat.Channels() != 3 {
+		return fmt.Errorf("color conversion requires a 3 channel image")
+	}
+	dst := gocv.NewMat()
+	gocv.CvtColor(frame.Mat, &dst, s.code)
+	frame.Replace(dst)
+	return nil
+}
+
+// denoiseOptions configures the denoise stage
+type denoiseOptions struct {
+	Method   string  `json:"method"`   // gaussian, median, bilateral or nlmeans
+	Kernel   int     `json:"kernel"`   // Kernel size or filter diameter
+	Strength float64 `json:"strength"` // Sigma or filter strength
+}
+
+type denoiseStage struct {
+	opts denoiseOptions
+}
+
+func newDenoise(env *Env, options json.RawMessage) (Processor, error) {
+	opts := denoiseOptions{Method: "gaussian", Kernel: 5, Strength: 3}
+	if err := decodeOptions(options, &opts); err != nil {
+		return nil, err
+	}
+	switch opts.Method {
+	case "gaussian", "median":
+		if opts.Kernel < 1 || opts.Kernel%2 == 0 {
+			return nil, fmt.Errorf("kernel must be a positive odd number")
+		}
+	case "bilateral", "nlmeans":
+	default:
+		return nil, fmt.Errorf("unsupported denoise method: %s", opts.Method)
+	}
+	return &denoiseStage{opts: opts}, nil
+}
+
+func (s *denoiseStage) Process(frame *Frame) error {
+	dst := gocv.NewMat()
+	switch s.opts.Method {
+	case "gaussian":
+		k := image.Pt(s.opts.Kernel, s.opts.Kernel)
+		gocv.GaussianBlur(frame.Mat, &dst, k, s.opts.Strength, s.opts.Strength, gocv.BorderDefault)
+	case "median":
+		gocv.MedianBlur(frame.Mat, &dst, s.opts.Kernel)
+	case "bilateral":
+		gocv.BilateralFilter(frame.Mat, &dst, s.opts.Kernel, s.opts.Strength*25, s.opts.Strength*25)
+	case "nlmeans":
+		h := float32(s.opts.Strength)
+		if frame.Mat.Channels() == 3 {
+			gocv.FastNlMeansDenoisingColoredWithParams(frame.Mat, &dst, h, h, 7, 21)
+		} else {
+			gocv.FastNlMeansDenoisingWithParams(frame.Mat, &dst, h, 7, 21)
+		}
+	}
+	frame.Replace(dst)
+	return nil
+}
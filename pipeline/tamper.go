@@ -0,0 +1,272 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"fmt"
+	"image"
+	"slices"
+	"sync"
+	"sync/atomic"
+
+	"github.com/Thivyesh/cameraServiceGo/events"
+	"github.com/Thivyesh/cameraServiceGo/types"
+	"gocv.io/x/gocv"
+)
+
+func init() {
+	Register("tamper", newTamper)
+}
+
+// Tamper alert names
+const (
+	AlertTooDark      = "too_dark"
+	AlertTooBright    = "too_bright"
+	AlertDefocused    = "defocused"
+	AlertFrozen       = "frozen"
+	AlertSceneChanged = "scene_changed"
+)
+
+// HealthMonitor holds the latest image health of a source
+type HealthMonitor struct {
+	mu      sync.RWMutex       // Protects health
+	health  *types.ImageHealth // Latest metrics, nil until first evaluation
+	resetRf atomic.Bool        // Requests a new scene reference
+}
+
+// NewHealthMonitor creates an empty health monitor
+func NewHealthMonitor() *HealthMonitor {
+	return &HealthMonitor{}
+}
+
+// Health returns a copy of the latest metrics, or nil if none were computed
+func (m *HealthMonitor) Health() *types.ImageHealth {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if m.health == nil {
+		return nil
+	}
+	health := *m.health
+	health.Alerts = slices.Clone(m.health.Alerts)
+	return &health
+}
+
+// ResetReference makes the next evaluated frame the scene reference
+func (m *HealthMonitor) ResetReference() {
+	m.resetRf.Store(true)
+}
+
+// Clear forgets the latest metrics once no stage evaluates them anymore
+func (m *HealthMonitor) Clear() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.health = nil
+}
+
+func (m *HealthMonitor) set(health types.ImageHealth) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.health = &health
+}
+
+// tamperOptions configures the tamper detection stage
+type tamperOptions struct {
+	Every        int     `json:"every"`         // Evaluate every Nth frame
+	Width        int     `json:"width"`         // Analysis width in pixels
+	MinBright    float64 `json:"min_bright"`    // Mean brightness below this is too dark
+	MaxBright    float64 `json:"max_bright"`    // Mean brightness above this is too bright
+	MinSharp     float64 `json:"min_sharpness"` // Laplacian variance below this is defocused
+	FrozenFrames int     `json:"frozen_frames"` // Identical evaluations before frozen
+	FrozenDiff   float64 `json:"frozen_diff"`   // Mean difference treated as identical
+	MaxChange    float64 `json:"max_change"`    // Changed fraction above this is a scene change
+	PixelDiff    float32 `json:"pixel_diff"`    // Gray level difference counted as changed
+	Hold         int     `json:"hold"`          // Consecutive evaluations before raising or clearing
+}
+
+type tamperStage struct {
+	opts      tamperOptions
+	sourceID  string
+	events    *events.Bus
+	monitor   *HealthMonitor
+	count     int            // Frames seen, for evaluation rate
+	prev      gocv.Mat       // Previous analysed frame
+	reference gocv.Mat       // Scene reference
+	frozen    int            // Consecutive identical evaluations
+	pending   map[string]int // Consecutive evaluations an alert condition changed state
+	active    map[string]bool
+}
+
+func newTamper(env *Env, options json.RawMessage) (Processor, error) {
+	opts := tamperOptions{
+		Every:        5,
+		Width:        320,
+		MinBright:    25,
+		MaxBright:    235,
+		MinSharp:     20,
+		FrozenFrames: 20,
+		FrozenDiff:   0.5,
+		MaxChange:    0.6,
+		PixelDiff:    40,
+		Hold:         3,
+	}
+	if err := decodeOptions(options, &opts); err != nil {
+		return nil, err
+	}
+	if opts.Every < 1 || opts.Width < 16 || opts.Hold < 1 || opts.FrozenFrames < 1 {
+		return nil, fmt.Errorf("every, hold and frozen_frames must be positive and width at least 16")
+	}
+
+	monitor := env.Health
+	if monitor == nil {
+		monitor = NewHealthMonitor()
+	}
+	return &tamperStage{
+		opts:      opts,
+		sourceID:  env.SourceID,
+		events:    env.Events,
+		monitor:   monitor,
+		prev:      gocv.NewMat(),
+		reference: gocv.NewMat(),
+		pending:   make(map[string]int),
+		active:    make(map[string]bool),
+	}, nil
+}
+
+func (s *tamperStage) Process(frame *Frame) error {
+	s.count++
+	if (s.count-1)%s.opts.Every != 0 {
+		return nil
+	}
+
+	gray := s.analysisImage(frame.Mat)
+	defer gray.Close()
+
+	health := types.ImageHealth{
+		Brightness: gray.Mean().Val1,
+		Sharpness:  sharpness(gray),
+		UpdatedAt:  frame.Data.Timestamp,
+	}
+
+	// Frozen frames are identical to the previous evaluation
+	if !s.prev.Empty() && s.prev.Cols() == gray.Cols() && s.prev.Rows() == gray.Rows() {
+		if meanDiff(gray, s.prev) <= s.opts.FrozenDiff {
+			s.frozen++
+		} else {
+			s.frozen = 0
+		}
+	}
+	gray.CopyTo(&s.prev)
+	health.FrozenFrames = s.frozen
+
+	// Scene change compares against a reference taken on the first frame
+	if s.monitor.resetRf.Swap(false) || s.reference.Empty() ||
+		s.reference.Cols() != gray.Cols() || s.reference.Rows() != gray.Rows() {
+		gray.CopyTo(&s.reference)
+	}
+	health.SceneChange = changedFraction(gray, s.reference, s.opts.PixelDiff)
+
+	s.update(AlertTooDark, health.Brightness < s.opts.MinBright, &health)
+	s.update(AlertTooBright, health.Brightness > s.opts.MaxBright, &health)
+	s.update(AlertDefocused, health.Sharpness < s.opts.MinSharp, &health)
+	s.update(AlertFrozen, s.frozen >= s.opts.FrozenFrames, &health)
+	s.update(AlertSceneChanged, health.SceneChange > s.opts.MaxChange, &health)
+
+	health.Alerts = make([]string, 0, len(s.active))
+	for _, alert := range []string{AlertTooDark, AlertTooBright, AlertDefocused, AlertFrozen, AlertSceneChanged} {
+		if s.active[alert] {
+			health.Alerts = append(health.Alerts, alert)
+		}
+	}
+	s.monitor.set(health)
+	frame.SetMetadata("health", health)
+	return nil
+}
+
+// update debounces an alert condition and publishes state changes
+func (s *tamperStage) update(alert string, condition bool, health *types.ImageHealth) {
+	if condition == s.active[alert] {
+		s.pending[alert] = 0
+		return
+	}
+	s.pending[alert]++
+	if s.pending[alert] < s.opts.Hold {
+		return
+	}
+
+	s.pending[alert] = 0
+	s.active[alert] = condition
+	if s.events == nil {
+		return
+	}
+	eventType := events.TamperCleared
+	if condition {
+		eventType = events.TamperAlert
+	}
+	s.events.Publish(eventType, s.sourceID, map[string]interface{}{
+		"alert":  alert,
+		"health": *health,
+	})
+}
+
+// analysisImage returns a downscaled grayscale copy of the frame
+func (s *tamperStage) analysisImage(img gocv.Mat) gocv.Mat {
+	gray := gocv.NewMat()
+	if img.Channels() == 1 {
+		img.CopyTo(&gray)
+	} else {
+		gocv.CvtColor(img, &gray, gocv.ColorBGRToGray)
+	}
+	if gray.Cols() <= s.opts.Width {
+		return gray
+	}
+
+	small := gocv.NewMat()
+	height := gray.Rows() * s.opts.Width / gray.Cols()
+	gocv.Resize(gray, &small, image.Pt(s.opts.Width, max(height, 1)), 0, 0, gocv.InterpolationArea)
+	gray.Close()
+	return small
+}
+
+// Close releases retained frames
+func (s *tamperStage) Close() error {
+	s.prev.Close()
+	s.reference.Close()
+	return nil
+}
+
+// sharpness returns the variance of the Laplacian of a grayscale image
+func sharpness(gray gocv.Mat) float64 {
+	lap := gocv.NewMat()
+	defer lap.Close()
+	mean := gocv.NewMat()
+	defer mean.Close()
+	stddev := gocv.NewMat()
+	defer stddev.Close()
+
+	gocv.Laplacian(gray, &lap, gocv.MatTypeCV64F, 1, 1, 0, gocv.BorderDefault)
+	gocv.MeanStdDev(lap, &mean, &stddev)
+	sd := stddev.GetDoubleAt(0, 0)
+	return sd * sd
+}
+
+// meanDiff returns the mean absolute difference of two images
+func meanDiff(a, b gocv.Mat) float64 {
+	diff := gocv.NewMat()
+	defer diff.Close()
+	gocv.AbsDiff(a, b, &diff)
+	return diff.Mean().Val1
+}
+
+// changedFraction returns the fraction of pixels differing by more than
+// threshold gray levels
+func changedFraction(a, b gocv.Mat, threshold float32) float64 {
+	diff := gocv.NewMat()
+	defer diff.Close()
+	gocv.AbsDiff(a, b, &diff)
+	gocv.Threshold(diff, &diff, threshold, 255, gocv.ThresholdBinary)
+	total := diff.Rows() * diff.Cols()
+	if total == 0 {
+		return 0
+	}
+	return float64(gocv.CountNonZero(diff)) / float64(total)
+}
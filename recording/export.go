@@ -0,0 +1,431 @@
+package recording
+
+import (
+	"archive/zip"
+	"context"
+	"fmt"
+	"image"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/Thivyesh/cameraServiceGo/ids"
+	"github.com/Thivyesh/cameraServiceGo/pipeline"
+	"github.com/Thivyesh/cameraServiceGo/types"
+	"gocv.io/x/gocv"
+)
+
+// exportRetention is how long finished exports are kept for download
+const exportRetention = 24 * time.Hour
+
+// defaultExportFPS is the AVI frame rate when it cannot be derived from the
+// frame times, e.g. for single frame exports
+const defaultExportFPS = 10
+
+// exportExtensions maps export formats to output file extensions
+var exportExtensions = map[string]string{
+	"avi":   ".avi",
+	"zip":   ".zip",
+	"mjpeg": ".mjpeg",
+}
+
+// ExportOptions describes a clip to export
+type ExportOptions struct {
+	SourceID string              // Source the frames were recorded from
+	Name     string              // Camera name used by overlays
+	From     time.Time           // Start of the time range
+	To       time.Time           // End of the time range
+	Format   string              // avi, zip or mjpeg
+	Stages   []types.StageConfig // Processing burned into the frames, e.g. osd
+}
+
+// exportJob is a running or finished export
+type exportJob struct {
+	info   types.ExportJob    // Progress
+	path   string             // Output file
+	cancel context.CancelFunc // Stops a running export
+}
+
+// Exporter assembles recorded and buffered frames into downloadable files.
+// Exports run in the background and are kept until they expire or are
+// deleted.
+type Exporter struct {
+	store *Store                // Frame source
+	dir   string                // Absolute output directory
+	mu    sync.Mutex            // Protects jobs
+	jobs  map[string]*exportJob // Exports by ID
+}
+
+// NewExporter creates an exporter writing to dir. Exports are not kept
+// across restarts, so leftover files are removed.
+func NewExporter(store *Store, dir string) (*Exporter, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create export directory: %v", err)
+	}
+	files, err := filepath.Glob(filepath.Join(dir, "export_*"))
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range files {
+		os.Remove(file)
+	}
+
+	return &Exporter{
+		store: store,
+		dir:   dir,
+		jobs:  make(map[string]*exportJob),
+	}, nil
+}
+
+// Start validates an export and runs it in the background. Ranges ending
+// in the future are cut off at the current time.
+func (e *Exporter) Start(opts ExportOptions) (types.ExportJob, error) {
+	ext, ok := exportExtensions[opts.Format]
+	if !ok {
+		return types.ExportJob{}, fmt.Errorf("unsupported export format: %s", opts.Format)
+	}
+	if now := time.Now(); opts.To.After(now) {
+		opts.To = now
+	}
+	if !opts.From.Before(opts.To) {
+		return types.ExportJob{}, fmt.Errorf("from must be before to")
+	}
+
+	var overlay *pipeline.Pipeline
+	if len(opts.Stages) > 0 {
+		env := &pipeline.Env{
+			SourceID: opts.SourceID,
+			Config:   types.SourceConfig{Name: opts.Name},
+		}
+		var err error
+		if overlay, err = pipeline.New(env, opts.Stages); err != nil {
+			return types.ExportJob{}, fmt.Errorf("invalid pipeline: %v", err)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	id := ids.New("export_")
+	job := &exportJob{
+		info: types.ExportJob{
+			ID:        id,
+			Source:    opts.SourceID,
+			From:      opts.From,
+			To:        opts.To,
+			Format:    opts.Format,
+			Status:    "running",
+			CreatedAt: time.Now(),
+		},
+		path:   filepath.Join(e.dir, id+ext),
+		cancel: cancel,
+	}
+
+	e.mu.Lock()
+	e.expire()
+	e.jobs[id] = job
+	e.mu.Unlock()
+
+	go e.run(ctx, job, opts, overlay)
+	return job.info, nil
+}
+
+// List returns all exports, most recent first
+func (e *Exporter) List() []types.ExportJob {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.expire()
+
+	jobs := make([]types.ExportJob, 0, len(e.jobs))
+	for _, job := range e.jobs {
+		jobs = append(jobs, job.info)
+	}
+	slices.SortFunc(jobs, func(a, b types.ExportJob) int {
+		return b.CreatedAt.Compare(a.CreatedAt)
+	})
+	return jobs
+}
+
+// Get returns the progress of an export
+func (e *Exporter) Get(id string) (types.ExportJob, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	job, ok := e.jobs[id]
+	if !ok {
+		return types.ExportJob{}, fmt.Errorf("export %w: %s", ErrNotFound, id)
+	}
+	return job.info, nil
+}
+
+// Open returns the output file of a finished export
+func (e *Exporter) Open(id string) (*os.File, types.ExportJob, error) {
+	info, err := e.Get(id)
+	if err != nil {
+		return nil, info, err
+	}
+	if info.Status != "done" {
+		return nil, info, fmt.Errorf("export %w: %s is %s", ErrNotReady, id, info.Status)
+	}
+
+	e.mu.Lock()
+	path := e.jobs[id].path
+	e.mu.Unlock()
+	file, err := os.Open(path)
+	return file, info, err
+}
+
+// Delete cancels a running export or removes a finished one
+func (e *Exporter) Delete(id string) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	job, ok := e.jobs[id]
+	if !ok {
+		return fmt.Errorf("export %w: %s", ErrNotFound, id)
+	}
+	job.cancel()
+	os.Remove(job.path)
+	delete(e.jobs, id)
+	return nil
+}
+
+// expire removes exports that finished more than exportRetention ago. The
+// caller must hold the lock.
+func (e *Exporter) expire() {
+	for id, job := range e.jobs {
+		if job.info.FinishedAt != nil && time.Since(*job.info.FinishedAt) > exportRetention {
+			os.Remove(job.path)
+			delete(e.jobs, id)
+		}
+	}
+}
+
+// update changes the progress of a job under the lock
+func (e *Exporter) update(job *exportJob, fn func(info *types.ExportJob)) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	fn(&job.info)
+}
+
+// run writes the export and records its outcome
+func (e *Exporter) run(ctx context.Context, job *exportJob, opts ExportOptions, overlay *pipeline.Pipeline) {
+	if overlay != nil {
+		defer overlay.Close()
+	}
+
+	size, err := e.write(ctx, job, opts, overlay)
+	if err != nil {
+		os.Remove(job.path)
+	}
+
+	e.update(job, func(info *types.ExportJob) {
+		now := time.Now()
+		info.FinishedAt = &now
+		switch {
+		case ctx.Err() != nil:
+			info.Status = "cancelled"
+		case err != nil:
+			info.Status = "failed"
+			info.Error = err.Error()
+		default:
+			info.Status = "done"
+			info.Progress = 1
+			info.Size = size
+		}
+	})
+}
+
+// write assembles the frames of the range into the output file and returns
+// its size
+func (e *Exporter) write(ctx context.Context, job *exportJob, opts ExportOptions, overlay *pipeline.Pipeline) (int64, error) {
+	// Count the frames first for progress reporting and the AVI frame rate
+	var total int
+	var first, last time.Time
+	err := e.store.Frames(opts.SourceID, opts.From, opts.To, false, func(frame types.FrameData) error {
+		if total == 0 {
+			first = frame.Timestamp
+		}
+		last = frame.Timestamp
+		total++
+		return ctx.Err()
+	})
+	if err != nil {
+		return 0, err
+	}
+	if total == 0 {
+		return 0, fmt.Errorf("no frames recorded or buffered between %s and %s",
+			opts.From.Format(time.RFC3339), opts.To.Format(time.RFC3339))
+	}
+	e.update(job, func(info *types.ExportJob) { info.Frames = total })
+
+	fps := float64(defaultExportFPS)
+	if span := last.Sub(first).Seconds(); total > 1 && span > 0 {
+		fps = float64(total-1) / span
+	}
+	out, err := newClipWriter(opts.Format, job.path, fps)
+	if err != nil {
+		return 0, err
+	}
+
+	written := 0
+	err = e.store.Frames(opts.SourceID, opts.From, opts.To, true, func(frame types.FrameData) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if overlay != nil {
+			data, err := applyOverlay(overlay, frame)
+			if err != nil {
+				return err
+			}
+			frame.Data = data
+		}
+		if err := out.write(frame); err != nil {
+			return err
+		}
+
+		written++
+		e.update(job, func(info *types.ExportJob) {
+			info.Progress = float64(written) / float64(max(total, written))
+		})
+		return nil
+	})
+	if closeErr := out.close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	stat, err := os.Stat(job.path)
+	if err != nil {
+		return 0, err
+	}
+	return stat.Size(), nil
+}
+
+// applyOverlay runs the export stages on a frame at its capture time
+func applyOverlay(overlay *pipeline.Pipeline, frame types.FrameData) ([]byte, error) {
+	img, err := gocv.IMDecode(frame.Data, gocv.IMReadColor)
+	if err != nil || img.Empty() {
+		img.Close()
+		return nil, fmt.Errorf("failed to decode frame %d", frame.ID)
+	}
+	defer img.Close()
+
+	work := pipeline.NewFrame(img, &frame)
+	defer work.Close()
+	return overlay.Run(work)
+}
+
+// clipWriter writes JPEG frames to an export file
+type clipWriter interface {
+	write(frame types.FrameData) error
+	close() error
+}
+
+func newClipWriter(format, path string, fps float64) (clipWriter, error) {
+	switch format {
+	case "avi":
+		return &aviWriter{path: path, fps: fps}, nil
+	case "zip":
+		file, err := os.Create(path)
+		if err != nil {
+			return nil, err
+		}
+		return &zipWriter{file: file, archive: zip.NewWriter(file)}, nil
+	case "mjpeg":
+		file, err := os.Create(path)
+		if err != nil {
+			return nil, err
+		}
+		return &mjpegWriter{file: file}, nil
+	default:
+		return nil, fmt.Errorf("unsupported export format: %s", format)
+	}
+}
+
+// aviWriter encodes frames as Motion JPEG AVI at a constant frame rate.
+// Frames with a different size than the first are scaled to match.
+type aviWriter struct {
+	path   string            // Output file
+	fps    float64           // Frame rate
+	writer *gocv.VideoWriter // Opened with the first frame's size
+	size   image.Point       // Frame size
+}
+
+func (w *aviWriter) write(frame types.FrameData) error {
+	img, err := gocv.IMDecode(frame.Data, gocv.IMReadColor)
+	if err != nil || img.Empty() {
+		img.Close()
+		return fmt.Errorf("failed to decode frame %d", frame.ID)
+	}
+	defer img.Close()
+
+	if w.writer == nil {
+		w.size = image.Pt(img.Cols(), img.Rows())
+		if w.writer, err = gocv.VideoWriterFile(w.path, "MJPG", w.fps, w.size.X, w.size.Y, true); err != nil {
+			return fmt.Errorf("failed to open video writer: %v", err)
+		}
+	}
+	if img.Cols() != w.size.X || img.Rows() != w.size.Y {
+		gocv.Resize(img, &img, w.size, 0, 0, gocv.InterpolationLinear)
+	}
+	return w.writer.Write(img)
+}
+
+func (w *aviWriter) close() error {
+	if w.writer == nil {
+		return nil
+	}
+	return w.writer.Close()
+}
+
+// zipWriter stores frames as JPEG files named after their capture time
+type zipWriter struct {
+	file    *os.File    // Output file
+	archive *zip.Writer // Archive being written
+	count   int         // Frames written
+}
+
+func (w *zipWriter) write(frame types.FrameData) error {
+	w.count++
+	entry, err := w.archive.CreateHeader(&zip.FileHeader{
+		Name: fmt.Sprintf("frame_%06d_%s.jpg", w.count,
+			frame.Timestamp.UTC().Format("20060102T150405.000Z")),
+		Method:   zip.Store, // JPEG data does not compress further
+		Modified: frame.Timestamp,
+	})
+	if err != nil {
+		return err
+	}
+	_, err = entry.Write(frame.Data)
+	return err
+}
+
+func (w *zipWriter) close() error {
+	err := w.archive.Close()
+	if closeErr := w.file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// mjpegWriter concatenates JPEG frames into a raw MJPEG stream
+type mjpegWriter struct {
+	file io.WriteCloser // Output file
+}
+
+func (w *mjpegWriter) write(frame types.FrameData) error {
+	_, err := w.file.Write(frame.Data)
+	return err
+}
+
+func (w *mjpegWriter) close() error {
+	return w.file.Close()
+}
@@ -0,0 +1,292 @@
+package recording
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/Thivyesh/cameraServiceGo/types"
+	bolt "go.etcd.io/bbolt"
+)
+
+// indexFile is the name of the index database in the recording directory
+const indexFile = "index.db"
+
+// eventRetention is how long event markers are kept in the index
+const eventRetention = 30 * 24 * time.Hour
+
+// Top level buckets of the index. Each holds a nested bucket per source ID.
+var (
+	segmentsBucket = []byte("segments")
+	eventsBucket   = []byte("events")
+)
+
+// index keeps the closed segments and the events of every source in an
+// embedded database so time ranges can be looked up without listing and
+// parsing segment files.
+//
+// Segments are keyed by their start time and store their end time, size and
+// file name. Events are keyed by their time and ID and store the event as
+// JSON. Times are big endian Unix nanoseconds so keys sort chronologically.
+type index struct {
+	db *bolt.DB
+}
+
+// indexedEvent is the stored form of an event marker
+type indexedEvent struct {
+	ID   int64           `json:"id"`
+	Type string          `json:"type"`
+	Data json.RawMessage `json:"data,omitempty"`
+}
+
+// openIndex opens or creates the index database in dir and drops expired
+// event markers
+func openIndex(dir string) (*index, error) {
+	db, err := bolt.Open(filepath.Join(dir, indexFile), 0o644, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open recording index: %v", err)
+	}
+
+	expired := timeKey(time.Now().Add(-eventRetention))
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, name := range [][]byte{segmentsBucket, eventsBucket} {
+			if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+				return err
+			}
+		}
+		events := tx.Bucket(eventsBucket)
+		return events.ForEachBucket(func(source []byte) error {
+			b := events.Bucket(source)
+			var keys [][]byte
+			c := b.Cursor()
+			for k, _ := c.First(); k != nil && bytes.Compare(k, expired) < 0; k, _ = c.Next() {
+				keys = append(keys, append([]byte(nil), k...))
+			}
+			for _, k := range keys {
+				if err := b.Delete(k); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to open recording index: %v", err)
+	}
+	return &index{db: db}, nil
+}
+
+// timeKey encodes a time as a sortable key
+func timeKey(t time.Time) []byte {
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, uint64(t.UnixNano()))
+	return key
+}
+
+// keyTime decodes the time at the start of a key
+func keyTime(key []byte) time.Time {
+	return time.Unix(0, int64(binary.BigEndian.Uint64(key)))
+}
+
+// segmentValue encodes the indexed fields of a segment
+func segmentValue(seg segment) []byte {
+	value := make([]byte, 16, 16+len(seg.path))
+	binary.BigEndian.PutUint64(value[0:], uint64(seg.end.UnixNano()))
+	binary.BigEndian.PutUint64(value[8:], uint64(seg.size))
+	return append(value, filepath.Base(seg.path)...)
+}
+
+// parseSegmentValue decodes an indexed segment of a source directory
+func parseSegmentValue(dir string, key, value []byte) (segment, bool) {
+	if len(key) != 8 || len(value) < 16 {
+		return segment{}, false
+	}
+	return segment{
+		path:  filepath.Join(dir, string(value[16:])),
+		start: keyTime(key),
+		end:   keyTime(value),
+		size:  int64(binary.BigEndian.Uint64(value[8:])),
+	}, true
+}
+
+// sync replaces the indexed segments with the closed segments found on disk,
+// by source ID. Sources without segments are dropped from the index.
+func (x *index) sync(segs map[string][]segment) error {
+	return x.db.Update(func(tx *bolt.Tx) error {
+		root := tx.Bucket(segmentsBucket)
+		var stale [][]byte
+		root.ForEachBucket(func(source []byte) error {
+			if _, ok := segs[string(source)]; !ok {
+				stale = append(stale, append([]byte(nil), source...))
+			}
+			return nil
+		})
+		for _, source := range stale {
+			if err := root.DeleteBucket(source); err != nil {
+				return err
+			}
+		}
+
+		for sourceID, list := range segs {
+			if root.Bucket([]byte(sourceID)) != nil {
+				if err := root.DeleteBucket([]byte(sourceID)); err != nil {
+					return err
+				}
+			}
+			b, err := root.CreateBucket([]byte(sourceID))
+			if err != nil {
+				return err
+			}
+			for _, seg := range list {
+				if err := b.Put(timeKey(seg.start), segmentValue(seg)); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+}
+
+// addSegment indexes a closed segment
+func (x *index) addSegment(sourceID string, seg segment) error {
+	return x.db.Update(func(tx *bolt.Tx) error {
+		b, err := tx.Bucket(segmentsBucket).CreateBucketIfNotExists([]byte(sourceID))
+		if err != nil {
+			return err
+		}
+		return b.Put(timeKey(seg.start), segmentValue(seg))
+	})
+}
+
+// segments returns the indexed segments of a source overlapping a time
+// range, oldest first. Segments of a source never overlap, so only the last
+// one starting before the range can reach into it.
+func (x *index) segments(sourceID, dir string, from, to time.Time) ([]segment, error) {
+	var segs []segment
+	err := x.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket(segmentsBucket).Bucket([]byte(sourceID))
+		if b == nil {
+			return nil
+		}
+		c := b.Cursor()
+		// Step back to the segment that may still run at from
+		k, v := c.Seek(timeKey(from))
+		switch {
+		case k == nil:
+			k, v = c.Last()
+		case !keyTime(k).Equal(from):
+			if k, v = c.Prev(); k == nil {
+				k, v = c.First()
+			}
+		}
+
+		for ; k != nil; k, v = c.Next() {
+			seg, ok := parseSegmentValue(dir, k, v)
+			if !ok {
+				continue
+			}
+			if seg.start.After(to) {
+				break
+			}
+			if !seg.end.Before(from) {
+				segs = append(segs, seg)
+			}
+		}
+		return nil
+	})
+	return segs, err
+}
+
+// usage returns the total size of the indexed segments by source ID
+func (x *index) usage() (map[string]int64, error) {
+	usage := make(map[string]int64)
+	err := x.db.View(func(tx *bolt.Tx) error {
+		root := tx.Bucket(segmentsBucket)
+		return root.ForEachBucket(func(source []byte) error {
+			var size int64
+			root.Bucket(source).ForEach(func(_, v []byte) error {
+				if len(v) >= 16 {
+					size += int64(binary.BigEndian.Uint64(v[8:]))
+				}
+				return nil
+			})
+			usage[string(source)] = size
+			return nil
+		})
+	})
+	return usage, err
+}
+
+// addEvents stores event markers in a single transaction, so frequent
+// analytics events stay cheap
+func (x *index) addEvents(events []types.Event) error {
+	return x.db.Update(func(tx *bolt.Tx) error {
+		for _, event := range events {
+			data, err := json.Marshal(event.Data)
+			if err != nil {
+				return err
+			}
+			value, err := json.Marshal(indexedEvent{ID: event.ID, Type: event.Type, Data: data})
+			if err != nil {
+				return err
+			}
+			key := append(timeKey(event.Timestamp), make([]byte, 8)...)
+			binary.BigEndian.PutUint64(key[8:], uint64(event.ID))
+
+			b, err := tx.Bucket(eventsBucket).CreateBucketIfNotExists([]byte(event.Source))
+			if err != nil {
+				return err
+			}
+			if err := b.Put(key, value); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// events returns up to limit event markers of a source within a time range,
+// oldest first, and whether more were left out
+func (x *index) events(sourceID string, from, to time.Time, limit int) ([]types.TimelineEvent, bool, error) {
+	var markers []types.TimelineEvent
+	truncated := false
+	err := x.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket(eventsBucket).Bucket([]byte(sourceID))
+		if b == nil {
+			return nil
+		}
+		c := b.Cursor()
+		for k, v := c.Seek(timeKey(from)); k != nil; k, v = c.Next() {
+			if len(k) != 16 {
+				continue
+			}
+			t := keyTime(k)
+			if t.After(to) {
+				break
+			}
+			if len(markers) == limit {
+				truncated = true
+				break
+			}
+			var event indexedEvent
+			if err := json.Unmarshal(v, &event); err != nil {
+				continue
+			}
+			marker := types.TimelineEvent{ID: event.ID, Type: event.Type, Time: t}
+			if len(event.Data) > 0 && string(event.Data) != "null" {
+				json.Unmarshal(event.Data, &marker.Data)
+			}
+			markers = append(markers, marker)
+		}
+		return nil
+	})
+	return markers, truncated, err
+}
+
+func (x *index) close() error {
+	return x.db.Close()
+}
@@ -0,0 +1,81 @@
+package recording
+
+import (
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Thivyesh/cameraServiceGo/events"
+	"github.com/Thivyesh/cameraServiceGo/types"
+)
+
+// Detection stages publish an event for every inferred frame with
+// detections, far too many to keep as markers for a month. Detections are
+// marked when the set of detected labels changes, or once per interval
+// while it stays the same, and never closer together than the spacing.
+const (
+	detectionMarkerInterval = 2 * time.Minute
+	detectionMarkerSpacing  = 30 * time.Second
+)
+
+// lastMarker is the most recent detection marker of a source
+type lastMarker struct {
+	time   time.Time // Event time
+	labels string    // Sorted detected labels
+}
+
+// markerThrottle decides which detection events become timeline markers
+type markerThrottle struct {
+	mu   sync.Mutex            // Protects last
+	last map[string]lastMarker // Latest marker by source ID
+}
+
+func newMarkerThrottle() *markerThrottle {
+	return &markerThrottle{last: make(map[string]lastMarker)}
+}
+
+// keep reports whether an event should be marked, recording it if so.
+// Events other than detections are always kept.
+func (m *markerThrottle) keep(event types.Event) bool {
+	if event.Type != events.AnalyticsDetection {
+		return true
+	}
+	labels := detectionLabels(event.Data)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	last, ok := m.last[event.Source]
+	if ok {
+		elapsed := event.Timestamp.Sub(last.time)
+		if elapsed < detectionMarkerSpacing ||
+			(labels == last.labels && elapsed < detectionMarkerInterval) {
+			return false
+		}
+	}
+	m.last[event.Source] = lastMarker{time: event.Timestamp, labels: labels}
+	return true
+}
+
+// forget drops the state of a removed source
+func (m *markerThrottle) forget(sourceID string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.last, sourceID)
+}
+
+// detectionLabels returns the distinct labels of a detection event payload
+// as a sorted, comma separated list
+func detectionLabels(data interface{}) string {
+	payload, ok := data.(map[string]interface{})
+	if !ok {
+		return ""
+	}
+	detections, _ := payload["detections"].([]types.Detection)
+	labels := make([]string, 0, len(detections))
+	for _, d := range detections {
+		labels = append(labels, d.Label)
+	}
+	slices.Sort(labels)
+	return strings.Join(slices.Compact(labels), ",")
+}
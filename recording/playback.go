@@ -0,0 +1,208 @@
+package recording
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/Thivyesh/cameraServiceGo/types"
+)
+
+// maxPlaybackSpeed is the fastest supported playback speed
+const maxPlaybackSpeed = 16
+
+// maxFrameDelay is the longest wait between two played frames. Longer breaks
+// in the footage are skipped.
+const maxFrameDelay = time.Second
+
+// Player replays the footage of a source at the pace it was captured,
+// scaled by a speed factor. Speed and position can be changed while playing.
+type Player struct {
+	store    *Store           // Footage being played
+	sourceID string           // Source the footage was recorded from
+	from, to time.Time        // Playable range
+	mu       sync.Mutex       // Protects shared state
+	frames   *frameIterator   // Frames left to play, nil once closed
+	pending  *types.FrameData // Frame waiting to be due
+	speed    float64          // Playback speed, 1 is real time
+	last     time.Time        // Capture time of the previous frame
+	position time.Time        // Capture time of the last played frame
+	due      time.Time        // When the pending or previous frame is due
+	changed  chan struct{}    // Closed when the speed or position changes
+}
+
+// Play starts playing the footage of a source captured between from and to
+func (s *Store) Play(sourceID string, from, to time.Time, speed float64) (*Player, error) {
+	if err := CheckSpeed(speed); err != nil {
+		return nil, err
+	}
+	if !from.Before(to) {
+		return nil, fmt.Errorf("playback range must end after it starts")
+	}
+	frames, err := s.iterate(sourceID, from, to, true)
+	if err != nil {
+		return nil, err
+	}
+	return &Player{
+		store:    s,
+		sourceID: sourceID,
+		from:     from,
+		to:       to,
+		frames:   frames,
+		speed:    speed,
+		position: from,
+		changed:  make(chan struct{}),
+	}, nil
+}
+
+// CheckSpeed verifies that a playback speed is supported
+func CheckSpeed(speed float64) error {
+	if speed <= 0 || speed > maxPlaybackSpeed {
+		return fmt.Errorf("playback speed must be greater than 0 and at most %d", maxPlaybackSpeed)
+	}
+	return nil
+}
+
+// Next waits until the next frame is due and returns it. It returns io.EOF
+// at the end of the range and the context error when ctx is done first.
+func (p *Player) Next(ctx context.Context) (types.FrameData, error) {
+	for {
+		p.mu.Lock()
+		if p.frames == nil {
+			p.mu.Unlock()
+			return types.FrameData{}, io.EOF
+		}
+		if p.pending == nil {
+			frame, err := p.frames.next()
+			if err != nil {
+				p.mu.Unlock()
+				return types.FrameData{}, err
+			}
+			p.schedule(frame.Timestamp)
+			p.pending = &frame
+		}
+		due, changed := p.due, p.changed
+		p.mu.Unlock()
+
+		if wait := time.Until(due); wait > 0 {
+			timer := time.NewTimer(wait)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return types.FrameData{}, ctx.Err()
+			case <-changed:
+				timer.Stop()
+				continue
+			case <-timer.C:
+			}
+		}
+
+		p.mu.Lock()
+		if p.changed != changed || p.pending == nil {
+			// Seeked or sped up while waiting
+			p.mu.Unlock()
+			continue
+		}
+		frame := *p.pending
+		p.pending = nil
+		p.last = frame.Timestamp
+		p.position = frame.Timestamp
+		p.mu.Unlock()
+		return frame, nil
+	}
+}
+
+// schedule sets when a frame captured at t is due. The caller must hold the
+// lock.
+func (p *Player) schedule(t time.Time) {
+	now := time.Now()
+	delay := time.Duration(float64(t.Sub(p.last)) / p.speed)
+	if p.last.IsZero() || delay > maxFrameDelay {
+		delay = 0
+	}
+	p.due = p.due.Add(delay)
+	// Start over after a slow reader instead of sending a burst
+	if now.Sub(p.due) > maxFrameDelay {
+		p.due = now
+	}
+}
+
+// notify wakes a waiting Next. The caller must hold the lock.
+func (p *Player) notify() {
+	close(p.changed)
+	p.changed = make(chan struct{})
+}
+
+// Seek continues playback at a point in time within the playable range
+func (p *Player) Seek(t time.Time) error {
+	if t.Before(p.from) || !t.Before(p.to) {
+		return fmt.Errorf("position must be between %s and %s",
+			p.from.Format(time.RFC3339), p.to.Format(time.RFC3339))
+	}
+	frames, err := p.store.iterate(p.sourceID, t, p.to, true)
+	if err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.frames == nil {
+		frames.close()
+		return fmt.Errorf("player is closed")
+	}
+	p.frames.close()
+	p.frames = frames
+	p.pending = nil
+	p.last = time.Time{}
+	p.due = time.Now()
+	p.position = t
+	p.notify()
+	return nil
+}
+
+// SetSpeed changes the playback speed. A frame waiting to be due keeps its
+// remaining share of the delay.
+func (p *Player) SetSpeed(speed float64) error {
+	if err := CheckSpeed(speed); err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.pending != nil {
+		if remaining := time.Until(p.due); remaining > 0 {
+			p.due = time.Now().Add(time.Duration(float64(remaining) * p.speed / speed))
+		}
+	}
+	p.speed = speed
+	p.notify()
+	return nil
+}
+
+// Speed returns the playback speed
+func (p *Player) Speed() float64 {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.speed
+}
+
+// Position returns the capture time of the last played frame, or where
+// playback starts before the first frame
+func (p *Player) Position() time.Time {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.position
+}
+
+// Close stops reading the footage
+func (p *Player) Close() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.frames != nil {
+		p.frames.close()
+		p.frames = nil
+		p.notify()
+	}
+}
@@ -0,0 +1,184 @@
+package recording
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Thivyesh/cameraServiceGo/types"
+)
+
+// Segment files hold the frames recorded from a source during a bounded
+// period. After a short header every frame is stored as its capture time,
+// frame ID and JPEG length followed by the JPEG data, so frames can be
+// skipped without reading them.
+const (
+	segmentMagic     = "CAMSEG1\n"
+	segmentExt       = ".seg"
+	recordHeaderSize = 20
+)
+
+// segment is a recorded segment file and the time range it covers. Open
+// segments are named after their start time and renamed to start-end when
+// they are closed.
+type segment struct {
+	path  string    // Segment file
+	start time.Time // Capture time of the first frame
+	end   time.Time // Capture time of the last frame
+	size  int64     // File size in bytes
+}
+
+// parseSegmentName reads the time range from a segment file name
+func parseSegmentName(name string) (start, end time.Time, closed, ok bool) {
+	base, found := strings.CutSuffix(name, segmentExt)
+	if !found {
+		return
+	}
+	first, last, closed := strings.Cut(base, "-")
+	startNs, err := strconv.ParseInt(first, 10, 64)
+	if err != nil {
+		return
+	}
+	start = time.Unix(0, startNs)
+	if !closed {
+		return start, start, false, true
+	}
+	endNs, err := strconv.ParseInt(last, 10, 64)
+	if err != nil || endNs < startNs {
+		return
+	}
+	return start, time.Unix(0, endNs), true, true
+}
+
+// segmentWriter appends frames to an open segment
+type segmentWriter struct {
+	segment
+	file   *os.File // Open segment file
+	frames int      // Number of frames written
+}
+
+// createSegment starts a segment in dir with the first frame's time
+func createSegment(dir string, start time.Time) (*segmentWriter, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create recording directory: %v", err)
+	}
+	path := filepath.Join(dir, strconv.FormatInt(start.UnixNano(), 10)+segmentExt)
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := file.WriteString(segmentMagic); err != nil {
+		file.Close()
+		os.Remove(path)
+		return nil, err
+	}
+	return &segmentWriter{
+		segment: segment{path: path, start: start, end: start, size: int64(len(segmentMagic))},
+		file:    file,
+	}, nil
+}
+
+// write appends a frame. Header and data are written at once so readers of
+// the open segment never see half a record header.
+func (w *segmentWriter) write(frame types.FrameData) error {
+	record := make([]byte, recordHeaderSize+len(frame.Data))
+	binary.BigEndian.PutUint64(record[0:], uint64(frame.Timestamp.UnixNano()))
+	binary.BigEndian.PutUint64(record[8:], uint64(frame.ID))
+	binary.BigEndian.PutUint32(record[16:], uint32(len(frame.Data)))
+	copy(record[recordHeaderSize:], frame.Data)
+	if _, err := w.file.Write(record); err != nil {
+		return err
+	}
+	w.size += int64(len(record))
+	w.end = frame.Timestamp
+	w.frames++
+	return nil
+}
+
+// close finishes the segment and renames it to its time range. Segments
+// without frames are removed.
+func (w *segmentWriter) close() error {
+	if err := w.file.Close(); err != nil {
+		return err
+	}
+	if w.frames == 0 {
+		return os.Remove(w.path)
+	}
+	path, err := finishSegment(w.path, w.start, w.end)
+	if err != nil {
+		return err
+	}
+	w.path = path
+	return nil
+}
+
+// finishSegment renames an open segment to include its end time and returns
+// the new path
+func finishSegment(path string, start, end time.Time) (string, error) {
+	name := fmt.Sprintf("%d-%d%s", start.UnixNano(), end.UnixNano(), segmentExt)
+	finished := filepath.Join(filepath.Dir(path), name)
+	return finished, os.Rename(path, finished)
+}
+
+// segmentReader reads the frames of a segment in order
+type segmentReader struct {
+	file   *os.File      // Segment file
+	reader *bufio.Reader // Buffered segment data
+	header [recordHeaderSize]byte
+}
+
+func openSegment(path string) (*segmentReader, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	r := &segmentReader{file: file, reader: bufio.NewReaderSize(file, 256<<10)}
+
+	magic := make([]byte, len(segmentMagic))
+	if _, err := io.ReadFull(r.reader, magic); err != nil || string(magic) != segmentMagic {
+		file.Close()
+		return nil, fmt.Errorf("not a recording segment: %s", path)
+	}
+	return r, nil
+}
+
+// next reads the header of the next frame and returns the frame without its
+// data and the size of the data, which must then be read or skipped. A record
+// cut short, e.g. by a crash while writing, ends the segment.
+func (r *segmentReader) next() (types.FrameData, int, error) {
+	if _, err := io.ReadFull(r.reader, r.header[:]); err != nil {
+		return types.FrameData{}, 0, io.EOF
+	}
+	frame := types.FrameData{
+		Timestamp: time.Unix(0, int64(binary.BigEndian.Uint64(r.header[0:]))),
+		ID:        int64(binary.BigEndian.Uint64(r.header[8:])),
+	}
+	return frame, int(binary.BigEndian.Uint32(r.header[16:])), nil
+}
+
+// read returns the data of the frame whose header was read last
+func (r *segmentReader) read(size int) ([]byte, error) {
+	data := make([]byte, size)
+	if _, err := io.ReadFull(r.reader, data); err != nil {
+		return nil, io.EOF
+	}
+	return data, nil
+}
+
+// skip discards the data of the frame whose header was read last
+func (r *segmentReader) skip(size int) error {
+	if _, err := r.reader.Discard(size); err != nil {
+		return io.EOF
+	}
+	return nil
+}
+
+func (r *segmentReader) close() error {
+	return r.file.Close()
+}
@@ -0,0 +1,506 @@
+// Package recording keeps the frames of sources in memory and on disk so
+// past footage can be exported
+package recording
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/Thivyesh/cameraServiceGo/types"
+)
+
+// segmentDuration is the longest period recorded into a single segment file
+const segmentDuration = 5 * time.Minute
+
+// maxPreRollBytes bounds the memory used by the pre-roll buffer of a source
+const maxPreRollBytes = 64 << 20
+
+// Errors callers may want to tell apart
+var (
+	ErrNotFound     = errors.New("not found")
+	ErrNotReady     = errors.New("not finished")
+	ErrRecording    = errors.New("already being recorded")
+	ErrNotRecording = errors.New("not being recorded")
+)
+
+// sourceState holds the recent frames of a source and its recording
+type sourceState struct {
+	mu        sync.Mutex        // Protects shared state
+	frames    []types.FrameData // Pre-roll buffer, oldest first
+	bytes     int               // Size of the buffered frames
+	recording bool              // Whether frames are written to disk
+	writer    *segmentWriter    // Open segment, created with the first frame
+}
+
+// Store keeps a short pre-roll buffer of every source in memory and records
+// sources to segment files on request
+type Store struct {
+	dir     string                  // Absolute recording directory
+	preRoll time.Duration           // Age of the oldest buffered frame
+	mu      sync.Mutex              // Protects sources
+	sources map[string]*sourceState // State by source ID
+	index   *index                  // Closed segments and events
+	markers *markerThrottle         // Limits detection markers
+}
+
+// NewStore opens or creates a recording directory. Segments left open by a
+// crash are closed using their last modification time.
+func NewStore(dir string, preRoll time.Duration) (*Store, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create recording directory: %v", err)
+	}
+
+	paths, err := filepath.Glob(filepath.Join(dir, "*", "*"+segmentExt))
+	if err != nil {
+		return nil, err
+	}
+	closed := make(map[string][]segment)
+	for _, path := range paths {
+		start, end, finished, ok := parseSegmentName(filepath.Base(path))
+		sourceID, err := url.PathUnescape(filepath.Base(filepath.Dir(path)))
+		if !ok || err != nil {
+			continue
+		}
+		stat, err := os.Stat(path)
+		if err != nil {
+			continue
+		}
+		if !finished {
+			end = stat.ModTime()
+			if end.Before(start) {
+				end = start
+			}
+			if path, err = finishSegment(path, start, end); err != nil {
+				continue
+			}
+		}
+		closed[sourceID] = append(closed[sourceID], segment{path: path, start: start, end: end, size: stat.Size()})
+	}
+
+	// Files on disk are authoritative, e.g. after segments were deleted by hand
+	index, err := openIndex(dir)
+	if err != nil {
+		return nil, err
+	}
+	if err := index.sync(closed); err != nil {
+		index.close()
+		return nil, fmt.Errorf("failed to update recording index: %v", err)
+	}
+
+	return &Store{
+		dir:     dir,
+		preRoll: preRoll,
+		sources: make(map[string]*sourceState),
+		index:   index,
+		markers: newMarkerThrottle(),
+	}, nil
+}
+
+// sourceDir returns the directory holding the segments of a source
+func (s *Store) sourceDir(sourceID string) (string, error) {
+	name := url.PathEscape(sourceID)
+	if name == "" || name == "." || name == ".." {
+		return "", fmt.Errorf("invalid source ID: %q", sourceID)
+	}
+	return filepath.Join(s.dir, name), nil
+}
+
+// state returns the state of a source, creating it when missing
+func (s *Store) state(sourceID string) *sourceState {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	st, ok := s.sources[sourceID]
+	if !ok {
+		st = &sourceState{}
+		s.sources[sourceID] = st
+	}
+	return st
+}
+
+// find returns the state of a source or nil when nothing was buffered or
+// recorded since the service started
+func (s *Store) find(sourceID string) *sourceState {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.sources[sourceID]
+}
+
+// Add buffers a frame and records it when the source is being recorded. A
+// failed write stops the recording and is returned.
+func (s *Store) Add(sourceID string, frame types.FrameData) error {
+	st := s.state(sourceID)
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	// Keep the encoded image only, stage results and raw frames stay live
+	frame = types.FrameData{
+		ID:        frame.ID,
+		Timestamp: frame.Timestamp,
+		Data:      frame.Data,
+		Source:    frame.Source,
+	}
+
+	st.frames = append(st.frames, frame)
+	st.bytes += len(frame.Data)
+	drop := 0
+	for drop < len(st.frames)-1 &&
+		(frame.Timestamp.Sub(st.frames[drop].Timestamp) > s.preRoll || st.bytes > maxPreRollBytes) {
+		st.bytes -= len(st.frames[drop].Data)
+		drop++
+	}
+	st.frames = slices.Delete(st.frames, 0, drop)
+
+	if !st.recording {
+		return nil
+	}
+	if err := s.record(sourceID, st, frame); err != nil {
+		st.recording = false
+		s.finish(sourceID, st)
+		return fmt.Errorf("recording stopped: %v", err)
+	}
+	return nil
+}
+
+// record writes a frame to the open segment, starting a new segment when
+// the current one is full. The caller must hold the source lock.
+func (s *Store) record(sourceID string, st *sourceState, frame types.FrameData) error {
+	if st.writer != nil && frame.Timestamp.Sub(st.writer.start) >= segmentDuration {
+		if err := s.finish(sourceID, st); err != nil {
+			return err
+		}
+	}
+	if st.writer == nil {
+		dir, err := s.sourceDir(sourceID)
+		if err != nil {
+			return err
+		}
+		if st.writer, err = createSegment(dir, frame.Timestamp); err != nil {
+			return err
+		}
+	}
+	return st.writer.write(frame)
+}
+
+// finish closes the open segment of a source, if any, and adds it to the
+// index. The caller must hold the source lock.
+func (s *Store) finish(sourceID string, st *sourceState) error {
+	w := st.writer
+	if w == nil {
+		return nil
+	}
+	st.writer = nil
+	if err := w.close(); err != nil {
+		return err
+	}
+	if w.frames == 0 {
+		return nil
+	}
+	if err := s.index.addSegment(sourceID, w.segment); err != nil {
+		return fmt.Errorf("failed to index segment: %v", err)
+	}
+	return nil
+}
+
+// Start begins recording the frames of a source to disk
+func (s *Store) Start(sourceID string) error {
+	if _, err := s.sourceDir(sourceID); err != nil {
+		return err
+	}
+	st := s.state(sourceID)
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	if st.recording {
+		return fmt.Errorf("source is %w: %s", ErrRecording, sourceID)
+	}
+	st.recording = true
+	return nil
+}
+
+// Stop ends the recording of a source and closes its segment
+func (s *Store) Stop(sourceID string) error {
+	st := s.find(sourceID)
+	if st == nil {
+		return fmt.Errorf("source is %w: %s", ErrNotRecording, sourceID)
+	}
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	if !st.recording {
+		return fmt.Errorf("source is %w: %s", ErrNotRecording, sourceID)
+	}
+	st.recording = false
+	return s.finish(sourceID, st)
+}
+
+// Recording reports whether a source is being recorded
+func (s *Store) Recording(sourceID string) bool {
+	st := s.find(sourceID)
+	if st == nil {
+		return false
+	}
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	return st.recording
+}
+
+// Remove stops recording a source and drops its pre-roll buffer. Recorded
+// segments are kept.
+func (s *Store) Remove(sourceID string) {
+	s.markers.forget(sourceID)
+	s.mu.Lock()
+	st, ok := s.sources[sourceID]
+	delete(s.sources, sourceID)
+	s.mu.Unlock()
+	if !ok {
+		return
+	}
+
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	if err := s.finish(sourceID, st); err != nil {
+		log.Printf("Error closing recording of source %s: %v", sourceID, err)
+	}
+	st.recording = false
+	st.frames = nil
+}
+
+// Close finishes all open segments and closes the index
+func (s *Store) Close() {
+	s.mu.Lock()
+	ids := make([]string, 0, len(s.sources))
+	for id := range s.sources {
+		ids = append(ids, id)
+	}
+	s.mu.Unlock()
+
+	for _, id := range ids {
+		s.Remove(id)
+	}
+	s.index.close()
+}
+
+// DiskUsage returns the size of the recorded segments of every source,
+// including segments being written
+func (s *Store) DiskUsage() (map[string]int64, error) {
+	usage, err := s.index.usage()
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for sourceID, st := range s.sources {
+		st.mu.Lock()
+		if st.writer != nil {
+			usage[sourceID] += st.writer.size
+		}
+		st.mu.Unlock()
+	}
+	return usage, nil
+}
+
+// AddEvents marks events of sources on their timelines. Events without a
+// source are ignored, and frequent detections are thinned out.
+func (s *Store) AddEvents(events ...types.Event) error {
+	var marked []types.Event
+	for _, event := range events {
+		if event.Source != "" && s.markers.keep(event) {
+			marked = append(marked, event)
+		}
+	}
+	if len(marked) == 0 {
+		return nil
+	}
+	return s.index.addEvents(marked)
+}
+
+// segments returns the recorded segments of a source overlapping a time
+// range, oldest first
+func (s *Store) segments(sourceID string, from, to time.Time) ([]segment, error) {
+	dir, err := s.sourceDir(sourceID)
+	if err != nil {
+		return nil, err
+	}
+	segs, err := s.index.segments(sourceID, dir, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	// The open segment is only known in memory
+	if st := s.find(sourceID); st != nil {
+		st.mu.Lock()
+		if st.writer != nil && st.writer.frames > 0 &&
+			!st.writer.start.After(to) && !st.writer.end.Before(from) {
+			segs = append(segs, st.writer.segment)
+		}
+		st.mu.Unlock()
+	}
+	return segs, nil
+}
+
+// Frames calls fn for every frame of a source captured between from and to,
+// in capture order. Recorded frames and the pre-roll buffer are merged, so
+// ranges that were never recorded can still be served while they are
+// buffered. Frame data is only read when withData is set.
+func (s *Store) Frames(sourceID string, from, to time.Time, withData bool, fn func(types.FrameData) error) error {
+	it, err := s.iterate(sourceID, from, to, withData)
+	if err != nil {
+		return err
+	}
+	defer it.close()
+
+	for {
+		frame, err := it.next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := fn(frame); err != nil {
+			return err
+		}
+	}
+}
+
+// frameIterator merges the recorded frames of a source with a snapshot of
+// its pre-roll buffer
+type frameIterator struct {
+	sourceID string            // Source the frames belong to
+	recorded *rangeReader      // Frames on disk
+	pending  types.FrameData   // Next recorded frame
+	err      error             // Error reading pending, io.EOF at the end
+	buffered []types.FrameData // Remaining buffered frames
+	withData bool              // Whether frame data is read
+	last     time.Time         // Capture time of the previous frame
+}
+
+// iterate starts reading the frames of a source between from and to
+func (s *Store) iterate(sourceID string, from, to time.Time, withData bool) (*frameIterator, error) {
+	segs, err := s.segments(sourceID, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	it := &frameIterator{
+		sourceID: sourceID,
+		recorded: &rangeReader{segs: segs, from: from, to: to, withData: withData},
+		withData: withData,
+	}
+	if st := s.find(sourceID); st != nil {
+		st.mu.Lock()
+		for _, frame := range st.frames {
+			if !frame.Timestamp.Before(from) && !frame.Timestamp.After(to) {
+				it.buffered = append(it.buffered, frame)
+			}
+		}
+		st.mu.Unlock()
+	}
+	it.pending, it.err = it.recorded.next()
+	return it, nil
+}
+
+// next returns the next frame in capture order or io.EOF
+func (it *frameIterator) next() (types.FrameData, error) {
+	for {
+		if it.err != nil && it.err != io.EOF {
+			return types.FrameData{}, it.err
+		}
+
+		// Take whichever source has the earlier frame. Recorded frames that
+		// are also buffered have the same time and are delivered once.
+		var frame types.FrameData
+		switch {
+		case it.err == nil && (len(it.buffered) == 0 || !it.buffered[0].Timestamp.Before(it.pending.Timestamp)):
+			frame = it.pending
+			it.pending, it.err = it.recorded.next()
+		case len(it.buffered) > 0:
+			frame, it.buffered = it.buffered[0], it.buffered[1:]
+			if !it.withData {
+				frame.Data = nil
+			}
+		default:
+			return types.FrameData{}, io.EOF
+		}
+
+		if !it.last.IsZero() && !frame.Timestamp.After(it.last) {
+			continue
+		}
+		it.last = frame.Timestamp
+		frame.Source = it.sourceID
+		return frame, nil
+	}
+}
+
+func (it *frameIterator) close() {
+	it.recorded.close()
+}
+
+// rangeReader reads the frames of consecutive segments within a time range
+type rangeReader struct {
+	segs     []segment      // Remaining segments
+	current  *segmentReader // Segment being read
+	from, to time.Time      // Time range
+	withData bool           // Whether frame data is read
+}
+
+// next returns the next frame in range or io.EOF
+func (r *rangeReader) next() (types.FrameData, error) {
+	for {
+		if r.current == nil {
+			if len(r.segs) == 0 {
+				return types.FrameData{}, io.EOF
+			}
+			current, err := openSegment(r.segs[0].path)
+			r.segs = r.segs[1:]
+			if err != nil {
+				// Segments may be rotated or removed while reading
+				continue
+			}
+			r.current = current
+		}
+
+		frame, size, err := r.current.next()
+		if err == nil && frame.Timestamp.After(r.to) {
+			r.segs = nil
+			err = io.EOF
+		}
+		if err == nil {
+			if frame.Timestamp.Before(r.from) || !r.withData {
+				err = r.current.skip(size)
+			} else {
+				frame.Data, err = r.current.read(size)
+			}
+		}
+		if err != nil {
+			r.current.close()
+			r.current = nil
+			continue
+		}
+		if frame.Timestamp.Before(r.from) {
+			continue
+		}
+		return frame, nil
+	}
+}
+
+func (r *rangeReader) close() {
+	if r.current != nil {
+		r.current.close()
+		r.current = nil
+	}
+}
@@ -0,0 +1,311 @@
+package recording
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/Thivyesh/cameraServiceGo/events"
+	"github.com/Thivyesh/cameraServiceGo/types"
+)
+
+// base is the capture time of the first test frame
+var base = time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+// at returns the time offset seconds after base
+func at(seconds int) time.Time {
+	return base.Add(time.Duration(seconds) * time.Second)
+}
+
+func newTestStore(t *testing.T, dir string, preRoll time.Duration) *Store {
+	t.Helper()
+	s, err := NewStore(dir, preRoll)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(s.Close)
+	return s
+}
+
+// addFrames adds one frame per second from first to last, with the second
+// as frame ID and data
+func addFrames(t *testing.T, s *Store, sourceID string, first, last int) {
+	t.Helper()
+	for i := first; i <= last; i++ {
+		frame := types.FrameData{ID: int64(i), Timestamp: at(i), Data: []byte(fmt.Sprint(i))}
+		if err := s.Add(sourceID, frame); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// frameIDs lists the frames of a source between from and to
+func frameIDs(t *testing.T, s *Store, sourceID string, from, to time.Time, withData bool) []int64 {
+	t.Helper()
+	var ids []int64
+	err := s.Frames(sourceID, from, to, withData, func(frame types.FrameData) error {
+		if withData && string(frame.Data) != fmt.Sprint(frame.ID) {
+			t.Errorf("frame %d has data %q", frame.ID, frame.Data)
+		}
+		if !withData && frame.Data != nil {
+			t.Errorf("frame %d has data without withData", frame.ID)
+		}
+		if frame.Source != sourceID {
+			t.Errorf("frame %d has source %q", frame.ID, frame.Source)
+		}
+		ids = append(ids, frame.ID)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ids
+}
+
+// seq returns the frame IDs first to last
+func seq(first, last int) []int64 {
+	var ids []int64
+	for i := first; i <= last; i++ {
+		ids = append(ids, int64(i))
+	}
+	return ids
+}
+
+func TestFramesMergesRecordingAndBuffer(t *testing.T) {
+	s := newTestStore(t, t.TempDir(), time.Hour)
+
+	// Buffered only, then recorded and buffered, then buffered again
+	addFrames(t, s, "cam", 0, 4)
+	if err := s.Start("cam"); err != nil {
+		t.Fatal(err)
+	}
+	addFrames(t, s, "cam", 5, 9)
+	if err := s.Stop("cam"); err != nil {
+		t.Fatal(err)
+	}
+	addFrames(t, s, "cam", 10, 11)
+
+	for _, withData := range []bool{true, false} {
+		got := frameIDs(t, s, "cam", at(0), at(11), withData)
+		if want := seq(0, 11); !slices.Equal(got, want) {
+			t.Errorf("withData %v: frames %v, want %v", withData, got, want)
+		}
+	}
+	if got, want := frameIDs(t, s, "cam", at(3), at(7), true), seq(3, 7); !slices.Equal(got, want) {
+		t.Errorf("frames %v, want %v", got, want)
+	}
+
+	// Without the buffer only the recording is left
+	s.Remove("cam")
+	if got, want := frameIDs(t, s, "cam", at(0), at(11), true), seq(5, 9); !slices.Equal(got, want) {
+		t.Errorf("after removing the source: frames %v, want %v", got, want)
+	}
+}
+
+func TestStartStopInState(t *testing.T) {
+	s := newTestStore(t, t.TempDir(), time.Second)
+	if err := s.Stop("cam"); !errors.Is(err, ErrNotRecording) {
+		t.Errorf("stop before recording = %v, want %v", err, ErrNotRecording)
+	}
+	if err := s.Start("cam"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Start("cam"); !errors.Is(err, ErrRecording) {
+		t.Errorf("second start = %v, want %v", err, ErrRecording)
+	}
+	if err := s.Stop("cam"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Stop("cam"); !errors.Is(err, ErrNotRecording) {
+		t.Errorf("second stop = %v, want %v", err, ErrNotRecording)
+	}
+}
+
+func TestFramesAcrossSegmentsAfterReopen(t *testing.T) {
+	dir := t.TempDir()
+	s := newTestStore(t, dir, time.Second)
+	if err := s.Start("cam"); err != nil {
+		t.Fatal(err)
+	}
+	// Spans two segments
+	last := int(segmentDuration/time.Second) + 30
+	for i := 0; i <= last; i += 30 {
+		if err := s.Add("cam", types.FrameData{ID: int64(i), Timestamp: at(i), Data: []byte(fmt.Sprint(i))}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	s.Close()
+
+	s = newTestStore(t, dir, time.Second)
+	var want []int64
+	for i := 60; i <= last-30; i += 30 {
+		want = append(want, int64(i))
+	}
+	if got := frameIDs(t, s, "cam", at(45), at(last-15), true); !slices.Equal(got, want) {
+		t.Errorf("frames %v, want %v", got, want)
+	}
+}
+
+// sameRange compares time ranges regardless of time zone
+func sameRange(a, b types.TimeRange) bool {
+	return a.From.Equal(b.From) && a.To.Equal(b.To)
+}
+
+func TestTimelineRangesAndGaps(t *testing.T) {
+	s := newTestStore(t, t.TempDir(), 5*time.Second)
+	if err := s.Start("cam"); err != nil {
+		t.Fatal(err)
+	}
+	addFrames(t, s, "cam", 0, 9)
+	if err := s.Stop("cam"); err != nil {
+		t.Fatal(err)
+	}
+	// The buffer keeps the last 5 seconds; a break shorter than the gap
+	// threshold joins the range
+	addFrames(t, s, "cam", 60, 62)
+	addFrames(t, s, "cam", 64, 65)
+
+	timeline, err := s.Timeline("cam", at(-30), at(100))
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantRanges := []types.TimeRange{{From: at(0), To: at(9)}, {From: at(60), To: at(65)}}
+	if !slices.EqualFunc(timeline.Ranges, wantRanges, sameRange) {
+		t.Errorf("ranges %v, want %v", timeline.Ranges, wantRanges)
+	}
+	wantGaps := []types.TimeRange{{From: at(-30), To: at(0)}, {From: at(9), To: at(60)}, {From: at(65), To: at(100)}}
+	if !slices.EqualFunc(timeline.Gaps, wantGaps, sameRange) {
+		t.Errorf("gaps %v, want %v", timeline.Gaps, wantGaps)
+	}
+
+	// Ranges are clipped to the requested period
+	timeline, err = s.Timeline("cam", at(5), at(61))
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantRanges = []types.TimeRange{{From: at(5), To: at(9)}, {From: at(60), To: at(61)}}
+	if !slices.EqualFunc(timeline.Ranges, wantRanges, sameRange) {
+		t.Errorf("clipped ranges %v, want %v", timeline.Ranges, wantRanges)
+	}
+}
+
+// detection returns a detection event of the given labels
+func detection(id int64, sourceID string, seconds int, labels ...string) types.Event {
+	var detections []types.Detection
+	for _, label := range labels {
+		detections = append(detections, types.Detection{Label: label})
+	}
+	return types.Event{
+		ID:        id,
+		Type:      events.AnalyticsDetection,
+		Source:    sourceID,
+		Timestamp: at(seconds),
+		Data:      map[string]interface{}{"frame_id": id, "detections": detections},
+	}
+}
+
+func TestTimelineEvents(t *testing.T) {
+	s := newTestStore(t, t.TempDir(), time.Second)
+
+	err := s.AddEvents(
+		types.Event{ID: 1, Type: events.SourceStarted, Source: "cam", Timestamp: at(0)},
+		types.Event{ID: 2, Type: events.SubscriberConnected, Timestamp: at(1)}, // No source
+		types.Event{ID: 3, Type: events.TamperAlert, Source: "other", Timestamp: at(2)},
+		types.Event{ID: 4, Type: events.TamperAlert, Source: "cam", Timestamp: at(3), Data: map[string]string{"reason": "covered"}},
+		types.Event{ID: 5, Type: events.SourceStopped, Source: "cam", Timestamp: at(200)},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	timeline, err := s.Timeline("cam", at(0), at(100))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ids []int64
+	for _, event := range timeline.Events {
+		ids = append(ids, event.ID)
+	}
+	if want := []int64{1, 4}; !slices.Equal(ids, want) {
+		t.Fatalf("events %v, want %v", ids, want)
+	}
+	if timeline.EventsTruncated {
+		t.Error("events reported truncated")
+	}
+	marker := timeline.Events[1]
+	if marker.Type != events.TamperAlert || !marker.Time.Equal(at(3)) {
+		t.Errorf("marker = %+v", marker)
+	}
+	if data, _ := marker.Data.(map[string]interface{}); data["reason"] != "covered" {
+		t.Errorf("marker data = %v", marker.Data)
+	}
+
+	markers, truncated, err := s.index.events("cam", at(0), at(300), 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(markers) != 2 || !truncated {
+		t.Errorf("got %d markers, truncated %v, want 2 and truncated", len(markers), truncated)
+	}
+}
+
+func TestDetectionMarkers(t *testing.T) {
+	s := newTestStore(t, t.TempDir(), time.Second)
+
+	// A person in view for ten minutes, detected every second, joined by a
+	// car for a while
+	var published []types.Event
+	for i := 0; i < 600; i++ {
+		labels := []string{"person"}
+		if i >= 100 && i < 130 {
+			labels = append(labels, "car", "person")
+		}
+		published = append(published, detection(int64(i), "cam", i, labels...))
+	}
+	if err := s.AddEvents(published...); err != nil {
+		t.Fatal(err)
+	}
+
+	timeline, err := s.Timeline("cam", at(0), at(600))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var seconds []int
+	for _, event := range timeline.Events {
+		seconds = append(seconds, int(event.Time.Sub(base)/time.Second))
+	}
+	// Start, every two minutes, and the label changes at least the spacing
+	// after the previous marker
+	want := []int{0, 100, 130, 250, 370, 490}
+	if !slices.Equal(seconds, want) {
+		t.Errorf("markers at %v, want %v", seconds, want)
+	}
+}
+
+func TestMarkerThrottle(t *testing.T) {
+	m := newMarkerThrottle()
+	tests := []struct {
+		event types.Event
+		want  bool
+	}{
+		{detection(1, "cam", 0, "person"), true},
+		{detection(2, "other", 1, "person"), true}, // Sources are independent
+		{detection(3, "cam", 10, "car"), false},    // Too close
+		{types.Event{ID: 4, Type: events.TamperAlert, Source: "cam", Timestamp: at(11)}, true},
+		{detection(5, "cam", 40, "person", "person"), false}, // Same labels
+		{detection(6, "cam", 41, "car"), true},
+		{detection(7, "cam", 41+int(detectionMarkerInterval/time.Second), "car"), true},
+	}
+	for _, tt := range tests {
+		if got := m.keep(tt.event); got != tt.want {
+			t.Errorf("keep(event %d) = %v, want %v", tt.event.ID, got, tt.want)
+		}
+	}
+
+	m.forget("cam")
+	if !m.keep(detection(8, "cam", 300, "car")) {
+		t.Error("first detection after forgetting the source was dropped")
+	}
+}
@@ -0,0 +1,87 @@
+package recording
+
+import (
+	"slices"
+	"time"
+
+	"github.com/Thivyesh/cameraServiceGo/types"
+)
+
+// timelineGap is the shortest break in footage reported as a gap. Shorter
+// breaks, e.g. between consecutive segments, are part of a covered range.
+const timelineGap = 5 * time.Second
+
+// maxTimelineEvents bounds the number of event markers in a timeline
+const maxTimelineEvents = 5000
+
+// Timeline returns the periods of a time range covered by footage of a
+// source, the gaps between them and the events of the source. Footage
+// includes the pre-roll buffer, so everything in a covered range can be
+// played back or exported.
+func (s *Store) Timeline(sourceID string, from, to time.Time) (types.Timeline, error) {
+	timeline := types.Timeline{
+		Source: sourceID,
+		From:   from,
+		To:     to,
+		Ranges: []types.TimeRange{},
+		Gaps:   []types.TimeRange{},
+	}
+
+	segs, err := s.segments(sourceID, from, to)
+	if err != nil {
+		return timeline, err
+	}
+	periods := make([]types.TimeRange, 0, len(segs)+1)
+	for _, seg := range segs {
+		periods = append(periods, types.TimeRange{From: seg.start, To: seg.end})
+	}
+	if st := s.find(sourceID); st != nil {
+		st.mu.Lock()
+		if len(st.frames) > 0 {
+			periods = append(periods, types.TimeRange{
+				From: st.frames[0].Timestamp,
+				To:   st.frames[len(st.frames)-1].Timestamp,
+			})
+		}
+		st.mu.Unlock()
+	}
+
+	// The buffer overlaps the recording when the source is being recorded
+	slices.SortFunc(periods, func(a, b types.TimeRange) int {
+		return a.From.Compare(b.From)
+	})
+	for _, period := range periods {
+		if period.From.Before(from) {
+			period.From = from
+		}
+		if period.To.After(to) {
+			period.To = to
+		}
+		if period.To.Before(period.From) {
+			continue
+		}
+		last := len(timeline.Ranges) - 1
+		if last < 0 || period.From.Sub(timeline.Ranges[last].To) >= timelineGap {
+			timeline.Ranges = append(timeline.Ranges, period)
+		} else if period.To.After(timeline.Ranges[last].To) {
+			timeline.Ranges[last].To = period.To
+		}
+	}
+
+	start := from
+	for _, covered := range timeline.Ranges {
+		if covered.From.Sub(start) >= timelineGap {
+			timeline.Gaps = append(timeline.Gaps, types.TimeRange{From: start, To: covered.From})
+		}
+		start = covered.To
+	}
+	if to.Sub(start) >= timelineGap {
+		timeline.Gaps = append(timeline.Gaps, types.TimeRange{From: start, To: to})
+	}
+
+	timeline.Events, timeline.EventsTruncated, err = s.index.events(sourceID, from, to, maxTimelineEvents)
+	if timeline.Events == nil {
+		timeline.Events = []types.TimelineEvent{}
+	}
+	return timeline, err
+}
@@ -0,0 +1,202 @@
+package scheduler
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// cronField is the set of allowed values of a cron field as a bit mask
+type cronField uint64
+
+func (f cronField) has(v int) bool {
+	return f&(1<<uint(v)) != 0
+}
+
+// fieldRange describes the values and names a cron field accepts
+type fieldRange struct {
+	name     string   // Field name for errors
+	min, max int      // Allowed values
+	names    []string // Value names starting at min, e.g. month names
+}
+
+var (
+	minuteRange = fieldRange{name: "minute", min: 0, max: 59}
+	hourRange   = fieldRange{name: "hour", min: 0, max: 23}
+	domRange    = fieldRange{name: "day of month", min: 1, max: 31}
+	monthRange  = fieldRange{name: "month", min: 1, max: 12, names: []string{
+		"jan", "feb", "mar", "apr", "may", "jun", "jul", "aug", "sep", "oct", "nov", "dec"}}
+	// Sunday is 0, 7 is accepted as an alias
+	dowRange = fieldRange{name: "day of week", min: 0, max: 7, names: []string{
+		"sun", "mon", "tue", "wed", "thu", "fri", "sat"}}
+)
+
+// cronMacros are shorthands for common expressions
+var cronMacros = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// cronExpr is a parsed five field cron expression: minute, hour, day of
+// month, month and day of week
+type cronExpr struct {
+	minute, hour, dom, month, dow cronField
+	domAny, dowAny                bool // Whether the day fields are *
+	location                      *time.Location
+}
+
+// parseCron parses a cron expression evaluated in a time zone. Fields accept
+// *, values, ranges (1-5), lists (1,15) and steps (*/15, 9-17/2); months and
+// days of the week also accept three letter names.
+func parseCron(expr string, location *time.Location) (*cronExpr, error) {
+	if macro, ok := cronMacros[strings.ToLower(strings.TrimSpace(expr))]; ok {
+		expr = macro
+	}
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("cron expression must have 5 fields, got %d", len(fields))
+	}
+
+	c := &cronExpr{location: location}
+	var err error
+	if c.minute, err = parseField(fields[0], minuteRange); err != nil {
+		return nil, err
+	}
+	if c.hour, err = parseField(fields[1], hourRange); err != nil {
+		return nil, err
+	}
+	if c.dom, err = parseField(fields[2], domRange); err != nil {
+		return nil, err
+	}
+	if c.month, err = parseField(fields[3], monthRange); err != nil {
+		return nil, err
+	}
+	if c.dow, err = parseField(fields[4], dowRange); err != nil {
+		return nil, err
+	}
+	if c.dow.has(7) {
+		c.dow |= 1
+	}
+	c.domAny = fields[2] == "*" || fields[2] == "?"
+	c.dowAny = fields[4] == "*" || fields[4] == "?"
+	return c, nil
+}
+
+// parseField parses a comma separated list of ranges and steps
+func parseField(field string, r fieldRange) (cronField, error) {
+	var set cronField
+	for _, part := range strings.Split(field, ",") {
+		rng, stepText, hasStep := strings.Cut(part, "/")
+		step := 1
+		if hasStep {
+			var err error
+			if step, err = strconv.Atoi(stepText); err != nil || step < 1 {
+				return 0, fmt.Errorf("invalid %s step: %q", r.name, part)
+			}
+		}
+
+		low, high := r.min, r.max
+		switch {
+		case rng == "*" || rng == "?":
+		case strings.Contains(rng, "-"):
+			first, last, _ := strings.Cut(rng, "-")
+			var err error
+			if low, err = r.value(first); err != nil {
+				return 0, err
+			}
+			if high, err = r.value(last); err != nil {
+				return 0, err
+			}
+			if low > high {
+				return 0, fmt.Errorf("invalid %s range: %q", r.name, part)
+			}
+		default:
+			var err error
+			if low, err = r.value(rng); err != nil {
+				return 0, err
+			}
+			// A single value with a step runs from the value to the maximum
+			high = low
+			if hasStep {
+				high = r.max
+			}
+		}
+
+		for v := low; v <= high; v += step {
+			set |= 1 << uint(v)
+		}
+	}
+	return set, nil
+}
+
+// value parses a number or name within the field range
+func (r fieldRange) value(text string) (int, error) {
+	for i, name := range r.names {
+		if strings.EqualFold(text, name) {
+			return r.min + i, nil
+		}
+	}
+	v, err := strconv.Atoi(text)
+	if err != nil || v < r.min || v > r.max {
+		return 0, fmt.Errorf("invalid %s: %q", r.name, text)
+	}
+	return v, nil
+}
+
+// dayMatches applies the cron rule that a day matches either day field when
+// both are restricted
+func (c *cronExpr) dayMatches(t time.Time) bool {
+	dom := c.dom.has(t.Day())
+	dow := c.dow.has(int(t.Weekday()))
+	switch {
+	case c.domAny && c.dowAny:
+		return true
+	case c.domAny:
+		return dow
+	case c.dowAny:
+		return dom
+	default:
+		return dom || dow
+	}
+}
+
+// next returns the first matching minute after t, or the zero time when the
+// expression matches nothing within five years (e.g. February 30th). Wall
+// clock times skipped by a daylight saving change do not run, repeated ones
+// run twice.
+func (c *cronExpr) next(t time.Time) time.Time {
+	// Truncating the absolute time keeps the offset of a repeated hour
+	t = t.Truncate(time.Minute).Add(time.Minute).In(c.location)
+	limit := t.AddDate(5, 0, 0)
+
+	for t.Before(limit) {
+		var next time.Time
+		switch {
+		case !c.month.has(int(t.Month())):
+			next = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, c.location)
+		case !c.dayMatches(t):
+			next = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, c.location)
+		case !c.hour.has(t.Hour()):
+			// Stepping in absolute time reaches both occurrences of a
+			// repeated hour, time.Date would pick only one of them
+			next = t.Add(time.Duration(60-t.Minute()) * time.Minute)
+		case !c.minute.has(t.Minute()):
+			next = t.Add(time.Minute)
+		default:
+			return t
+		}
+
+		// Normalized wall clock times can fall back within a repeated hour
+		if !next.After(t) {
+			next = t.Add(time.Minute)
+		}
+		t = next
+	}
+	return time.Time{}
+}
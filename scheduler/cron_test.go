@@ -0,0 +1,243 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+)
+
+// fieldOf builds the field matching the given values
+func fieldOf(values ...int) cronField {
+	var f cronField
+	for _, v := range values {
+		f |= 1 << uint(v)
+	}
+	return f
+}
+
+// rangeOf builds the field matching low to high in steps
+func rangeOf(low, high, step int) cronField {
+	var f cronField
+	for v := low; v <= high; v += step {
+		f |= 1 << uint(v)
+	}
+	return f
+}
+
+func TestParseCronFields(t *testing.T) {
+	tests := []struct {
+		expr                          string
+		minute, hour, dom, month, dow cronField
+	}{
+		{"* * * * *", rangeOf(0, 59, 1), rangeOf(0, 23, 1), rangeOf(1, 31, 1), rangeOf(1, 12, 1), rangeOf(0, 7, 1)},
+		{"*/15 9-17/2 1,15 * *", fieldOf(0, 15, 30, 45), fieldOf(9, 11, 13, 15, 17), fieldOf(1, 15), rangeOf(1, 12, 1), rangeOf(0, 7, 1)},
+		{"5/20 0 * * *", fieldOf(5, 25, 45), fieldOf(0), rangeOf(1, 31, 1), rangeOf(1, 12, 1), rangeOf(0, 7, 1)},
+		{"0,30 8-10,14 ? jan,JUL mon-fri", fieldOf(0, 30), fieldOf(8, 9, 10, 14), rangeOf(1, 31, 1), fieldOf(1, 7), rangeOf(1, 5, 1)},
+		{"0 0 * * 7", fieldOf(0), fieldOf(0), rangeOf(1, 31, 1), rangeOf(1, 12, 1), fieldOf(0, 7)},
+		{"0 0 * * 5-7", fieldOf(0), fieldOf(0), rangeOf(1, 31, 1), rangeOf(1, 12, 1), fieldOf(0, 5, 6, 7)},
+		{"@hourly", fieldOf(0), rangeOf(0, 23, 1), rangeOf(1, 31, 1), rangeOf(1, 12, 1), rangeOf(0, 7, 1)},
+		{" @Weekly ", fieldOf(0), fieldOf(0), rangeOf(1, 31, 1), rangeOf(1, 12, 1), fieldOf(0)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			c, err := parseCron(tt.expr, time.UTC)
+			if err != nil {
+				t.Fatal(err)
+			}
+			fields := []struct {
+				name      string
+				got, want cronField
+			}{
+				{"minute", c.minute, tt.minute},
+				{"hour", c.hour, tt.hour},
+				{"day of month", c.dom, tt.dom},
+				{"month", c.month, tt.month},
+				{"day of week", c.dow, tt.dow},
+			}
+			for _, f := range fields {
+				if f.got != f.want {
+					t.Errorf("%s = %b, want %b", f.name, f.got, f.want)
+				}
+			}
+		})
+	}
+}
+
+func TestParseCronInvalid(t *testing.T) {
+	for _, expr := range []string{
+		"",
+		"* * * *",
+		"* * * * * *",
+		"@reboot",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * 32 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"*/0 * * * *",
+		"*/x * * * *",
+		"10-5 * * * *",
+		"1-2-3 * * * *",
+		"a * * * *",
+		"* * * smarch *",
+		"1,,2 * * * *",
+		"-1 * * * *",
+	} {
+		t.Run(expr, func(t *testing.T) {
+			if _, err := parseCron(expr, time.UTC); err == nil {
+				t.Errorf("parseCron(%q) succeeded", expr)
+			}
+		})
+	}
+}
+
+func mustLoad(t *testing.T, name string) *time.Location {
+	t.Helper()
+	location, err := time.LoadLocation(name)
+	if err != nil {
+		t.Skipf("time zone %s unavailable: %v", name, err)
+	}
+	return location
+}
+
+func TestCronNext(t *testing.T) {
+	utc := func(year int, month time.Month, day, hour, min int) time.Time {
+		return time.Date(year, month, day, hour, min, 0, 0, time.UTC)
+	}
+	tests := []struct {
+		name string
+		expr string
+		from time.Time
+		want time.Time
+	}{
+		{"next minute", "* * * * *", utc(2024, 5, 1, 10, 0), utc(2024, 5, 1, 10, 1)},
+		{"seconds are dropped", "* * * * *", utc(2024, 5, 1, 10, 0).Add(59 * time.Second), utc(2024, 5, 1, 10, 1)},
+		{"step", "*/15 * * * *", utc(2024, 5, 1, 10, 16), utc(2024, 5, 1, 10, 30)},
+		{"strictly after", "30 10 * * *", utc(2024, 5, 1, 10, 30), utc(2024, 5, 2, 10, 30)},
+		{"range step", "0 9-17/4 * * *", utc(2024, 5, 1, 13, 1), utc(2024, 5, 1, 17, 0)},
+		{"day rollover", "0 9 * * *", utc(2024, 5, 1, 18, 0), utc(2024, 5, 2, 9, 0)},
+		{"year rollover", "0 0 1 1 *", utc(2024, 12, 31, 23, 59), utc(2025, 1, 1, 0, 0)},
+		{"weekdays skip the weekend", "0 9 * * mon-fri", utc(2024, 5, 3, 10, 0), utc(2024, 5, 6, 9, 0)},
+		{"sunday as 7", "0 0 * * 7", utc(2024, 5, 1, 0, 0), utc(2024, 5, 5, 0, 0)},
+		{"month without the day", "0 0 31 * *", utc(2024, 4, 1, 0, 0), utc(2024, 5, 31, 0, 0)},
+		{"leap day", "0 0 29 2 *", utc(2024, 3, 1, 0, 0), utc(2028, 2, 29, 0, 0)},
+		{"impossible date", "0 0 30 2 *", utc(2024, 1, 1, 0, 0), time.Time{}},
+		// Day of month 13 or any Friday; May 3rd 2024 is a Friday
+		{"day fields or", "0 0 13 * 5", utc(2024, 5, 1, 0, 0), utc(2024, 5, 3, 0, 0)},
+		{"day fields or, day of month", "0 0 13 * 5", utc(2024, 5, 10, 0, 0), utc(2024, 5, 13, 0, 0)},
+		// A restricted day of month with any day of week only matches the date
+		{"day of month only", "0 0 13 * *", utc(2024, 5, 1, 0, 0), utc(2024, 5, 13, 0, 0)},
+		{"day of week only", "0 0 ? * 5", utc(2024, 5, 4, 0, 0), utc(2024, 5, 10, 0, 0)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := parseCron(tt.expr, time.UTC)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := c.next(tt.from); !got.Equal(tt.want) {
+				t.Errorf("next(%v) = %v, want %v", tt.from, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCronNextTimeZone(t *testing.T) {
+	newYork := mustLoad(t, "America/New_York")
+	c, err := parseCron("0 9 * * *", newYork)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 9:00 in New York is 13:00 UTC in summer and 14:00 UTC in winter
+	tests := []struct {
+		from, want time.Time
+	}{
+		{time.Date(2024, 7, 1, 12, 0, 0, 0, time.UTC), time.Date(2024, 7, 1, 13, 0, 0, 0, time.UTC)},
+		{time.Date(2024, 7, 1, 13, 0, 0, 0, time.UTC), time.Date(2024, 7, 2, 13, 0, 0, 0, time.UTC)},
+		{time.Date(2024, 1, 15, 13, 30, 0, 0, time.UTC), time.Date(2024, 1, 15, 14, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		got := c.next(tt.from)
+		if !got.Equal(tt.want) {
+			t.Errorf("next(%v) = %v, want %v", tt.from, got, tt.want)
+		}
+		if got.Location() != newYork {
+			t.Errorf("next(%v) is in %v, want the schedule's time zone", tt.from, got.Location())
+		}
+	}
+
+	// Offsets that are not whole hours keep hour boundaries in local time
+	kolkata := mustLoad(t, "Asia/Kolkata")
+	if c, err = parseCron("0 9 * * *", kolkata); err != nil {
+		t.Fatal(err)
+	}
+	from, want := time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 7, 1, 3, 30, 0, 0, time.UTC)
+	if got := c.next(from); !got.Equal(want) {
+		t.Errorf("next(%v) = %v, want %v", from, got, want)
+	}
+}
+
+func TestCronNextDaylightSaving(t *testing.T) {
+	berlin := mustLoad(t, "Europe/Berlin")
+	at := func(month time.Month, day, hour, min int, utcOffset time.Duration) time.Time {
+		return time.Date(2024, month, day, hour, min, 0, 0, time.UTC).Add(-utcOffset)
+	}
+	const cet, cest = time.Hour, 2 * time.Hour
+
+	tests := []struct {
+		name string
+		expr string
+		from time.Time
+		want []time.Time // Consecutive runs
+	}{
+		{
+			// Clocks jump from 2:00 to 3:00 on March 31st, so 2:30 does not exist
+			name: "spring forward skips the missing time",
+			expr: "30 2 * * *",
+			from: at(3, 30, 12, 0, cet),
+			want: []time.Time{at(4, 1, 2, 30, cest)},
+		},
+		{
+			name: "spring forward keeps later times",
+			expr: "0 3 * * *",
+			from: at(3, 30, 12, 0, cet),
+			want: []time.Time{at(3, 31, 3, 0, cest), at(4, 1, 3, 0, cest)},
+		},
+		{
+			name: "spring forward hourly",
+			expr: "0 * * * *",
+			from: at(3, 31, 0, 30, cet),
+			want: []time.Time{at(3, 31, 1, 0, cet), at(3, 31, 3, 0, cest), at(3, 31, 4, 0, cest)},
+		},
+		{
+			// Clocks fall back from 3:00 to 2:00 on October 27th, so 2:30 happens twice
+			name: "fall back repeats the time",
+			expr: "30 2 * * *",
+			from: at(10, 26, 12, 0, cest),
+			want: []time.Time{at(10, 27, 2, 30, cest), at(10, 27, 2, 30, cet), at(10, 28, 2, 30, cet)},
+		},
+		{
+			name: "fall back keeps other times single",
+			expr: "0 4 * * *",
+			from: at(10, 26, 12, 0, cest),
+			want: []time.Time{at(10, 27, 4, 0, cet), at(10, 28, 4, 0, cet)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := parseCron(tt.expr, berlin)
+			if err != nil {
+				t.Fatal(err)
+			}
+			from := tt.from
+			for i, want := range tt.want {
+				got := c.next(from)
+				if !got.Equal(want) {
+					t.Fatalf("run %d: next(%v) = %v, want %v", i+1, from.In(berlin), got, want.In(berlin))
+				}
+				from = got
+			}
+		})
+	}
+}
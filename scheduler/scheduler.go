@@ -0,0 +1,467 @@
+// Package scheduler runs actions on sources at times given by cron
+// expressions
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Thivyesh/cameraServiceGo/ids"
+	"github.com/Thivyesh/cameraServiceGo/jsonfile"
+	"github.com/Thivyesh/cameraServiceGo/types"
+)
+
+// historySize is the number of executions kept across all schedules
+const historySize = 1000
+
+// snapshotTimeout bounds how long a snapshot action waits for a frame
+const snapshotTimeout = 10 * time.Second
+
+// Files in the scheduler directory
+const (
+	schedulesFile = "schedules.json"
+	historyFile   = "history.json"
+	snapshotsDir  = "snapshots"
+)
+
+// ErrNotFound is returned for unknown schedules and snapshots
+var ErrNotFound = errors.New("not found")
+
+// Target runs scheduled actions on sources. Capture and recording actions
+// succeed when the source is already in the requested state, so schedules
+// can be repeated.
+type Target interface {
+	EnsurePaused(sourceID string) error
+	EnsureRunning(sourceID string) error
+	EnsureRecording(sourceID string) error
+	EnsureNotRecording(sourceID string) error
+	Snapshot(sourceID string, timeout time.Duration) (types.FrameData, error)
+	SetPipeline(sourceID string, stages []types.StageConfig) error
+}
+
+// entry is a schedule with its parsed expression
+type entry struct {
+	schedule types.Schedule // Definition
+	cron     *cronExpr      // Parsed expression
+	next     time.Time      // Next run, zero when disabled or never
+}
+
+// Scheduler runs schedules and keeps their definitions and execution
+// history in a directory
+type Scheduler struct {
+	dir     string              // Absolute scheduler directory
+	target  Target              // Runs the actions
+	mu      sync.Mutex          // Protects shared state
+	entries map[string]*entry   // Schedules by ID
+	history []types.ScheduleRun // Executions, oldest first
+	wake    chan struct{}       // Signals schedule changes to the loop
+	cancel  context.CancelFunc  // Stops the loop
+}
+
+// New opens or creates a scheduler directory and starts running its
+// schedules. Runs missed while the service was down are skipped.
+func New(dir string, target Target) (*Scheduler, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Join(dir, snapshotsDir), 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create scheduler directory: %v", err)
+	}
+
+	s := &Scheduler{
+		dir:     dir,
+		target:  target,
+		entries: make(map[string]*entry),
+		wake:    make(chan struct{}, 1),
+	}
+
+	var schedules []types.Schedule
+	if err := jsonfile.Read(filepath.Join(dir, schedulesFile), &schedules); err != nil {
+		return nil, fmt.Errorf("failed to load schedules: %v", err)
+	}
+	now := time.Now()
+	for _, schedule := range schedules {
+		e, err := compile(schedule)
+		if err != nil {
+			log.Printf("Skipping schedule %s: %v", schedule.ID, err)
+			continue
+		}
+		e.plan(now)
+		s.entries[schedule.ID] = e
+	}
+	if err := jsonfile.Read(filepath.Join(dir, historyFile), &s.history); err != nil {
+		log.Printf("Discarding unreadable schedule history: %v", err)
+		s.history = nil
+	}
+	if err := s.removeUnlistedSnapshots(); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancel = cancel
+	go s.run(ctx)
+	return s, nil
+}
+
+// compile validates a schedule and parses its expression
+func compile(schedule types.Schedule) (*entry, error) {
+	switch schedule.Action {
+	case "start_capture", "stop_capture", "start_recording", "stop_recording", "snapshot", "set_pipeline":
+	default:
+		return nil, fmt.Errorf("unsupported action: %s", schedule.Action)
+	}
+	if schedule.Source == "" {
+		return nil, fmt.Errorf("source is required")
+	}
+	if schedule.Action != "set_pipeline" && schedule.Pipeline != nil {
+		return nil, fmt.Errorf("pipeline is only used by set_pipeline")
+	}
+
+	location := time.Local
+	if schedule.TimeZone != "" {
+		var err error
+		if location, err = time.LoadLocation(schedule.TimeZone); err != nil {
+			return nil, fmt.Errorf("invalid time zone: %v", err)
+		}
+	}
+	cron, err := parseCron(schedule.Cron, location)
+	if err != nil {
+		return nil, err
+	}
+	return &entry{schedule: schedule, cron: cron}, nil
+}
+
+// plan computes the next run after now
+func (e *entry) plan(now time.Time) {
+	e.next = time.Time{}
+	if !e.schedule.Disabled {
+		e.next = e.cron.next(now)
+	}
+}
+
+// run waits for the earliest due schedule and executes all due schedules
+func (s *Scheduler) run(ctx context.Context) {
+	for {
+		s.mu.Lock()
+		var earliest time.Time
+		for _, e := range s.entries {
+			if !e.next.IsZero() && (earliest.IsZero() || e.next.Before(earliest)) {
+				earliest = e.next
+			}
+		}
+		s.mu.Unlock()
+
+		var due <-chan time.Time
+		var timer *time.Timer
+		if !earliest.IsZero() {
+			timer = time.NewTimer(time.Until(earliest))
+			due = timer.C
+		}
+
+		select {
+		case <-ctx.Done():
+		case <-s.wake:
+		case <-due:
+			s.runDue(time.Now())
+		}
+		if timer != nil {
+			timer.Stop()
+		}
+		if ctx.Err() != nil {
+			return
+		}
+	}
+}
+
+// runDue starts the schedules whose run time has come and plans their next
+// run
+func (s *Scheduler) runDue(now time.Time) {
+	s.mu.Lock()
+	var due []types.Schedule
+	for _, e := range s.entries {
+		if !e.next.IsZero() && !e.next.After(now) {
+			due = append(due, e.schedule)
+			e.plan(now)
+		}
+	}
+	s.mu.Unlock()
+
+	for _, schedule := range due {
+		go s.execute(schedule, false)
+	}
+}
+
+// execute runs a schedule's action and records the outcome
+func (s *Scheduler) execute(schedule types.Schedule, manual bool) types.ScheduleRun {
+	run := types.ScheduleRun{
+		Schedule: schedule.ID,
+		Action:   schedule.Action,
+		Source:   schedule.Source,
+		Time:     time.Now(),
+		Manual:   manual,
+	}
+
+	var err error
+	switch schedule.Action {
+	case "start_capture":
+		err = s.target.EnsureRunning(schedule.Source)
+	case "stop_capture":
+		err = s.target.EnsurePaused(schedule.Source)
+	case "start_recording":
+		err = s.target.EnsureRecording(schedule.Source)
+	case "stop_recording":
+		err = s.target.EnsureNotRecording(schedule.Source)
+	case "set_pipeline":
+		err = s.target.SetPipeline(schedule.Source, schedule.Pipeline)
+	case "snapshot":
+		run.Snapshot, err = s.snapshot(schedule)
+	}
+	run.Success = err == nil
+	if err != nil {
+		run.Error = err.Error()
+		log.Printf("Schedule %s (%s on %s) failed: %v", schedule.ID, schedule.Action, schedule.Source, err)
+	}
+
+	s.mu.Lock()
+	s.history = append(s.history, run)
+	var dropped []types.ScheduleRun
+	if len(s.history) > historySize {
+		dropped = s.history[:len(s.history)-historySize]
+		s.history = slices.Clone(s.history[len(s.history)-historySize:])
+	}
+	err = jsonfile.Write(filepath.Join(s.dir, historyFile), s.history)
+	s.mu.Unlock()
+	if err != nil {
+		log.Printf("Error saving schedule history: %v", err)
+	}
+
+	// Snapshots are only reachable through the history
+	for _, old := range dropped {
+		if old.Snapshot != "" {
+			os.Remove(filepath.Join(s.dir, snapshotsDir, old.Snapshot))
+		}
+	}
+	return run
+}
+
+// removeUnlistedSnapshots deletes snapshot files no kept execution refers
+// to, left behind when the history was trimmed or discarded
+func (s *Scheduler) removeUnlistedSnapshots() error {
+	listed := make(map[string]bool)
+	for _, run := range s.history {
+		if run.Snapshot != "" {
+			listed[run.Snapshot] = true
+		}
+	}
+	files, err := os.ReadDir(filepath.Join(s.dir, snapshotsDir))
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		if !listed[file.Name()] {
+			os.Remove(filepath.Join(s.dir, snapshotsDir, file.Name()))
+		}
+	}
+	return nil
+}
+
+// snapshot stores the next frame of the schedule's source and returns the
+// file name
+func (s *Scheduler) snapshot(schedule types.Schedule) (string, error) {
+	frame, err := s.target.Snapshot(schedule.Source, snapshotTimeout)
+	if err != nil {
+		return "", err
+	}
+	name := fmt.Sprintf("%s_%d.jpg", schedule.ID, frame.Timestamp.UnixNano())
+	if err := os.WriteFile(filepath.Join(s.dir, snapshotsDir, name), frame.Data, 0o644); err != nil {
+		return "", err
+	}
+	return name, nil
+}
+
+// info returns the status of an entry. The caller must hold the lock.
+func (s *Scheduler) info(e *entry) types.ScheduleInfo {
+	info := types.ScheduleInfo{Schedule: e.schedule}
+	if !e.next.IsZero() {
+		next := e.next
+		info.NextRun = &next
+	}
+	for i := len(s.history) - 1; i >= 0; i-- {
+		if s.history[i].Schedule == e.schedule.ID {
+			run := s.history[i]
+			info.LastRun = &run
+			break
+		}
+	}
+	return info
+}
+
+// save stores all schedules. The caller must hold the lock.
+func (s *Scheduler) save() error {
+	schedules := make([]types.Schedule, 0, len(s.entries))
+	for _, e := range s.entries {
+		schedules = append(schedules, e.schedule)
+	}
+	slices.SortFunc(schedules, func(a, b types.Schedule) int {
+		return strings.Compare(a.ID, b.ID)
+	})
+	if err := jsonfile.Write(filepath.Join(s.dir, schedulesFile), schedules); err != nil {
+		return fmt.Errorf("failed to save schedules: %v", err)
+	}
+	return nil
+}
+
+// notify wakes the loop to reconsider the next run
+func (s *Scheduler) notify() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// List returns all schedules ordered by ID
+func (s *Scheduler) List() []types.ScheduleInfo {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	infos := make([]types.ScheduleInfo, 0, len(s.entries))
+	for _, e := range s.entries {
+		infos = append(infos, s.info(e))
+	}
+	slices.SortFunc(infos, func(a, b types.ScheduleInfo) int {
+		return strings.Compare(a.Schedule.ID, b.Schedule.ID)
+	})
+	return infos
+}
+
+// Get returns a schedule and its next run
+func (s *Scheduler) Get(id string) (types.ScheduleInfo, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.entries[id]
+	if !ok {
+		return types.ScheduleInfo{}, fmt.Errorf("schedule %w: %s", ErrNotFound, id)
+	}
+	return s.info(e), nil
+}
+
+// Create validates and stores a new schedule
+func (s *Scheduler) Create(schedule types.Schedule) (types.ScheduleInfo, error) {
+	schedule.ID = ids.New("schedule_")
+	return s.put(schedule, false)
+}
+
+// Update replaces the definition of a schedule
+func (s *Scheduler) Update(id string, schedule types.Schedule) (types.ScheduleInfo, error) {
+	schedule.ID = id
+	return s.put(schedule, true)
+}
+
+func (s *Scheduler) put(schedule types.Schedule, replace bool) (types.ScheduleInfo, error) {
+	e, err := compile(schedule)
+	if err != nil {
+		return types.ScheduleInfo{}, err
+	}
+	e.plan(time.Now())
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	old, exists := s.entries[schedule.ID]
+	if replace && !exists {
+		return types.ScheduleInfo{}, fmt.Errorf("schedule %w: %s", ErrNotFound, schedule.ID)
+	}
+	s.entries[schedule.ID] = e
+	if err := s.save(); err != nil {
+		if exists {
+			s.entries[schedule.ID] = old
+		} else {
+			delete(s.entries, schedule.ID)
+		}
+		return types.ScheduleInfo{}, err
+	}
+	s.notify()
+	return s.info(e), nil
+}
+
+// Delete removes a schedule. Its history and snapshots are kept until they
+// fall out of the history.
+func (s *Scheduler) Delete(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.entries[id]
+	if !ok {
+		return fmt.Errorf("schedule %w: %s", ErrNotFound, id)
+	}
+	delete(s.entries, id)
+	if err := s.save(); err != nil {
+		s.entries[id] = e
+		return err
+	}
+	s.notify()
+	return nil
+}
+
+// Run executes a schedule's action immediately, regardless of whether it is
+// disabled
+func (s *Scheduler) Run(id string) (types.ScheduleRun, error) {
+	s.mu.Lock()
+	e, ok := s.entries[id]
+	s.mu.Unlock()
+	if !ok {
+		return types.ScheduleRun{}, fmt.Errorf("schedule %w: %s", ErrNotFound, id)
+	}
+	return s.execute(e.schedule, true), nil
+}
+
+// History returns the executions of a schedule, most recent first. A limit
+// of 0 returns all kept executions.
+func (s *Scheduler) History(id string, limit int) []types.ScheduleRun {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	runs := []types.ScheduleRun{}
+	for i := len(s.history) - 1; i >= 0 && (limit == 0 || len(runs) < limit); i-- {
+		if s.history[i].Schedule == id {
+			runs = append(runs, s.history[i])
+		}
+	}
+	return runs
+}
+
+// SnapshotPath returns the file of a still taken by a schedule
+func (s *Scheduler) SnapshotPath(id, name string) (string, error) {
+	base, ok := strings.CutPrefix(name, id+"_")
+	if !ok || !strings.HasSuffix(base, ".jpg") {
+		return "", fmt.Errorf("snapshot %w: %s", ErrNotFound, name)
+	}
+	if _, err := strconv.ParseInt(strings.TrimSuffix(base, ".jpg"), 10, 64); err != nil {
+		return "", fmt.Errorf("snapshot %w: %s", ErrNotFound, name)
+	}
+
+	path := filepath.Join(s.dir, snapshotsDir, name)
+	if filepath.Dir(path) != filepath.Join(s.dir, snapshotsDir) {
+		return "", fmt.Errorf("snapshot %w: %s", ErrNotFound, name)
+	}
+	if _, err := os.Stat(path); err != nil {
+		return "", fmt.Errorf("snapshot %w: %s", ErrNotFound, name)
+	}
+	return path, nil
+}
+
+// Close stops running schedules
+func (s *Scheduler) Close() {
+	s.cancel()
+}
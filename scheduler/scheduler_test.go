@@ -0,0 +1,94 @@
+package scheduler
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/Thivyesh/cameraServiceGo/types"
+)
+
+// fakeTarget returns a frame for every snapshot and fails other actions
+type fakeTarget struct {
+	frames int // Snapshots taken
+}
+
+func (f *fakeTarget) EnsurePaused(sourceID string) error       { return fmt.Errorf("unsupported") }
+func (f *fakeTarget) EnsureRunning(sourceID string) error      { return fmt.Errorf("unsupported") }
+func (f *fakeTarget) EnsureRecording(sourceID string) error    { return fmt.Errorf("unsupported") }
+func (f *fakeTarget) EnsureNotRecording(sourceID string) error { return fmt.Errorf("unsupported") }
+
+func (f *fakeTarget) SetPipeline(sourceID string, stages []types.StageConfig) error {
+	return fmt.Errorf("unsupported")
+}
+
+func (f *fakeTarget) Snapshot(sourceID string, timeout time.Duration) (types.FrameData, error) {
+	f.frames++
+	return types.FrameData{Timestamp: time.Unix(0, int64(f.frames)), Data: []byte("jpeg")}, nil
+}
+
+// snapshotFiles lists the stored snapshots
+func snapshotFiles(t *testing.T, dir string) []string {
+	t.Helper()
+	files, err := os.ReadDir(filepath.Join(dir, snapshotsDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, file := range files {
+		names = append(names, file.Name())
+	}
+	return names
+}
+
+func TestSnapshotsFollowHistory(t *testing.T) {
+	dir := t.TempDir()
+	s, err := New(dir, &fakeTarget{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	info, err := s.Create(types.Schedule{Cron: "0 0 1 1 *", Source: "cam", Action: "snapshot"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	id := info.Schedule.ID
+
+	// Fill the history with runs whose snapshots exist
+	first, err := s.Run(id)
+	if err != nil || !first.Success {
+		t.Fatalf("run = %+v, %v", first, err)
+	}
+	s.mu.Lock()
+	for len(s.history) < historySize {
+		s.history = append(s.history, types.ScheduleRun{Schedule: "other"})
+	}
+	s.mu.Unlock()
+
+	// The first run falls out of the history with its snapshot
+	last, err := s.Run(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := snapshotFiles(t, dir), []string{last.Snapshot}; !slices.Equal(got, want) {
+		t.Errorf("snapshots %v, want %v", got, want)
+	}
+
+	// Files no kept run refers to are removed on startup
+	orphan := filepath.Join(dir, snapshotsDir, "gone_1.jpg")
+	if err := os.WriteFile(orphan, []byte("jpeg"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	s.Close()
+	s, err = New(dir, &fakeTarget{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	if got, want := snapshotFiles(t, dir), []string{last.Snapshot}; !slices.Equal(got, want) {
+		t.Errorf("snapshots after reopening %v, want %v", got, want)
+	}
+}
@@ -3,26 +3,119 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"log"
+	"slices"
 	"sync"
+	"time"
 
+	"github.com/Thivyesh/cameraServiceGo/devices"
+	"github.com/Thivyesh/cameraServiceGo/events"
+	"github.com/Thivyesh/cameraServiceGo/ids"
+	"github.com/Thivyesh/cameraServiceGo/media"
+	"github.com/Thivyesh/cameraServiceGo/metrics"
+	"github.com/Thivyesh/cameraServiceGo/recording"
 	"github.com/Thivyesh/cameraServiceGo/source"
 	"github.com/Thivyesh/cameraServiceGo/types"
 )
 
+// eventHistorySize is the number of events kept for Last-Event-ID resume
+const eventHistorySize = 1000
+
+// recordQueueSize is the number of frames of a source waiting to be buffered
+// and recorded before further frames are dropped
+const recordQueueSize = 100
+
+// indexBatchSize is the most timeline events written in one transaction
+const indexBatchSize = 256
+
+// timelineEvents are the event types marked on the timelines of sources
+var timelineEvents = []string{"source.*", "analytics.*", "tamper.*", "recording.*"}
+
 // CameraService manages multiple video sources and their subscribers
 type CameraService struct {
 	mu          sync.RWMutex                      // Protects shared state
 	sources     map[string]*source.VideoSource    // Active video sources
 	subscribers map[string][]chan types.FrameData // Subscribers per source
+	events      *events.Bus                       // Service event bus
+	media       *media.Library                    // Uploaded videos
+	recordings  *recording.Store                  // Pre-roll buffers and recordings
+	exports     *recording.Exporter               // Clip exports
 }
 
-// NewCameraService creates a new camera service instance
-func NewCameraService() *CameraService {
-	return &CameraService{
+// NewCameraService creates a new camera service instance playing uploaded
+// videos from library and keeping footage in recordings
+func NewCameraService(library *media.Library, recordings *recording.Store, exports *recording.Exporter) *CameraService {
+	s := &CameraService{
 		sources:     make(map[string]*source.VideoSource),
 		subscribers: make(map[string][]chan types.FrameData),
+		events:      events.NewBus(eventHistorySize),
+		media:       library,
+		recordings:  recordings,
+		exports:     exports,
 	}
+
+	// The index must see every event, a dropped one is missing for good
+	sub := s.events.SubscribeLossless(events.Filter{Types: timelineEvents})
+	go s.indexEvents(sub)
+
+	return s
+}
+
+// indexEvents marks the events of sources on their recording timelines,
+// writing the events that arrived meanwhile together
+func (s *CameraService) indexEvents(sub *events.Subscription) {
+	for event := range sub.C {
+		batch := []types.Event{event}
+	drain:
+		for len(batch) < indexBatchSize {
+			select {
+			case event := <-sub.C:
+				batch = append(batch, event)
+			default:
+				break drain
+			}
+		}
+		if err := s.recordings.AddEvents(batch...); err != nil {
+			log.Printf("Error indexing %d events: %v", len(batch), err)
+		}
+	}
+}
+
+// Events returns the service event bus
+func (s *CameraService) Events() *events.Bus {
+	return s.events
+}
+
+// Media returns the library of uploaded videos
+func (s *CameraService) Media() *media.Library {
+	return s.media
+}
+
+// Exports returns the clip exports
+func (s *CameraService) Exports() *recording.Exporter {
+	return s.exports
+}
+
+// Recordings returns the pre-roll buffers and recordings of sources
+func (s *CameraService) Recordings() *recording.Store {
+	return s.recordings
+}
+
+// DeleteMedia removes an uploaded video that no source is playing
+func (s *CameraService) DeleteMedia(id string) error {
+	// Hold the lock until the video is gone so AddSource cannot start
+	// playing it in between
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for sourceID, src := range s.sources {
+		if src.GetInfo().MediaID == id {
+			return fmt.Errorf("media %s is in use by source %s", id, sourceID)
+		}
+	}
+	return s.media.Delete(id)
 }
 
 // AddSource adds a new video source to the service
@@ -30,6 +123,33 @@ func (s *CameraService) AddSource(ctx context.Context, config types.SourceConfig
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
+	// Library videos are played as file sources
+	if config.MediaID != "" {
+		if config.Type == "" {
+			config.Type = "file"
+		}
+		if config.Type != "file" {
+			return "", fmt.Errorf("media can only be played by file sources")
+		}
+		path, err := s.media.Path(config.MediaID)
+		if err != nil {
+			return "", err
+		}
+		config.URI = path
+	}
+
+	// Playback sources are named after the played source unless given a URI
+	if config.Type == "playback" && config.Playback != nil {
+		playback := *config.Playback
+		if playback.To.IsZero() {
+			playback.To = time.Now()
+		}
+		config.Playback = &playback
+		if config.URI == "" {
+			config.URI = playback.Source
+		}
+	}
+
 	// Generate source ID
 	sourceID := fmt.Sprintf("%s_%s", config.Type, config.URI)
 
@@ -38,8 +158,34 @@ func (s *CameraService) AddSource(ctx context.Context, config types.SourceConfig
 		return "", fmt.Errorf("source already exists: %s", sourceID)
 	}
 
+	// Derived views need their parent to exist when they are created
+	if config.Type == "derived" && config.Derived != nil {
+		if _, exists := s.sources[config.Derived.Parent]; !exists {
+			return "", fmt.Errorf("parent source not found: %s", config.Derived.Parent)
+		}
+	}
+
+	if config.Type == "push" && config.IngestToken == "" {
+		config.IngestToken = ids.Token()
+	}
+
+	for i := range config.PrivacyZones {
+		if config.PrivacyZones[i].ID == "" {
+			config.PrivacyZones[i].ID = ids.New("zone_")
+		}
+	}
+
 	// Create and initialize new source
-	videoSource := source.NewVideoSource(config)
+	videoSource := source.NewVideoSource(config, source.Dependencies{
+		Events:     s.events,
+		Frames:     s,
+		Recordings: s.recordings,
+	})
+
+	// Sources feeding each other would pass frames around forever
+	if s.feedsInto(videoSource.Feeds(), sourceID) {
+		return "", fmt.Errorf("source %s would consume its own frames through its inputs", sourceID)
+	}
 
 	bgCtx := context.Background()
 	if err := videoSource.Start(bgCtx); err != nil {
@@ -53,6 +199,16 @@ func (s *CameraService) AddSource(ctx context.Context, config types.SourceConfig
 	// Start frame distribution
 	go s.distributeFrames(bgCtx, sourceID)
 
+	// Never publish access tokens or credentials
+	config.RawAccessToken = ""
+	config.IngestToken = ""
+	if config.HTTP != nil {
+		http := *config.HTTP
+		http.Password = ""
+		config.HTTP = &http
+	}
+	s.events.Publish(events.SourceAdded, sourceID, config)
+
 	return sourceID, nil
 }
 
@@ -69,15 +225,42 @@ func (s *CameraService) Subscribe(sourceID string) (<-chan types.FrameData, erro
 	// Create subscriber channel
 	subscriber := make(chan types.FrameData, 100)
 	s.subscribers[sourceID] = append(s.subscribers[sourceID], subscriber)
+	metrics.Subscribers.WithLabelValues(sourceID).Set(float64(len(s.subscribers[sourceID])))
+
+	s.events.Publish(events.SubscriberConnected, sourceID, map[string]int{
+		"subscribers": len(s.subscribers[sourceID]),
+	})
 
 	return subscriber, nil
 }
 
+// Unsubscribe removes a subscription created by Subscribe
+func (s *CameraService) Unsubscribe(sourceID string, frames <-chan types.FrameData) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	subs := s.subscribers[sourceID]
+	for i, sub := range subs {
+		if sub == frames {
+			s.subscribers[sourceID] = append(subs[:i:i], subs[i+1:]...)
+			metrics.Subscribers.WithLabelValues(sourceID).Set(float64(len(s.subscribers[sourceID])))
+			s.events.Publish(events.SubscriberDisconnected, sourceID, map[string]int{
+				"subscribers": len(s.subscribers[sourceID]),
+			})
+			return
+		}
+	}
+}
+
 // distributeFrames handles frame distribution to subscribers
 func (s *CameraService) distributeFrames(ctx context.Context, sourceID string) {
 	source := s.sources[sourceID]
 	frames := source.GetFrames()
 
+	record := make(chan types.FrameData, recordQueueSize)
+	defer close(record)
+	go s.recordFrames(sourceID, record)
+
 	for {
 		select {
 		case <-ctx.Done():
@@ -87,10 +270,21 @@ func (s *CameraService) distributeFrames(ctx context.Context, sourceID string) {
 				return
 			}
 
-			// Get current subscribers
+			// Sends do not block, so they happen under the lock. RemoveSource
+			// can then neither close subscribers nor drop the metrics of the
+			// source in the middle.
 			s.mu.RLock()
-			subs := s.subscribers[sourceID]
-			s.mu.RUnlock()
+			subs, exists := s.subscribers[sourceID]
+
+			// Keep the frame for exports, unless the source was just removed
+			if exists {
+				select {
+				case record <- frame:
+				default:
+					// Skip if the disk is not keeping up
+					metrics.RecordingDropped.WithLabelValues(sourceID).Inc()
+				}
+			}
 
 			// Distribute frame to all subscribers
 			for _, sub := range subs {
@@ -99,8 +293,30 @@ func (s *CameraService) distributeFrames(ctx context.Context, sourceID string) {
 					// Frame sent successfully
 				default:
 					// Skip if subscriber is not keeping up
+					metrics.SubscriberDropped.WithLabelValues(sourceID).Inc()
 				}
 			}
+			s.mu.RUnlock()
+		}
+	}
+}
+
+// recordFrames buffers and records the frames of a source. Disk writes
+// happen here so a slow disk does not hold up subscribers.
+func (s *CameraService) recordFrames(sourceID string, frames <-chan types.FrameData) {
+	for frame := range frames {
+		s.mu.RLock()
+		_, exists := s.sources[sourceID]
+		s.mu.RUnlock()
+		if !exists {
+			continue
+		}
+
+		if err := s.recordings.Add(sourceID, frame); err != nil {
+			log.Printf("Error recording source %s: %v", sourceID, err)
+			s.events.Publish(events.RecordingStopped, sourceID, map[string]string{
+				"error": err.Error(),
+			})
 		}
 	}
 }
@@ -127,9 +343,311 @@ func (s *CameraService) RemoveSource(sourceID string) error {
 	delete(s.sources, sourceID)
 	delete(s.subscribers, sourceID)
 
+	if s.recordings.Recording(sourceID) {
+		s.events.Publish(events.RecordingStopped, sourceID, nil)
+	}
+	s.recordings.Remove(sourceID)
+	metrics.RemoveSource(sourceID)
+
+	s.events.Publish(events.SourceRemoved, sourceID, nil)
+
+	return nil
+}
+
+// getSource looks up a source by ID
+func (s *CameraService) getSource(sourceID string) (*source.VideoSource, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	source, exists := s.sources[sourceID]
+	if !exists {
+		return nil, fmt.Errorf("source not found: %s", sourceID)
+	}
+	return source, nil
+}
+
+// SetPipeline replaces the processing stages of a source
+func (s *CameraService) SetPipeline(sourceID string, stages []types.StageConfig) error {
+	source, err := s.getSource(sourceID)
+	if err != nil {
+		return err
+	}
+	return source.SetPipeline(stages)
+}
+
+// GetPipeline returns the processing stages of a source and their timings
+func (s *CameraService) GetPipeline(sourceID string) (types.PipelineInfo, error) {
+	source, err := s.getSource(sourceID)
+	if err != nil {
+		return types.PipelineInfo{}, err
+	}
+	return source.GetPipeline(), nil
+}
+
+// GetPrivacyZones returns the privacy zones of a source
+func (s *CameraService) GetPrivacyZones(sourceID string) ([]types.PrivacyZone, error) {
+	source, err := s.getSource(sourceID)
+	if err != nil {
+		return nil, err
+	}
+	zones := source.GetPrivacyZones()
+	if zones == nil {
+		zones = []types.PrivacyZone{}
+	}
+	return zones, nil
+}
+
+// SetPrivacyZones replaces the privacy zones of a source. Zones without an ID
+// are assigned one.
+func (s *CameraService) SetPrivacyZones(sourceID string, zones []types.PrivacyZone) ([]types.PrivacyZone, error) {
+	source, err := s.getSource(sourceID)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range zones {
+		if zones[i].ID == "" {
+			zones[i].ID = ids.New("zone_")
+		}
+	}
+	if err := source.SetPrivacyZones(zones); err != nil {
+		return nil, err
+	}
+	return zones, nil
+}
+
+// AddPrivacyZone adds a privacy zone to a source
+func (s *CameraService) AddPrivacyZone(sourceID string, zone types.PrivacyZone) (types.PrivacyZone, error) {
+	source, err := s.getSource(sourceID)
+	if err != nil {
+		return types.PrivacyZone{}, err
+	}
+
+	if zone.ID == "" {
+		zone.ID = ids.New("zone_")
+	}
+	if err := source.AddPrivacyZone(zone); err != nil {
+		return types.PrivacyZone{}, err
+	}
+	return zone, nil
+}
+
+// RemovePrivacyZone removes a privacy zone from a source
+func (s *CameraService) RemovePrivacyZone(sourceID, zoneID string) error {
+	source, err := s.getSource(sourceID)
+	if err != nil {
+		return err
+	}
+	return source.RemovePrivacyZone(zoneID)
+}
+
+// Snapshot waits for the next processed frame of a source
+func (s *CameraService) Snapshot(sourceID string, timeout time.Duration) (types.FrameData, error) {
+	source, err := s.getSource(sourceID)
+	if err != nil {
+		return types.FrameData{}, err
+	}
+	return source.NextFrame(timeout)
+}
+
+// PauseSource stops capturing from a source and releases its device while
+// keeping the source and its subscribers
+func (s *CameraService) PauseSource(sourceID string) error {
+	source, err := s.getSource(sourceID)
+	if err != nil {
+		return err
+	}
+	return source.Pause()
+}
+
+// ResumeSource restarts capturing from a paused source
+func (s *CameraService) ResumeSource(sourceID string) error {
+	source, err := s.getSource(sourceID)
+	if err != nil {
+		return err
+	}
+	return source.Resume()
+}
+
+// EnsurePaused pauses a source unless it is already paused
+func (s *CameraService) EnsurePaused(sourceID string) error {
+	source, err := s.getSource(sourceID)
+	if err != nil {
+		return err
+	}
+	return source.EnsurePaused()
+}
+
+// EnsureRunning resumes a source unless it is already capturing
+func (s *CameraService) EnsureRunning(sourceID string) error {
+	source, err := s.getSource(sourceID)
+	if err != nil {
+		return err
+	}
+	return source.EnsureRunning()
+}
+
+// StartRecording begins recording a source to disk
+func (s *CameraService) StartRecording(sourceID string) error {
+	if _, err := s.getSource(sourceID); err != nil {
+		return err
+	}
+	if err := s.recordings.Start(sourceID); err != nil {
+		return err
+	}
+	s.events.Publish(events.RecordingStarted, sourceID, nil)
+	return nil
+}
+
+// StopRecording ends the recording of a source
+func (s *CameraService) StopRecording(sourceID string) error {
+	if _, err := s.getSource(sourceID); err != nil {
+		return err
+	}
+	if err := s.recordings.Stop(sourceID); err != nil {
+		return err
+	}
+	s.events.Publish(events.RecordingStopped, sourceID, nil)
+	return nil
+}
+
+// EnsureRecording records a source unless it is already being recorded
+func (s *CameraService) EnsureRecording(sourceID string) error {
+	if err := s.StartRecording(sourceID); err != nil && !errors.Is(err, recording.ErrRecording) {
+		return err
+	}
+	return nil
+}
+
+// EnsureNotRecording stops recording a source unless it is not being recorded
+func (s *CameraService) EnsureNotRecording(sourceID string) error {
+	if err := s.StopRecording(sourceID); err != nil && !errors.Is(err, recording.ErrNotRecording) {
+		return err
+	}
+	return nil
+}
+
+// ControlPlayback seeks or changes the speed of a playback source
+func (s *CameraService) ControlPlayback(sourceID string, control types.PlaybackControl) (*types.PlaybackStatus, error) {
+	source, err := s.getSource(sourceID)
+	if err != nil {
+		return nil, err
+	}
+	return source.ControlPlayback(control)
+}
+
+// Export starts assembling the frames of a source captured between from and
+// to into a file. Footage of removed sources can still be exported while it
+// is on disk.
+func (s *CameraService) Export(opts recording.ExportOptions) (types.ExportJob, error) {
+	if source, err := s.getSource(opts.SourceID); err == nil {
+		opts.Name = source.GetInfo().Name
+	}
+	return s.exports.Start(opts)
+}
+
+// CheckRawAccess verifies that a token grants access to a source's frames
+// before anonymization
+func (s *CameraService) CheckRawAccess(sourceID, token string) error {
+	source, err := s.getSource(sourceID)
+	if err != nil {
+		return err
+	}
+	if !source.CheckRawAccess(token) {
+		return fmt.Errorf("raw access denied for source: %s", sourceID)
+	}
 	return nil
 }
 
+// CheckIngestAccess verifies that a token allows uploading frames to a push
+// source
+func (s *CameraService) CheckIngestAccess(sourceID, token string) error {
+	source, err := s.getSource(sourceID)
+	if err != nil {
+		return err
+	}
+	if !source.CheckIngestAccess(token) {
+		return fmt.Errorf("ingest access denied for source: %s", sourceID)
+	}
+	return nil
+}
+
+// IngestToken returns the upload token of a push source
+func (s *CameraService) IngestToken(sourceID string) (string, error) {
+	source, err := s.getSource(sourceID)
+	if err != nil {
+		return "", err
+	}
+	return source.IngestToken(), nil
+}
+
+// Ingest delivers an uploaded JPEG frame to a push source
+func (s *CameraService) Ingest(sourceID string, data []byte) error {
+	source, err := s.getSource(sourceID)
+	if err != nil {
+		return err
+	}
+	return source.Ingest(data)
+}
+
+// GetCodes returns codes decoded on a source newer than since, newest first
+func (s *CameraService) GetCodes(sourceID string, since time.Time, limit int) ([]types.CodeRead, error) {
+	source, err := s.getSource(sourceID)
+	if err != nil {
+		return nil, err
+	}
+	return source.Codes(since, limit), nil
+}
+
+// ResetHealthReference captures a new scene reference for tamper detection
+func (s *CameraService) ResetHealthReference(sourceID string) error {
+	source, err := s.getSource(sourceID)
+	if err != nil {
+		return err
+	}
+	source.ResetHealthReference()
+	return nil
+}
+
+// GetFrameInfo returns metadata of the latest frame of a source, including
+// analytics results attached by pipeline stages
+func (s *CameraService) GetFrameInfo(sourceID string) (types.FrameInfo, error) {
+	source, err := s.getSource(sourceID)
+	if err != nil {
+		return types.FrameInfo{}, err
+	}
+	return source.LatestInfo(), nil
+}
+
+// ListDevices returns the local video devices and the sources using them
+func (s *CameraService) ListDevices() ([]types.VideoDevice, error) {
+	list, err := devices.List()
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	inUse := make(map[int][]string)
+	for id, src := range s.sources {
+		info := src.GetInfo()
+		if info.Type != "webcam" {
+			continue
+		}
+		if index, err := devices.Index(info.URI); err == nil {
+			inUse[index] = append(inUse[index], id)
+		}
+	}
+	for i := range list {
+		if ids, ok := inUse[list[i].Index]; ok {
+			slices.Sort(ids)
+			list[i].InUseBy = ids
+		}
+	}
+	return list, nil
+}
+
 // ListSources returns information about all active sources
 func (s *CameraService) ListSources() []types.SourceInfo {
 	s.mu.RLock()
@@ -137,7 +655,48 @@ func (s *CameraService) ListSources() []types.SourceInfo {
 
 	sources := make([]types.SourceInfo, 0, len(s.sources))
 	for _, src := range s.sources {
-		sources = append(sources, src.GetInfo())
+		info := src.GetInfo()
+		info.Lineage = s.lineage(info.Parent)
+		info.Recording = s.recordings.Recording(info.ID)
+		sources = append(sources, info)
 	}
 	return sources
 }
+
+// feedsInto reports whether sourceID is among the given sources or the
+// sources they consume, at any depth. The caller must hold the lock.
+func (s *CameraService) feedsInto(feeds []string, sourceID string) bool {
+	seen := make(map[string]bool)
+	for len(feeds) > 0 {
+		id := feeds[0]
+		feeds = feeds[1:]
+		if id == sourceID {
+			return true
+		}
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		if src, exists := s.sources[id]; exists {
+			feeds = append(feeds, src.Feeds()...)
+		}
+	}
+	return false
+}
+
+// lineage returns the chain of parents ending in parentID, starting with the
+// source that captures from a device. The caller must hold the lock.
+func (s *CameraService) lineage(parentID string) []string {
+	var chain []string
+	seen := make(map[string]bool)
+	for parentID != "" && !seen[parentID] {
+		seen[parentID] = true
+		chain = append([]string{parentID}, chain...)
+		src, exists := s.sources[parentID]
+		if !exists {
+			break
+		}
+		parentID = src.Parent()
+	}
+	return chain
+}
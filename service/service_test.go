@@ -0,0 +1,44 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/Thivyesh/cameraServiceGo/source"
+	"github.com/Thivyesh/cameraServiceGo/types"
+)
+
+func TestFeedsInto(t *testing.T) {
+	s := &CameraService{sources: make(map[string]*source.VideoSource)}
+	add := func(id string, config types.SourceConfig) {
+		s.sources[id] = source.NewVideoSource(config, source.Dependencies{})
+	}
+	add("cam", types.SourceConfig{Type: "webcam", URI: "0"})
+	add("wall", types.SourceConfig{Type: "composite", URI: "wall", Composite: &types.CompositeConfig{Sources: []string{"cam", "lobby"}}})
+	add("view", types.SourceConfig{Type: "derived", URI: "view", Derived: &types.DerivedConfig{Parent: "wall"}})
+	add("pip", types.SourceConfig{Type: "composite", URI: "pip", Composite: &types.CompositeConfig{
+		Mode: "pip",
+		Pip:  &types.PipConfig{Primary: "view", Secondary: "cam"},
+	}})
+
+	tests := []struct {
+		name     string
+		feeds    []string
+		sourceID string
+		want     bool
+	}{
+		{"no inputs", nil, "lobby", false},
+		{"independent", []string{"cam", "wall"}, "other", false},
+		{"itself", []string{"lobby"}, "lobby", true},
+		{"through a composite", []string{"wall"}, "lobby", true},
+		{"through a derived view", []string{"view"}, "lobby", true},
+		{"through pip and derived", []string{"pip"}, "lobby", true},
+		{"device source", []string{"cam"}, "lobby", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := s.feedsInto(tt.feeds, tt.sourceID); got != tt.want {
+				t.Errorf("feedsInto(%v, %s) = %v, want %v", tt.feeds, tt.sourceID, got, tt.want)
+			}
+		})
+	}
+}
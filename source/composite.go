@@ -0,0 +1,313 @@
+package source
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+	"slices"
+	"time"
+
+	"github.com/Thivyesh/cameraServiceGo/pipeline"
+	"github.com/Thivyesh/cameraServiceGo/types"
+	"gocv.io/x/gocv"
+)
+
+// Default composite output settings
+const (
+	defaultCompositeWidth  = 1280
+	defaultCompositeHeight = 720
+	defaultPipSize         = 0.3
+	defaultPipMargin       = 16
+	defaultPipBorder       = 2
+	defaultPipBorderColor  = "#FFFFFF"
+)
+
+var (
+	placeholderColor = color.RGBA{R: 40, G: 40, B: 40, A: 255}
+	labelColor       = color.RGBA{R: 200, G: 200, B: 200, A: 255}
+)
+
+// tile caches the decoded image of a composite input
+type tile struct {
+	seq       uint64    // Feed sequence number of the cached image
+	img       gocv.Mat  // Decoded image resized to the tile
+	live      bool      // Whether the input is delivering frames
+	timestamp time.Time // Capture time of the cached image
+}
+
+// compositeCapture renders other sources into regions of a single frame
+type compositeCapture struct {
+	rects       []image.Rectangle  // Output region of each input, in drawing order
+	inset       int                // Index of the bordered inset, -1 for none
+	border      int                // Inset border width in pixels
+	borderColor color.RGBA         // Inset border color
+	timestamps  bool               // Whether input capture times are drawn
+	size        image.Point        // Output size
+	pace        pacer              // Output frame rate
+	feeds       []*feed            // Input sources in drawing order
+	tiles       []tile             // Decoded input cache
+	canvas      gocv.Mat           // Composed output frame
+	ctx         context.Context    // Cancelled on Close
+	cancel      context.CancelFunc // Stops the feeds
+}
+
+func newCompositeCapture(sourceID string, config *types.CompositeConfig, frames Subscriber) (Capture, error) {
+	if config == nil {
+		return nil, fmt.Errorf("composite source requires a composite configuration")
+	}
+	if frames == nil {
+		return nil, fmt.Errorf("composite sources are not supported by this service")
+	}
+
+	size, interval, err := outputSettings(config.Width, config.Height, config.FPS)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &compositeCapture{
+		inset:      -1,
+		timestamps: config.ShowTimestamps,
+		size:       size,
+		pace:       pacer{interval: interval},
+	}
+	switch config.Mode {
+	case "", "grid":
+		if len(config.Sources) == 0 {
+			return nil, fmt.Errorf("composite source requires at least one input source")
+		}
+		cols, rows, err := parseLayout(config.Layout, len(config.Sources))
+		if err != nil {
+			return nil, err
+		}
+		c.rects = gridRects(cols, rows, len(config.Sources), size)
+	case "pip":
+		if err := c.layoutPip(config.Pip); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unsupported composite mode: %s", config.Mode)
+	}
+	if slices.Contains(compositeInputs(config), sourceID) {
+		return nil, fmt.Errorf("composite source cannot include itself")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	c.ctx, c.cancel = ctx, cancel
+	c.canvas = gocv.NewMatWithSize(size.Y, size.X, gocv.MatTypeCV8UC3)
+	c.tiles = make([]tile, len(c.rects))
+	for i, input := range compositeInputs(config) {
+		c.feeds = append(c.feeds, followSource(ctx, frames, input))
+		c.tiles[i].img = gocv.NewMat()
+	}
+	return c, nil
+}
+
+// layoutPip places the primary source over the whole output and the
+// secondary source in an inset corner
+func (c *compositeCapture) layoutPip(config *types.PipConfig) error {
+	if config == nil || config.Primary == "" || config.Secondary == "" {
+		return fmt.Errorf("pip mode requires primary and secondary sources")
+	}
+
+	size := config.Size
+	if size == 0 {
+		size = defaultPipSize
+	}
+	if size < 0.05 || size > 0.9 {
+		return fmt.Errorf("pip size must be between 0.05 and 0.9")
+	}
+	margin, border := defaultPipMargin, defaultPipBorder
+	if config.Margin != nil {
+		margin = *config.Margin
+	}
+	if config.Border != nil {
+		border = *config.Border
+	}
+	if margin < 0 || border < 0 {
+		return fmt.Errorf("pip margin and border must not be negative")
+	}
+	borderColor := config.BorderColor
+	if borderColor == "" {
+		borderColor = defaultPipBorderColor
+	}
+	var err error
+	if c.borderColor, err = pipeline.ParseColor(borderColor); err != nil {
+		return err
+	}
+
+	inset := image.Pt(int(float64(c.size.X)*size), int(float64(c.size.Y)*size))
+	var corner image.Point
+	switch config.Position {
+	case "top_left":
+		corner = image.Pt(margin, margin)
+	case "top_right":
+		corner = image.Pt(c.size.X-margin-inset.X, margin)
+	case "bottom_left":
+		corner = image.Pt(margin, c.size.Y-margin-inset.Y)
+	case "", "bottom_right":
+		corner = c.size.Sub(inset).Sub(image.Pt(margin, margin))
+	default:
+		return fmt.Errorf("unsupported pip position: %s", config.Position)
+	}
+	rect := image.Rectangle{Min: corner, Max: corner.Add(inset)}
+	if !rect.In(image.Rect(0, 0, c.size.X, c.size.Y)) {
+		return fmt.Errorf("pip margin %d does not fit a %dx%d output", margin, c.size.X, c.size.Y)
+	}
+
+	c.rects = []image.Rectangle{image.Rect(0, 0, c.size.X, c.size.Y), rect}
+	c.inset = 1
+	c.border = border
+	return nil
+}
+
+// compositeInputs returns the IDs of the sources a composite is built from,
+// in drawing order
+func compositeInputs(config *types.CompositeConfig) []string {
+	if config.Mode == "pip" {
+		if config.Pip == nil {
+			return nil
+		}
+		return []string{config.Pip.Primary, config.Pip.Secondary}
+	}
+	return config.Sources
+}
+
+// Read waits for the next output interval and composes the inputs
+func (c *compositeCapture) Read(img *gocv.Mat) bool {
+	if !c.pace.wait(c.ctx) {
+		return false
+	}
+
+	c.canvas.SetTo(gocv.NewScalar(0, 0, 0, 0))
+	for i, f := range c.feeds {
+		rect := c.rects[i]
+		if i == c.inset && c.border > 0 {
+			gocv.Rectangle(&c.canvas, rect.Inset(-c.border), c.borderColor, -1)
+		}
+
+		t := &c.tiles[i]
+		updateTile(t, f, rect.Size())
+		if !t.live {
+			drawPlaceholder(&c.canvas, rect, f.sourceID)
+			continue
+		}
+		drawTile(&c.canvas, rect, t.img)
+		if c.timestamps {
+			drawTimestamp(&c.canvas, rect, t.timestamp)
+		}
+	}
+
+	c.canvas.CopyTo(img)
+	return true
+}
+
+// gridRects returns the cells of a grid for count inputs
+func gridRects(cols, rows, count int, size image.Point) []image.Rectangle {
+	rects := make([]image.Rectangle, count)
+	for i := range rects {
+		col, row := i%cols, i/cols
+		rects[i] = image.Rect(
+			col*size.X/cols, row*size.Y/rows,
+			(col+1)*size.X/cols, (row+1)*size.Y/rows,
+		)
+	}
+	return rects
+}
+
+// Interrupt ends a read waiting for the next frame
+func (c *compositeCapture) Interrupt() {
+	c.cancel()
+}
+
+// Close stops following inputs and releases images
+func (c *compositeCapture) Close() error {
+	c.cancel()
+	for _, t := range c.tiles {
+		t.img.Close()
+	}
+	return c.canvas.Close()
+}
+
+// updateTile decodes the latest frame of a feed into a tile if it changed
+func updateTile(t *tile, f *feed, size image.Point) {
+	frame, seq, live := f.Latest()
+	t.live = live
+	if !live || (seq == t.seq && t.img.Cols() == size.X && t.img.Rows() == size.Y) {
+		return
+	}
+
+	decoded, err := gocv.IMDecode(frame.Data, gocv.IMReadColor)
+	if err != nil || decoded.Empty() {
+		decoded.Close()
+		return
+	}
+	gocv.Resize(decoded, &t.img, size, 0, 0, gocv.InterpolationArea)
+	decoded.Close()
+	t.seq = seq
+	t.timestamp = frame.Timestamp
+}
+
+// drawTile copies an image into a region of the canvas
+func drawTile(canvas *gocv.Mat, rect image.Rectangle, img gocv.Mat) {
+	if img.Empty() || img.Cols() != rect.Dx() || img.Rows() != rect.Dy() {
+		return
+	}
+	region := canvas.Region(rect)
+	img.CopyTo(&region)
+	region.Close()
+}
+
+// drawPlaceholder marks a region whose source is missing
+func drawPlaceholder(canvas *gocv.Mat, rect image.Rectangle, sourceID string) {
+	gocv.Rectangle(canvas, rect, placeholderColor, -1)
+	gocv.PutText(canvas, "NO SIGNAL", image.Pt(rect.Min.X+10, rect.Min.Y+30),
+		gocv.FontHersheySimplex, 0.7, labelColor, 2)
+	gocv.PutText(canvas, sourceID, image.Pt(rect.Min.X+10, rect.Min.Y+55),
+		gocv.FontHersheySimplex, 0.5, labelColor, 1)
+}
+
+// drawTimestamp labels a region with the capture time of its image so that
+// inputs can be compared frame by frame
+func drawTimestamp(canvas *gocv.Mat, rect image.Rectangle, timestamp time.Time) {
+	text := timestamp.Format("15:04:05.000")
+	size := gocv.GetTextSize(text, gocv.FontHersheySimplex, 0.5, 1)
+	origin := image.Pt(rect.Min.X+6, rect.Max.Y-8)
+	background := image.Rect(origin.X-4, origin.Y-size.Y-4, origin.X+size.X+4, origin.Y+4)
+	gocv.Rectangle(canvas, background.Intersect(rect), placeholderColor, -1)
+	gocv.PutText(canvas, text, origin, gocv.FontHersheySimplex, 0.5, labelColor, 1)
+}
+
+// parseLayout parses a COLUMNSxROWS grid, deriving a square grid when empty
+func parseLayout(layout string, count int) (int, int, error) {
+	if layout == "" {
+		cols := int(math.Ceil(math.Sqrt(float64(count))))
+		rows := (count + cols - 1) / cols
+		return cols, rows, nil
+	}
+
+	var cols, rows int
+	if _, err := fmt.Sscanf(layout, "%dx%d", &cols, &rows); err != nil || cols < 1 || rows < 1 {
+		return 0, 0, fmt.Errorf("invalid layout %q, expected COLUMNSxROWS", layout)
+	}
+	if cols*rows < count {
+		return 0, 0, fmt.Errorf("layout %s has %d tiles for %d sources", layout, cols*rows, count)
+	}
+	return cols, rows, nil
+}
+
+// outputSettings applies defaults to a virtual source's output settings
+func outputSettings(width, height int, fps float64) (image.Point, time.Duration, error) {
+	if width == 0 && height == 0 {
+		width, height = defaultCompositeWidth, defaultCompositeHeight
+	}
+	if width < 16 || height < 16 {
+		return image.Point{}, 0, fmt.Errorf("output size must be at least 16x16")
+	}
+	interval, err := frameInterval(fps)
+	if err != nil {
+		return image.Point{}, 0, err
+	}
+	return image.Pt(width, height), interval, nil
+}
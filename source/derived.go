@@ -0,0 +1,135 @@
+package source
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+
+	"github.com/Thivyesh/cameraServiceGo/pipeline"
+	"github.com/Thivyesh/cameraServiceGo/types"
+	"gocv.io/x/gocv"
+)
+
+// derivedCapture produces a transformed view of another source's frames. The
+// parent keeps the only handle on the device; the view decodes the frames it
+// distributes.
+type derivedCapture struct {
+	feed      *feed              // Parent source frames
+	seq       uint64             // Feed sequence number of the last frame read
+	transform *pipeline.Pipeline // Crop, rotate, flip and scale stages
+	ctx       context.Context    // Cancelled on Close
+	cancel    context.CancelFunc // Stops the feed
+}
+
+func newDerivedCapture(sourceID string, config *types.DerivedConfig, frames Subscriber) (Capture, error) {
+	if config == nil || config.Parent == "" {
+		return nil, fmt.Errorf("derived source requires a parent source")
+	}
+	if frames == nil {
+		return nil, fmt.Errorf("derived sources are not supported by this service")
+	}
+	if config.Parent == sourceID {
+		return nil, fmt.Errorf("derived source cannot be its own parent")
+	}
+
+	stages, err := transformStages(config)
+	if err != nil {
+		return nil, err
+	}
+	transform, err := pipeline.New(&pipeline.Env{SourceID: sourceID}, stages)
+	if err != nil {
+		return nil, fmt.Errorf("invalid transform: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	return &derivedCapture{
+		feed:      followSource(ctx, frames, config.Parent),
+		transform: transform,
+		ctx:       ctx,
+		cancel:    cancel,
+	}, nil
+}
+
+// Read waits for the next parent frame and applies the transform
+func (c *derivedCapture) Read(img *gocv.Mat) bool {
+	for {
+		frame, seq, ok := c.feed.Next(c.ctx, c.seq)
+		if !ok {
+			return false
+		}
+		c.seq = seq
+
+		decoded, err := gocv.IMDecode(frame.Data, gocv.IMReadColor)
+		if err != nil || decoded.Empty() {
+			decoded.Close()
+			continue
+		}
+
+		// A frame the transform fails on is skipped, the view keeps
+		// following the parent
+		work := pipeline.NewFrame(decoded, &frame)
+		err = c.transform.Process(work)
+		if err == nil {
+			work.Mat.CopyTo(img)
+		}
+		work.Close()
+		decoded.Close()
+		if err != nil {
+			log.Printf("Transform failed on frame %d of source %s: %v", frame.ID, frame.Source, err)
+			continue
+		}
+		return true
+	}
+}
+
+// Interrupt ends a read waiting for a parent frame
+func (c *derivedCapture) Interrupt() {
+	c.cancel()
+}
+
+// Close stops following the parent and releases the transform
+func (c *derivedCapture) Close() error {
+	c.cancel()
+	c.transform.Close()
+	return nil
+}
+
+// transformStages translates a derived view into pipeline stages, applied in
+// the order crop, rotate, flip, scale
+func transformStages(config *types.DerivedConfig) ([]types.StageConfig, error) {
+	var stages []types.StageConfig
+	add := func(stageType string, options interface{}) error {
+		raw, err := json.Marshal(options)
+		if err != nil {
+			return err
+		}
+		stages = append(stages, types.StageConfig{Type: stageType, Options: raw})
+		return nil
+	}
+
+	if config.Crop != nil {
+		if err := add("crop", config.Crop); err != nil {
+			return nil, err
+		}
+	}
+	if config.Rotate != 0 {
+		if err := add("rotate", map[string]float64{"angle": config.Rotate}); err != nil {
+			return nil, err
+		}
+	}
+	if config.Flip != "" {
+		if err := add("flip", map[string]string{"mode": config.Flip}); err != nil {
+			return nil, err
+		}
+	}
+	if config.Scale < 0 {
+		return nil, fmt.Errorf("scale must not be negative")
+	}
+	if config.Scale != 0 && config.Scale != 1 {
+		if err := add("resize", map[string]float64{"scale": config.Scale}); err != nil {
+			return nil, err
+		}
+	}
+	return stages, nil
+}
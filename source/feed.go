@@ -0,0 +1,99 @@
+package source
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/Thivyesh/cameraServiceGo/types"
+)
+
+// feedRetryInterval is how long a feed waits before resubscribing to a
+// source that does not exist or was removed
+const feedRetryInterval = time.Second
+
+// feed follows the frames of another source for virtual sources. It keeps
+// the latest frame and resubscribes when the source disappears.
+type feed struct {
+	sourceID string          // Followed source
+	mu       sync.Mutex      // Protects shared state
+	latest   types.FrameData // Most recent frame
+	seq      uint64          // Incremented for every received frame
+	live     bool            // Whether the source is currently delivering
+	notify   chan struct{}   // Closed when a frame arrives
+}
+
+// followSource starts following a source until ctx is cancelled
+func followSource(ctx context.Context, frames Subscriber, sourceID string) *feed {
+	f := &feed{sourceID: sourceID, notify: make(chan struct{})}
+	go f.run(ctx, frames)
+	return f
+}
+
+func (f *feed) run(ctx context.Context, frames Subscriber) {
+	for {
+		if ch, err := frames.Subscribe(f.sourceID); err == nil {
+			f.consume(ctx, ch)
+			frames.Unsubscribe(f.sourceID, ch)
+		}
+
+		f.mu.Lock()
+		f.live = false
+		f.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(feedRetryInterval):
+		}
+	}
+}
+
+// consume stores frames until the subscription ends
+func (f *feed) consume(ctx context.Context, ch <-chan types.FrameData) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case frame, ok := <-ch:
+			if !ok {
+				return
+			}
+			f.mu.Lock()
+			f.latest = frame
+			f.seq++
+			f.live = true
+			close(f.notify)
+			f.notify = make(chan struct{})
+			f.mu.Unlock()
+		}
+	}
+}
+
+// Latest returns the most recent frame, its sequence number and whether the
+// source is currently live
+func (f *feed) Latest() (types.FrameData, uint64, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.latest, f.seq, f.live
+}
+
+// Next waits for a frame newer than seq
+func (f *feed) Next(ctx context.Context, seq uint64) (types.FrameData, uint64, bool) {
+	for {
+		f.mu.Lock()
+		if f.seq != seq && f.live {
+			frame, current := f.latest, f.seq
+			f.mu.Unlock()
+			return frame, current, true
+		}
+		notify := f.notify
+		f.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return types.FrameData{}, seq, false
+		case <-notify:
+		}
+	}
+}
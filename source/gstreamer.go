@@ -0,0 +1,174 @@
+package source
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+
+	"gocv.io/x/gocv"
+)
+
+// gstreamerProbe is a minimal pipeline that opens whenever OpenCV has
+// working GStreamer support
+const gstreamerProbe = "videotestsrc num-buffers=1 ! videoconvert ! appsink"
+
+// AllowGStreamerPipelines enables GStreamer pipelines with any element.
+// Elements such as filesrc and filesink read and write files with the
+// privileges of the service, so by default pipelines are limited to
+// safeGStreamerElements.
+var AllowGStreamerPipelines bool
+
+// safeGStreamerElements capture from network streams, devices and test
+// sources, or only transform buffers
+var safeGStreamerElements = []string{
+	// Sources
+	"videotestsrc", "v4l2src", "rtspsrc", "souphttpsrc", "udpsrc", "srtsrc",
+	// Depayloaders, parsers and decoders
+	"rtph264depay", "rtph265depay", "rtpjpegdepay", "rtpvp8depay", "rtpvp9depay",
+	"h264parse", "h265parse", "jpegparse", "multipartdemux",
+	"decodebin", "avdec_h264", "avdec_h265", "jpegdec", "vp8dec", "vp9dec",
+	"v4l2h264dec", "nvh264dec", "vaapih264dec",
+	// Conversion and flow
+	"videoconvert", "videoscale", "videorate", "videoflip", "videocrop",
+	"capsfilter", "queue", "queue2", "identity", "appsink",
+}
+
+var (
+	gstreamerOnce      sync.Once
+	gstreamerAvailable bool
+)
+
+// openGStreamer opens a GStreamer pipeline through OpenCV
+func openGStreamer(uri string) (Capture, error) {
+	pipeline, err := gstreamerPipeline(uri)
+	if err != nil {
+		return nil, err
+	}
+
+	vc, err := gocv.OpenVideoCaptureWithAPI(pipeline, gocv.VideoCaptureGstreamer)
+	if err == nil {
+		return vc, nil
+	}
+	if vc != nil {
+		vc.Close()
+	}
+
+	// OpenCV reports the same failure for a broken pipeline and for a build
+	// without GStreamer, so tell them apart with a pipeline that always works
+	if !hasGStreamer() {
+		return nil, fmt.Errorf("OpenCV was built without GStreamer support or the GStreamer base plugins are missing")
+	}
+	return nil, fmt.Errorf("failed to open GStreamer pipeline %q: %v", pipeline, err)
+}
+
+// hasGStreamer reports whether OpenCV can run GStreamer pipelines
+func hasGStreamer() bool {
+	gstreamerOnce.Do(func() {
+		vc, err := gocv.OpenVideoCaptureWithAPI(gstreamerProbe, gocv.VideoCaptureGstreamer)
+		gstreamerAvailable = err == nil && vc.IsOpened()
+		if vc != nil {
+			vc.Close()
+		}
+	})
+	return gstreamerAvailable
+}
+
+// gstreamerPipeline validates a pipeline description and terminates it with
+// an appsink, which OpenCV reads frames from, when it has none
+func gstreamerPipeline(uri string) (string, error) {
+	elements, err := splitPipeline(uri)
+	if err != nil {
+		return "", err
+	}
+
+	for i, element := range elements {
+		if element == "" {
+			return "", fmt.Errorf("pipeline element %d is empty", i+1)
+		}
+		words := splitWords(element)
+		name := words[0]
+		if name == "appsink" && i != len(elements)-1 {
+			return "", fmt.Errorf("appsink must be the last pipeline element")
+		}
+		if AllowGStreamerPipelines {
+			continue
+		}
+		// Caps such as video/x-raw,width=640 only restrict formats
+		if !strings.Contains(name, "/") && !slices.Contains(safeGStreamerElements, name) {
+			return "", fmt.Errorf("GStreamer element %s is disabled, set CAMERA_ALLOW_GSTREAMER=true to allow any element", name)
+		}
+		// Further bare words would be more elements, which are not checked
+		for _, word := range words[1:] {
+			if key, _, ok := strings.Cut(word, "="); !ok || key == "" {
+				return "", fmt.Errorf("pipeline element %d has %q where a key=value property is expected, separate elements with !", i+1, word)
+			}
+		}
+	}
+
+	pipeline := strings.Join(elements, " ! ")
+	if strings.Fields(elements[len(elements)-1])[0] != "appsink" {
+		pipeline += " ! videoconvert ! appsink"
+	}
+	return pipeline, nil
+}
+
+// splitPipeline splits a pipeline description at links, ignoring ! inside
+// quoted property values
+func splitPipeline(uri string) ([]string, error) {
+	if strings.TrimSpace(uri) == "" {
+		return nil, fmt.Errorf("gstreamer source requires a pipeline as URI")
+	}
+
+	var elements []string
+	var current strings.Builder
+	var quote rune
+	for _, r := range uri {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '"' || r == '\'':
+			quote = r
+		case r == '!':
+			elements = append(elements, strings.TrimSpace(current.String()))
+			current.Reset()
+			continue
+		}
+		current.WriteRune(r)
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated quote in pipeline")
+	}
+	return append(elements, strings.TrimSpace(current.String())), nil
+}
+
+// splitWords splits a pipeline element at whitespace, keeping quoted
+// property values that contain spaces in one word
+func splitWords(element string) []string {
+	var words []string
+	var current strings.Builder
+	var quote rune
+	for _, r := range element {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '"' || r == '\'':
+			quote = r
+		case r == ' ' || r == '\t' || r == '\n' || r == '\r':
+			if current.Len() > 0 {
+				words = append(words, current.String())
+				current.Reset()
+			}
+			continue
+		}
+		current.WriteRune(r)
+	}
+	if current.Len() > 0 {
+		words = append(words, current.String())
+	}
+	return words
+}
@@ -0,0 +1,107 @@
+package source
+
+import (
+	"strings"
+	"testing"
+
+	"gocv.io/x/gocv"
+)
+
+func TestGStreamerPipeline(t *testing.T) {
+	tests := []struct {
+		name  string
+		uri   string
+		allow bool
+		want  string
+		err   string
+	}{
+		{
+			name: "appends appsink",
+			uri:  "videotestsrc ! video/x-raw,width=320",
+			want: "videotestsrc ! video/x-raw,width=320 ! videoconvert ! appsink",
+		},
+		{
+			name: "keeps appsink",
+			uri:  "rtspsrc location=rtsp://camera/stream ! rtph264depay ! h264parse ! avdec_h264 ! videoconvert ! appsink drop=true",
+			want: "rtspsrc location=rtsp://camera/stream ! rtph264depay ! h264parse ! avdec_h264 ! videoconvert ! appsink drop=true",
+		},
+		{
+			name: "quoted link",
+			uri:  `souphttpsrc location="http://camera/a!b" ! jpegdec`,
+			want: `souphttpsrc location="http://camera/a!b" ! jpegdec ! videoconvert ! appsink`,
+		},
+		{name: "empty", uri: " ", err: "requires a pipeline"},
+		{name: "empty element", uri: "videotestsrc ! ! appsink", err: "element 2 is empty"},
+		{name: "unterminated quote", uri: `souphttpsrc location="http://camera`, err: "unterminated quote"},
+		{name: "appsink in the middle", uri: "videotestsrc ! appsink ! videoconvert", err: "appsink must be the last"},
+		{name: "file source", uri: "filesrc location=/etc/shadow ! decodebin", err: "filesrc is disabled"},
+		{name: "file sink", uri: "videotestsrc ! filesink location=/tmp/out", err: "filesink is disabled"},
+		{
+			name: "file source after an allowed element",
+			uri:  "videotestsrc ! queue filesrc location=/etc/shadow ! jpegdec ! appsink",
+			err:  `has "filesrc"`,
+		},
+		{
+			name: "file sink after an allowed element",
+			uri:  "videotestsrc ! videoconvert filesink location=/tmp/out ! appsink",
+			err:  `has "filesink"`,
+		},
+		{
+			name: "element after caps",
+			uri:  "videotestsrc ! video/x-raw,width=320 filesink location=/tmp/out",
+			err:  `has "filesink"`,
+		},
+		{
+			name: "properties",
+			uri:  `souphttpsrc location="http://camera/a b.mjpg" is-live=true ! jpegdec`,
+			want: `souphttpsrc location="http://camera/a b.mjpg" is-live=true ! jpegdec ! videoconvert ! appsink`,
+		},
+		{
+			name:  "file source allowed",
+			uri:   "filesrc location=/srv/video.mp4 ! decodebin",
+			allow: true,
+			want:  "filesrc location=/srv/video.mp4 ! decodebin ! videoconvert ! appsink",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			AllowGStreamerPipelines = tt.allow
+			defer func() { AllowGStreamerPipelines = false }()
+
+			got, err := gstreamerPipeline(tt.uri)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("got error %v, want %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("pipeline = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGStreamerVideoTestSrc(t *testing.T) {
+	if !hasGStreamer() {
+		t.Skip("OpenCV has no GStreamer support")
+	}
+
+	capture, err := openGStreamer("videotestsrc num-buffers=10 ! video/x-raw,width=320,height=240")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer capture.Close()
+
+	img := gocv.NewMat()
+	defer img.Close()
+	if !capture.Read(&img) {
+		t.Fatal("failed to read a frame")
+	}
+	if img.Cols() != 320 || img.Rows() != 240 {
+		t.Errorf("frame is %dx%d, want 320x240", img.Cols(), img.Rows())
+	}
+}
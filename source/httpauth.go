@@ -0,0 +1,170 @@
+package source
+
+import (
+	"context"
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// getWithAuth requests a URL, answering a basic or digest authentication
+// challenge when credentials are configured
+func getWithAuth(ctx context.Context, client *http.Client, uri, username, password string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, uri, nil)
+	if err != nil {
+		return nil, fmt.Errorf("invalid URL: %v", err)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode == http.StatusUnauthorized && username != "" {
+		challenges := resp.Header.Values("WWW-Authenticate")
+		resp.Body.Close()
+
+		req, _ = http.NewRequestWithContext(ctx, http.MethodGet, uri, nil)
+		if err := authorize(req, challenges, username, password); err != nil {
+			return nil, err
+		}
+		if resp, err = client.Do(req); err != nil {
+			return nil, err
+		}
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("unexpected status: %s", resp.Status)
+	}
+	return resp, nil
+}
+
+// authorize sets the Authorization header for the strongest supported
+// challenge
+func authorize(req *http.Request, challenges []string, username, password string) error {
+	var basic bool
+	for _, challenge := range challenges {
+		scheme, params := parseChallenge(challenge)
+		switch strings.ToLower(scheme) {
+		case "digest":
+			header, err := digestAuthorization(req.Method, req.URL.RequestURI(), params, username, password)
+			if err != nil {
+				return err
+			}
+			req.Header.Set("Authorization", header)
+			return nil
+		case "basic":
+			basic = true
+		}
+	}
+	if !basic {
+		return fmt.Errorf("unsupported authentication challenge: %s", strings.Join(challenges, ", "))
+	}
+	req.SetBasicAuth(username, password)
+	return nil
+}
+
+// digestAuthorization computes an RFC 7616 digest response
+func digestAuthorization(method, uri string, params map[string]string, username, password string) (string, error) {
+	nonce := params["nonce"]
+	if nonce == "" {
+		return "", fmt.Errorf("digest challenge without nonce")
+	}
+
+	algorithm := params["algorithm"]
+	var newHash func() hash.Hash
+	switch strings.ToUpper(algorithm) {
+	case "", "MD5":
+		newHash = md5.New
+	case "SHA-256":
+		newHash = sha256.New
+	default:
+		return "", fmt.Errorf("unsupported digest algorithm: %s", algorithm)
+	}
+	digest := func(parts ...string) string {
+		h := newHash()
+		h.Write([]byte(strings.Join(parts, ":")))
+		return hex.EncodeToString(h.Sum(nil))
+	}
+
+	realm := params["realm"]
+	ha1 := digest(username, realm, password)
+	ha2 := digest(method, uri)
+
+	fields := []string{
+		fmt.Sprintf("username=%q", username),
+		fmt.Sprintf("realm=%q", realm),
+		fmt.Sprintf("nonce=%q", nonce),
+		fmt.Sprintf("uri=%q", uri),
+	}
+	if algorithm != "" {
+		fields = append(fields, "algorithm="+algorithm)
+	}
+	if opaque, ok := params["opaque"]; ok {
+		fields = append(fields, fmt.Sprintf("opaque=%q", opaque))
+	}
+
+	qops := strings.Split(params["qop"], ",")
+	for i := range qops {
+		qops[i] = strings.TrimSpace(qops[i])
+	}
+	if slices.Contains(qops, "auth") {
+		buf := make([]byte, 8)
+		if _, err := rand.Read(buf); err != nil {
+			return "", err
+		}
+		cnonce, nc := hex.EncodeToString(buf), "00000001"
+		response := digest(ha1, nonce, nc, cnonce, "auth", ha2)
+		fields = append(fields, "qop=auth", "nc="+nc, fmt.Sprintf("cnonce=%q", cnonce),
+			fmt.Sprintf("response=%q", response))
+	} else {
+		fields = append(fields, fmt.Sprintf("response=%q", digest(ha1, nonce, ha2)))
+	}
+	return "Digest " + strings.Join(fields, ", "), nil
+}
+
+// parseChallenge splits a WWW-Authenticate value into its scheme and
+// parameters
+func parseChallenge(challenge string) (string, map[string]string) {
+	challenge = strings.TrimSpace(challenge)
+	scheme, rest, _ := strings.Cut(challenge, " ")
+	params := make(map[string]string)
+
+	for rest = strings.TrimSpace(rest); rest != ""; {
+		key, value, found := strings.Cut(rest, "=")
+		if !found {
+			break
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+
+		if strings.HasPrefix(value, `"`) {
+			// Quoted values may contain commas and escaped quotes
+			var b strings.Builder
+			i := 1
+			for ; i < len(value) && value[i] != '"'; i++ {
+				if value[i] == '\\' && i+1 < len(value) {
+					i++
+				}
+				b.WriteByte(value[i])
+			}
+			params[key] = b.String()
+			value = value[min(i+1, len(value)):]
+		} else {
+			end := strings.IndexByte(value, ',')
+			if end < 0 {
+				end = len(value)
+			}
+			params[key] = strings.TrimSpace(value[:end])
+			value = value[end:]
+		}
+		rest = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(value), ","))
+	}
+	return scheme, params
+}
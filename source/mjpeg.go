@@ -0,0 +1,281 @@
+package source
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net"
+	"net/http"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/Thivyesh/cameraServiceGo/types"
+	"gocv.io/x/gocv"
+)
+
+// Default HTTP source timeouts
+const (
+	defaultConnectTimeout = 10 * time.Second
+	defaultReadTimeout    = 10 * time.Second
+)
+
+// maxPartSize bounds the size of a single multipart frame
+const maxPartSize = 16 << 20
+
+// jpegReader is implemented by captures that receive JPEG encoded frames
+// and can hand them over without a decode and re-encode round trip
+type jpegReader interface {
+	ReadJPEG() ([]byte, bool)
+}
+
+// mjpegCapture reads a multipart/x-mixed-replace JPEG stream over HTTP
+type mjpegCapture struct {
+	uri         string             // Stream URL, for logging
+	body        io.ReadCloser      // Response body
+	parts       *partReader        // Multipart parser over the body
+	readTimeout time.Duration      // Longest gap between frames
+	watchdog    *time.Timer        // Aborts the request when frames stop
+	timedOut    atomic.Bool        // Whether the watchdog fired
+	ctx         context.Context    // Request context
+	cancel      context.CancelFunc // Aborts the request
+}
+
+func newMJPEGCapture(uri string, config *types.HTTPConfig) (Capture, error) {
+	if config == nil {
+		config = &types.HTTPConfig{}
+	}
+	connectTimeout, readTimeout := defaultConnectTimeout, defaultReadTimeout
+	if config.ConnectTimeoutMs < 0 || config.ReadTimeoutMs < 0 {
+		return nil, fmt.Errorf("timeouts must not be negative")
+	}
+	if config.ConnectTimeoutMs > 0 {
+		connectTimeout = time.Duration(config.ConnectTimeoutMs) * time.Millisecond
+	}
+	if config.ReadTimeoutMs > 0 {
+		readTimeout = time.Duration(config.ReadTimeoutMs) * time.Millisecond
+	}
+
+	// The stream never ends, so only connection setup is bounded by the
+	// client; frame gaps are bounded by the watchdog
+	client := &http.Client{Transport: &http.Transport{
+		Proxy:                 http.ProxyFromEnvironment,
+		DialContext:           (&net.Dialer{Timeout: connectTimeout}).DialContext,
+		TLSHandshakeTimeout:   connectTimeout,
+		ResponseHeaderTimeout: connectTimeout,
+	}}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	resp, err := getWithAuth(ctx, client, uri, config.Username, config.Password)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	boundary, err := multipartBoundary(resp.Header.Get("Content-Type"))
+	if err != nil {
+		resp.Body.Close()
+		cancel()
+		return nil, err
+	}
+
+	c := &mjpegCapture{
+		uri:         uri,
+		body:        resp.Body,
+		parts:       newPartReader(resp.Body, boundary),
+		readTimeout: readTimeout,
+		ctx:         ctx,
+		cancel:      cancel,
+	}
+	c.watchdog = time.AfterFunc(readTimeout, func() {
+		c.timedOut.Store(true)
+		cancel()
+	})
+	return c, nil
+}
+
+// ReadJPEG returns the next JPEG part of the stream as received
+func (c *mjpegCapture) ReadJPEG() ([]byte, bool) {
+	for {
+		part, err := c.parts.Next()
+		if err != nil {
+			if c.timedOut.Load() {
+				log.Printf("No frame from MJPEG stream %s within %v", c.uri, c.readTimeout)
+			} else if c.ctx.Err() == nil {
+				log.Printf("MJPEG stream %s ended: %v", c.uri, err)
+			}
+			return nil, false
+		}
+		c.watchdog.Reset(c.readTimeout)
+
+		// Skip parts that are not JPEG images, such as text keepalives
+		if !isJPEG(part) {
+			continue
+		}
+		return part, true
+	}
+}
+
+// Read decodes the next JPEG part of the stream
+func (c *mjpegCapture) Read(img *gocv.Mat) bool {
+	for {
+		part, ok := c.ReadJPEG()
+		if !ok {
+			return false
+		}
+		if err := gocv.IMDecodeIntoMat(part, gocv.IMReadColor, img); err != nil {
+			log.Printf("Skipping undecodable frame from %s: %v", c.uri, err)
+			continue
+		}
+		return true
+	}
+}
+
+// Interrupt aborts the request, ending a read waiting for data
+func (c *mjpegCapture) Interrupt() {
+	c.cancel()
+}
+
+// Close aborts the request
+func (c *mjpegCapture) Close() error {
+	c.watchdog.Stop()
+	c.cancel()
+	return c.body.Close()
+}
+
+// isJPEG reports whether data starts with a JPEG start of image marker
+func isJPEG(data []byte) bool {
+	return len(data) >= 2 && data[0] == 0xFF && data[1] == 0xD8
+}
+
+// multipartBoundary extracts the part boundary of a multipart stream
+func multipartBoundary(contentType string) (string, error) {
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return "", fmt.Errorf("invalid content type %q: %v", contentType, err)
+	}
+	if !strings.HasPrefix(mediaType, "multipart/") {
+		return "", fmt.Errorf("unexpected content type %s, expected multipart/x-mixed-replace", mediaType)
+	}
+
+	// Some cameras include the leading dashes in the parameter
+	boundary := strings.TrimPrefix(params["boundary"], "--")
+	if boundary == "" {
+		return "", fmt.Errorf("content type %q has no boundary", contentType)
+	}
+	return boundary, nil
+}
+
+// partReader splits a multipart/x-mixed-replace stream into part bodies. It
+// is more lenient than mime/multipart: it tolerates missing closing
+// delimiters and bare LF line endings, and uses Content-Length when present
+// so a part is complete without waiting for the next boundary.
+type partReader struct {
+	r          *bufio.Reader // Buffered stream
+	delimiter  []byte        // Boundary line without line ending
+	atBoundary bool          // Whether the last read consumed a boundary line
+}
+
+func newPartReader(r io.Reader, boundary string) *partReader {
+	return &partReader{
+		r:         bufio.NewReaderSize(r, 64<<10),
+		delimiter: []byte("--" + boundary),
+	}
+}
+
+// Next returns the body of the next part
+func (p *partReader) Next() ([]byte, error) {
+	if !p.atBoundary {
+		if err := p.skipToBoundary(); err != nil {
+			return nil, err
+		}
+	}
+	p.atBoundary = false
+
+	header, err := textproto.NewReader(p.r).ReadMIMEHeader()
+	if err != nil {
+		return nil, fmt.Errorf("invalid part header: %v", err)
+	}
+
+	if value := header.Get("Content-Length"); value != "" {
+		size, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil || size < 0 || size > maxPartSize {
+			return nil, fmt.Errorf("invalid part length %q", value)
+		}
+		body := make([]byte, size)
+		if _, err := io.ReadFull(p.r, body); err != nil {
+			return nil, err
+		}
+		return body, nil
+	}
+	return p.readToBoundary()
+}
+
+// skipToBoundary discards input up to and including the next boundary line
+func (p *partReader) skipToBoundary() error {
+	lineStart := true
+	for {
+		line, err := p.r.ReadSlice('\n')
+		if lineStart && err == nil {
+			if ok, closing := p.boundary(line); ok {
+				if closing {
+					return io.EOF
+				}
+				return nil
+			}
+		}
+		if err != nil && err != bufio.ErrBufferFull {
+			return err
+		}
+		lineStart = err == nil
+	}
+}
+
+// readToBoundary reads a part body up to the next boundary line
+func (p *partReader) readToBoundary() ([]byte, error) {
+	var body []byte
+	lineStart := true
+	for {
+		line, err := p.r.ReadSlice('\n')
+		if lineStart && err == nil {
+			if ok, _ := p.boundary(line); ok {
+				p.atBoundary = true
+				// The line break before a boundary belongs to the delimiter
+				body = bytes.TrimSuffix(body, []byte("\n"))
+				return bytes.TrimSuffix(body, []byte("\r")), nil
+			}
+		}
+		if err != nil && err != bufio.ErrBufferFull {
+			return nil, err
+		}
+		body = append(body, line...)
+		if len(body) > maxPartSize {
+			return nil, fmt.Errorf("part exceeds %d bytes", maxPartSize)
+		}
+		lineStart = err == nil
+	}
+}
+
+// boundary reports whether a line is a boundary and whether it is the
+// closing one
+func (p *partReader) boundary(line []byte) (bool, bool) {
+	line = bytes.TrimSpace(line)
+	if !bytes.HasPrefix(line, p.delimiter) {
+		return false, false
+	}
+	rest := line[len(p.delimiter):]
+	switch {
+	case len(rest) == 0:
+		return true, false
+	case bytes.Equal(rest, []byte("--")):
+		return true, true
+	default:
+		return false, false
+	}
+}
@@ -0,0 +1,249 @@
+package source
+
+import (
+	"bytes"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Thivyesh/cameraServiceGo/types"
+)
+
+// Test frames start with a JPEG marker and contain line breaks and
+// boundary-like lines, which must not end a part early
+var testFrames = [][]byte{
+	[]byte("\xff\xd8first\r\n--frame-ish\r\n\xff\xd9"),
+	[]byte("\xff\xd8second\n\xff\xd9"),
+}
+
+// writeParts writes frames as multipart parts, with or without a
+// Content-Length header, and a text keepalive part in between
+func writeParts(w http.ResponseWriter, boundary string, withLength bool) {
+	for i, frame := range testFrames {
+		if i > 0 {
+			fmt.Fprintf(w, "--%s\r\nContent-Type: text/plain\r\n\r\nkeepalive\r\n", boundary)
+		}
+		fmt.Fprintf(w, "--%s\r\nContent-Type: image/jpeg\r\n", boundary)
+		if withLength {
+			fmt.Fprintf(w, "Content-Length: %d\r\n", len(frame))
+		}
+		fmt.Fprintf(w, "\r\n%s\r\n", frame)
+	}
+	// Without a length the last part is only complete at the next boundary
+	fmt.Fprintf(w, "--%s\r\n", boundary)
+	w.(http.Flusher).Flush()
+}
+
+// streamHandler serves the test frames and keeps the stream open until the
+// client goes away
+func streamHandler(contentType, boundary string, withLength bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", contentType)
+		writeParts(w, boundary, withLength)
+		<-r.Context().Done()
+	}
+}
+
+// readFrames opens a capture of url and reads count frames from it
+func readFrames(t *testing.T, url string, config *types.HTTPConfig, count int) [][]byte {
+	t.Helper()
+	capture, err := newMJPEGCapture(url, config)
+	if err != nil {
+		t.Fatalf("newMJPEGCapture: %v", err)
+	}
+	defer capture.Close()
+
+	var frames [][]byte
+	for range count {
+		frame, ok := capture.(jpegReader).ReadJPEG()
+		if !ok {
+			t.Fatalf("ReadJPEG failed after %d frames", len(frames))
+		}
+		frames = append(frames, frame)
+	}
+	return frames
+}
+
+func TestMJPEGBoundaryParsing(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		boundary    string
+		withLength  bool
+	}{
+		{"quoted with length", `multipart/x-mixed-replace; boundary="frame"`, "frame", true},
+		{"quoted without length", `multipart/x-mixed-replace; boundary="frame"`, "frame", false},
+		{"unquoted with length", "multipart/x-mixed-replace;boundary=myboundary", "myboundary", true},
+		{"unquoted without length", "multipart/x-mixed-replace; boundary=myboundary", "myboundary", false},
+		{"leading dashes", "multipart/x-mixed-replace; boundary=--myboundary", "myboundary", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(streamHandler(tt.contentType, tt.boundary, tt.withLength))
+			defer server.Close()
+
+			frames := readFrames(t, server.URL, nil, len(testFrames))
+			for i, frame := range frames {
+				if !bytes.Equal(frame, testFrames[i]) {
+					t.Errorf("frame %d = %q, want %q", i, frame, testFrames[i])
+				}
+			}
+		})
+	}
+}
+
+func TestMJPEGRejectsNonMultipart(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/jpeg")
+		w.Write(testFrames[0])
+	}))
+	defer server.Close()
+
+	if _, err := newMJPEGCapture(server.URL, nil); err == nil {
+		t.Fatal("expected an error for a non-multipart response")
+	}
+}
+
+func TestMJPEGWatchdog(t *testing.T) {
+	server := httptest.NewServer(streamHandler("multipart/x-mixed-replace; boundary=frame", "frame", true))
+	defer server.Close()
+
+	capture, err := newMJPEGCapture(server.URL, &types.HTTPConfig{ReadTimeoutMs: 100})
+	if err != nil {
+		t.Fatalf("newMJPEGCapture: %v", err)
+	}
+	defer capture.Close()
+
+	reader := capture.(jpegReader)
+	for range testFrames {
+		if _, ok := reader.ReadJPEG(); !ok {
+			t.Fatal("ReadJPEG failed before the stream stalled")
+		}
+	}
+
+	// The server sends nothing more, so the watchdog aborts the read
+	start := time.Now()
+	if _, ok := reader.ReadJPEG(); ok {
+		t.Fatal("ReadJPEG succeeded on a stalled stream")
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("stalled read returned after %v, want about 100ms", elapsed)
+	}
+	if !capture.(*mjpegCapture).timedOut.Load() {
+		t.Error("watchdog did not fire")
+	}
+}
+
+// authServer serves the test stream to clients answering its challenge
+func authServer(t *testing.T, challenge string, check func(r *http.Request) bool) *httptest.Server {
+	t.Helper()
+	stream := streamHandler("multipart/x-mixed-replace; boundary=frame", "frame", true)
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") == "" || !check(r) {
+			w.Header().Set("WWW-Authenticate", challenge)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		stream(w, r)
+	}))
+}
+
+// checkDigest verifies a digest response the way a camera would
+func checkDigest(r *http.Request, newHash func() hash.Hash, realm, nonce, password string) bool {
+	scheme, params := parseChallenge(r.Header.Get("Authorization"))
+	if scheme != "Digest" || params["realm"] != realm || params["nonce"] != nonce ||
+		params["uri"] != r.URL.RequestURI() || params["qop"] != "auth" || params["opaque"] != "opaque-value" {
+		return false
+	}
+	digest := func(parts ...string) string {
+		h := newHash()
+		h.Write([]byte(strings.Join(parts, ":")))
+		return hex.EncodeToString(h.Sum(nil))
+	}
+	ha1 := digest(params["username"], realm, password)
+	ha2 := digest(r.Method, params["uri"])
+	return params["response"] == digest(ha1, nonce, params["nc"], params["cnonce"], "auth", ha2)
+}
+
+func TestMJPEGAuthentication(t *testing.T) {
+	const user, password = "admin", "secret"
+	const realm, nonce = "camera", "dcd98b7102dd2f0e8b11d0f600bfb0c093"
+	digestChallenge := func(algorithm string) string {
+		return fmt.Sprintf(`Digest realm=%q, qop="auth,auth-int", algorithm=%s, nonce=%q, opaque="opaque-value"`,
+			realm, algorithm, nonce)
+	}
+
+	tests := []struct {
+		name      string
+		challenge string
+		check     func(r *http.Request) bool
+	}{
+		{
+			name:      "basic",
+			challenge: `Basic realm="camera"`,
+			check: func(r *http.Request) bool {
+				u, p, ok := r.BasicAuth()
+				return ok && u == user && p == password
+			},
+		},
+		{
+			name:      "digest md5",
+			challenge: digestChallenge("MD5"),
+			check: func(r *http.Request) bool {
+				return checkDigest(r, md5.New, realm, nonce, password)
+			},
+		},
+		{
+			name:      "digest sha-256",
+			challenge: digestChallenge("SHA-256"),
+			check: func(r *http.Request) bool {
+				return checkDigest(r, sha256.New, realm, nonce, password)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := authServer(t, tt.challenge, tt.check)
+			defer server.Close()
+
+			config := &types.HTTPConfig{Username: user, Password: password}
+			frames := readFrames(t, server.URL+"/video?res=high", config, 1)
+			if !bytes.Equal(frames[0], testFrames[0]) {
+				t.Errorf("frame = %q, want %q", frames[0], testFrames[0])
+			}
+
+			// Wrong credentials end in the server's 401
+			_, err := newMJPEGCapture(server.URL, &types.HTTPConfig{Username: user, Password: "wrong"})
+			if err == nil || !strings.Contains(err.Error(), "401") {
+				t.Errorf("wrong password: got error %v, want 401", err)
+			}
+		})
+	}
+}
+
+func TestMJPEGUnauthorizedWithoutCredentials(t *testing.T) {
+	server := authServer(t, `Basic realm="camera"`, func(*http.Request) bool { return true })
+	defer server.Close()
+
+	_, err := newMJPEGCapture(server.URL, nil)
+	if err == nil || !strings.Contains(err.Error(), "401") {
+		t.Errorf("got error %v, want 401", err)
+	}
+}
+
+func TestMJPEGUnsupportedChallenge(t *testing.T) {
+	server := authServer(t, `Bearer realm="camera"`, func(*http.Request) bool { return true })
+	defer server.Close()
+
+	_, err := newMJPEGCapture(server.URL, &types.HTTPConfig{Username: "admin", Password: "secret"})
+	if err == nil || !strings.Contains(err.Error(), "unsupported authentication challenge") {
+		t.Errorf("got error %v, want unsupported challenge", err)
+	}
+}
@@ -0,0 +1,48 @@
+package source
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// defaultFPS is the frame rate of sources that generate their own frames,
+// such as composites and image sequences, unless configured
+const defaultFPS = 10
+
+// maxFPS is the highest configurable frame rate of such sources
+const maxFPS = 120
+
+// pacer spaces reads of a source to a frame rate
+type pacer struct {
+	interval time.Duration // Time between frames
+	next     time.Time     // When the next frame is due
+}
+
+// wait blocks until the next frame is due. It returns false if ctx is
+// cancelled first.
+func (p *pacer) wait(ctx context.Context) bool {
+	now := time.Now()
+	if p.next.Before(now) {
+		p.next = now
+	}
+	select {
+	case <-ctx.Done():
+		return false
+	case <-time.After(p.next.Sub(now)):
+	}
+	p.next = p.next.Add(p.interval)
+	return true
+}
+
+// frameInterval returns the time between frames at a configured frame rate,
+// using the default frame rate when fps is zero
+func frameInterval(fps float64) (time.Duration, error) {
+	if fps == 0 {
+		fps = defaultFPS
+	}
+	if fps < 0 || fps > maxFPS {
+		return 0, fmt.Errorf("fps must be between 0 and %d", maxFPS)
+	}
+	return time.Duration(float64(time.Second) / fps), nil
+}
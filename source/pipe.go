@@ -0,0 +1,396 @@
+package source
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"slices"
+	"sync"
+
+	"github.com/Thivyesh/cameraServiceGo/types"
+	"gocv.io/x/gocv"
+)
+
+// AllowPipeCommands enables pipe sources that run a command. Commands run
+// with the privileges of the service, so they are disabled by default.
+var AllowPipeCommands bool
+
+// stderrLines is the number of standard error lines kept per pipe source
+const stderrLines = 20
+
+// processStatus tracks the command of a pipe source across restarts
+type processStatus struct {
+	mu       sync.Mutex // Protects shared state
+	pid      int        // Process ID of the running command
+	running  bool       // Whether the command is running
+	starts   int        // Number of times the command was started
+	lastExit string     // Exit status of the previous run
+	stderr   []string   // Most recent complete stderr lines
+	partial  []byte     // Incomplete stderr line
+}
+
+// Info returns a snapshot of the process status
+func (p *processStatus) Info() *types.ProcessInfo {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return &types.ProcessInfo{
+		PID:      p.pid,
+		Running:  p.running,
+		Restarts: max(p.starts-1, 0),
+		LastExit: p.lastExit,
+		Stderr:   slices.Clone(p.stderr),
+	}
+}
+
+func (p *processStatus) started(pid int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.pid, p.running = pid, true
+	p.starts++
+}
+
+func (p *processStatus) exited(err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.running = false
+	p.lastExit = "exit status 0"
+	if err != nil {
+		p.lastExit = err.Error()
+	}
+}
+
+// Write collects standard error output line by line
+func (p *processStatus) Write(data []byte) (int, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.partial = append(p.partial, data...)
+	for {
+		i := bytes.IndexByte(p.partial, '\n')
+		if i < 0 {
+			break
+		}
+		line := string(bytes.TrimRight(p.partial[:i], "\r"))
+		p.partial = p.partial[i+1:]
+		p.stderr = append(p.stderr, line)
+		if len(p.stderr) > stderrLines {
+			p.stderr = p.stderr[len(p.stderr)-stderrLines:]
+		}
+	}
+	// Bound lines that never end, e.g. progress output using carriage returns
+	if len(p.partial) > 4096 {
+		p.partial = p.partial[len(p.partial)-4096:]
+	}
+	return len(data), nil
+}
+
+// pipeCapture reads raw or concatenated JPEG frames from the standard output
+// of a command or from a named pipe
+type pipeCapture struct {
+	config *types.PipeConfig // Frame source and format
+	stream io.ReadCloser     // Frame stream
+	reader *bufio.Reader     // Buffered frame stream
+	cmd    *exec.Cmd         // Running command, nil for named pipes
+	status *processStatus    // Command status shared across restarts
+	raw    []byte            // Raw frame buffer
+	mtype  gocv.MatType      // Raw frame matrix type
+}
+
+func newPipeCapture(config *types.PipeConfig, status *processStatus) (Capture, error) {
+	if config == nil || (len(config.Command) == 0) == (config.Path == "") {
+		return nil, fmt.Errorf("pipe source requires either a command or a path")
+	}
+
+	c := &pipeCapture{config: config, status: status}
+	switch config.Format {
+	case "", "mjpeg":
+	case "raw":
+		channels, err := pixelChannels(config.PixelFormat)
+		if err != nil {
+			return nil, err
+		}
+		if config.Width < 1 || config.Height < 1 {
+			return nil, fmt.Errorf("raw frames require width and height")
+		}
+		c.raw = make([]byte, config.Width*config.Height*channels)
+		c.mtype = gocv.MatTypeCV8UC3
+		if channels == 1 {
+			c.mtype = gocv.MatTypeCV8UC1
+		}
+	default:
+		return nil, fmt.Errorf("unsupported pipe format: %s", config.Format)
+	}
+
+	if err := c.open(); err != nil {
+		return nil, err
+	}
+	c.reader = bufio.NewReaderSize(c.stream, 256<<10)
+	return c, nil
+}
+
+// open starts the command or opens the named pipe
+func (c *pipeCapture) open() error {
+	if c.config.Path != "" {
+		// Only named pipes, a path must not stream arbitrary host files
+		if err := requireNamedPipe(os.Stat(c.config.Path)); err != nil {
+			return err
+		}
+		// Opening for writing too keeps the pipe open between writers
+		// instead of blocking until one connects
+		file, err := os.OpenFile(c.config.Path, os.O_RDWR, 0)
+		if err != nil {
+			file, err = os.Open(c.config.Path)
+		}
+		if err != nil {
+			return err
+		}
+		// The path may have been replaced since it was checked
+		if err := requireNamedPipe(file.Stat()); err != nil {
+			file.Close()
+			return err
+		}
+		c.stream = file
+		return nil
+	}
+
+	if !AllowPipeCommands {
+		return fmt.Errorf("pipe commands are disabled, set CAMERA_ALLOW_PIPE_COMMANDS=true to enable them")
+	}
+	cmd := exec.Command(c.config.Command[0], c.config.Command[1:]...)
+	cmd.Stderr = c.status
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to start command: %v", err)
+	}
+	c.status.started(cmd.Process.Pid)
+	c.cmd, c.stream = cmd, stdout
+	return nil
+}
+
+// requireNamedPipe checks that file information describes a named pipe
+func requireNamedPipe(info os.FileInfo, err error) error {
+	if err != nil {
+		return err
+	}
+	if info.Mode()&os.ModeNamedPipe == 0 {
+		return fmt.Errorf("pipe path %s is not a named pipe", info.Name())
+	}
+	return nil
+}
+
+// ReadJPEG returns the next frame JPEG encoded
+func (c *pipeCapture) ReadJPEG() ([]byte, bool) {
+	if c.raw == nil {
+		data, err := readJPEG(c.reader)
+		return data, err == nil
+	}
+
+	img := gocv.NewMat()
+	defer img.Close()
+	if !c.Read(&img) {
+		return nil, false
+	}
+	buf, err := gocv.IMEncode(".jpg", img)
+	if err != nil {
+		return nil, false
+	}
+	defer buf.Close()
+	return append([]byte(nil), buf.GetBytes()...), true
+}
+
+// Read decodes the next frame
+func (c *pipeCapture) Read(img *gocv.Mat) bool {
+	if c.raw == nil {
+		for {
+			data, err := readJPEG(c.reader)
+			if err != nil {
+				return false
+			}
+			if gocv.IMDecodeIntoMat(data, gocv.IMReadColor, img) == nil {
+				return true
+			}
+		}
+	}
+
+	if _, err := io.ReadFull(c.reader, c.raw); err != nil {
+		return false
+	}
+	mat, err := gocv.NewMatFromBytes(c.config.Height, c.config.Width, c.mtype, c.raw)
+	if err != nil {
+		return false
+	}
+	defer mat.Close()
+
+	switch c.config.PixelFormat {
+	case "rgb24":
+		// Swapping channels is symmetric, so BGR to RGB also converts back
+		gocv.CvtColor(mat, img, gocv.ColorBGRToRGB)
+	case "gray":
+		gocv.CvtColor(mat, img, gocv.ColorGrayToBGR)
+	default:
+		mat.CopyTo(img)
+	}
+	return true
+}
+
+// Interrupt ends a read waiting for output, stopping the command or
+// closing the named pipe
+func (c *pipeCapture) Interrupt() {
+	if c.cmd == nil {
+		c.stream.Close()
+		return
+	}
+	c.cmd.Process.Kill()
+}
+
+// Close stops the command or closes the named pipe
+func (c *pipeCapture) Close() error {
+	if c.cmd == nil {
+		return c.stream.Close()
+	}
+	c.cmd.Process.Kill()
+	c.status.exited(c.cmd.Wait())
+	return nil
+}
+
+// pixelChannels returns the bytes per pixel of a raw pixel format
+func pixelChannels(format string) (int, error) {
+	switch format {
+	case "", "bgr24", "rgb24":
+		return 3, nil
+	case "gray":
+		return 1, nil
+	default:
+		return 0, fmt.Errorf("unsupported pixel format: %s", format)
+	}
+}
+
+// readJPEG reads the next image of a concatenated JPEG stream. Segments are
+// followed by their lengths, so image boundaries are found without decoding
+// and without being fooled by marker bytes inside embedded thumbnails.
+func readJPEG(r *bufio.Reader) ([]byte, error) {
+	if err := skipToImage(r); err != nil {
+		return nil, err
+	}
+
+	buf := []byte{0xFF, 0xD8}
+	var marker byte
+	for {
+		if marker == 0 {
+			var err error
+			if marker, err = readMarker(r); err != nil {
+				return nil, err
+			}
+		}
+		buf = append(buf, 0xFF, marker)
+
+		switch {
+		case marker == 0xD9: // End of image
+			return buf, nil
+		case marker == 0x01 || (marker >= 0xD0 && marker <= 0xD7): // No payload
+			marker = 0
+			continue
+		}
+
+		var size [2]byte
+		if _, err := io.ReadFull(r, size[:]); err != nil {
+			return nil, err
+		}
+		length := int(size[0])<<8 | int(size[1])
+		if length < 2 {
+			return nil, fmt.Errorf("invalid JPEG segment length %d", length)
+		}
+		buf = append(buf, size[:]...)
+		start := len(buf)
+		buf = append(buf, make([]byte, length-2)...)
+		if _, err := io.ReadFull(r, buf[start:]); err != nil {
+			return nil, err
+		}
+
+		next := byte(0)
+		if marker == 0xDA { // Start of scan, entropy coded data follows
+			var err error
+			if buf, next, err = appendScan(buf, r); err != nil {
+				return nil, err
+			}
+		}
+		marker = next
+
+		if len(buf) > maxPartSize {
+			return nil, fmt.Errorf("JPEG image exceeds %d bytes", maxPartSize)
+		}
+	}
+}
+
+// skipToImage discards input up to and including a start of image marker
+func skipToImage(r *bufio.Reader) error {
+	for {
+		b, err := r.ReadByte()
+		if err != nil {
+			return err
+		}
+		for b == 0xFF {
+			if b, err = r.ReadByte(); err != nil {
+				return err
+			}
+			if b == 0xD8 {
+				return nil
+			}
+		}
+	}
+}
+
+// readMarker reads a marker, skipping fill bytes
+func readMarker(r *bufio.Reader) (byte, error) {
+	b, err := r.ReadByte()
+	if err != nil {
+		return 0, err
+	}
+	if b != 0xFF {
+		return 0, fmt.Errorf("corrupt JPEG stream, expected marker")
+	}
+	for b == 0xFF {
+		if b, err = r.ReadByte(); err != nil {
+			return 0, err
+		}
+	}
+	return b, nil
+}
+
+// appendScan copies entropy coded data up to the next marker, which is
+// returned. Stuffed zero bytes and restart markers are part of the data.
+func appendScan(buf []byte, r *bufio.Reader) ([]byte, byte, error) {
+	for {
+		b, err := r.ReadByte()
+		if err != nil {
+			return nil, 0, err
+		}
+		if b != 0xFF {
+			buf = append(buf, b)
+			continue
+		}
+
+		next, err := r.ReadByte()
+		if err != nil {
+			return nil, 0, err
+		}
+		switch {
+		case next == 0x00 || (next >= 0xD0 && next <= 0xD7):
+			buf = append(buf, 0xFF, next)
+		case next == 0xFF:
+			r.UnreadByte()
+		default:
+			return buf, next, nil
+		}
+		if len(buf) > maxPartSize {
+			return nil, 0, fmt.Errorf("JPEG image exceeds %d bytes", maxPartSize)
+		}
+	}
+}
@@ -0,0 +1,31 @@
+package source
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Thivyesh/cameraServiceGo/types"
+)
+
+func TestPipePathRequiresNamedPipe(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "secret")
+	if err := os.WriteFile(file, []byte("\xff\xd8secret"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, path := range []string{file, dir, filepath.Join(dir, "missing")} {
+		t.Run(filepath.Base(path), func(t *testing.T) {
+			capture, err := newPipeCapture(&types.PipeConfig{Path: path}, &processStatus{})
+			if err == nil {
+				capture.Close()
+				t.Fatal("opened a path that is not a named pipe")
+			}
+			if path != filepath.Join(dir, "missing") && !strings.Contains(err.Error(), "not a named pipe") {
+				t.Errorf("got error %v", err)
+			}
+		})
+	}
+}
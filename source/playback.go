@@ -0,0 +1,209 @@
+package source
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/Thivyesh/cameraServiceGo/recording"
+	"github.com/Thivyesh/cameraServiceGo/types"
+	"gocv.io/x/gocv"
+)
+
+// playbackState keeps the position and speed of a playback source across
+// pauses, which close and reopen its capture
+type playbackState struct {
+	mu       sync.Mutex        // Protects shared state
+	player   *recording.Player // Player of the open capture, nil while closed
+	position time.Time         // Where a reopened capture continues
+	speed    float64           // Speed of a reopened capture, 0 for the configured one
+}
+
+// Info returns the playback status of a configuration
+func (p *playbackState) Info(config *types.PlaybackConfig) *types.PlaybackStatus {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	status := &types.PlaybackStatus{
+		Source:   config.Source,
+		From:     config.From,
+		To:       config.To,
+		Position: config.From,
+		Speed:    playbackSpeed(config, p.speed),
+		Mode:     config.Mode,
+	}
+	if status.Mode == "" {
+		status.Mode = "once"
+	}
+	if !p.position.IsZero() {
+		status.Position = p.position
+	}
+	if p.player != nil {
+		status.Position = p.player.Position()
+		status.Speed = p.player.Speed()
+	}
+	return status
+}
+
+// Control seeks and changes the speed of the open capture, or of the next
+// one while the source is paused
+func (p *playbackState) Control(config *types.PlaybackConfig, control types.PlaybackControl) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if control.Position != nil {
+		position := *control.Position
+		if position.Before(config.From) || !position.Before(config.To) {
+			return fmt.Errorf("position must be between %s and %s",
+				config.From.Format(time.RFC3339), config.To.Format(time.RFC3339))
+		}
+		if p.player != nil {
+			if err := p.player.Seek(position); err != nil {
+				return err
+			}
+		}
+		p.position = position
+	}
+	if control.Speed != 0 {
+		if p.player != nil {
+			if err := p.player.SetSpeed(control.Speed); err != nil {
+				return err
+			}
+		} else if err := recording.CheckSpeed(control.Speed); err != nil {
+			return err
+		}
+		p.speed = control.Speed
+	}
+	return nil
+}
+
+// playbackSpeed returns the speed set through the API or the configured one
+func playbackSpeed(config *types.PlaybackConfig, speed float64) float64 {
+	switch {
+	case speed != 0:
+		return speed
+	case config.Speed != 0:
+		return config.Speed
+	default:
+		return 1
+	}
+}
+
+// playbackCapture plays recorded footage of another source
+type playbackCapture struct {
+	player   *recording.Player  // Paced recorded frames
+	state    *playbackState     // Position kept across reopens
+	from     time.Time          // Where looped playback restarts
+	loop     bool               // Whether playback restarts at the end
+	played   bool               // Whether a frame was played since the last restart
+	finished bool               // Whether once-mode playback reached the end
+	ctx      context.Context    // Cancelled on Close
+	cancel   context.CancelFunc // Interrupts waiting for frames
+}
+
+func newPlaybackCapture(config *types.PlaybackConfig, recordings *recording.Store, state *playbackState) (Capture, error) {
+	if config == nil || config.Source == "" {
+		return nil, fmt.Errorf("playback source requires the source to play")
+	}
+	if recordings == nil {
+		return nil, fmt.Errorf("recordings are not available")
+	}
+
+	var loop bool
+	switch config.Mode {
+	case "", "once":
+	case "loop":
+		loop = true
+	default:
+		return nil, fmt.Errorf("unsupported playback mode: %s", config.Mode)
+	}
+
+	state.mu.Lock()
+	defer state.mu.Unlock()
+
+	position := config.From
+	if !state.position.IsZero() {
+		position = state.position
+	}
+	player, err := recordings.Play(config.Source, position, config.To, playbackSpeed(config, state.speed))
+	if err != nil {
+		return nil, err
+	}
+	state.player = player
+
+	ctx, cancel := context.WithCancel(context.Background())
+	return &playbackCapture{
+		player: player,
+		state:  state,
+		from:   config.From,
+		loop:   loop,
+		ctx:    ctx,
+		cancel: cancel,
+	}, nil
+}
+
+// ReadJPEG returns the next recorded frame when it is due
+func (c *playbackCapture) ReadJPEG() ([]byte, bool) {
+	for {
+		frame, err := c.player.Next(c.ctx)
+		// Restart looped playback unless the range has no footage at all
+		if err == io.EOF && c.loop && c.played && c.ctx.Err() == nil {
+			if err := c.player.Seek(c.from); err != nil {
+				log.Printf("Restarting playback failed: %v", err)
+				return nil, false
+			}
+			c.played = false
+			continue
+		}
+		if err == io.EOF {
+			c.finished = c.ctx.Err() == nil
+		}
+		if err != nil {
+			return nil, false
+		}
+		c.played = true
+		return frame.Data, true
+	}
+}
+
+// Read decodes the next recorded frame
+func (c *playbackCapture) Read(img *gocv.Mat) bool {
+	for {
+		data, ok := c.ReadJPEG()
+		if !ok {
+			return false
+		}
+		if err := gocv.IMDecodeIntoMat(data, gocv.IMReadColor, img); err != nil {
+			log.Printf("Skipping undecodable recorded frame: %v", err)
+			continue
+		}
+		return true
+	}
+}
+
+// Finished reports whether once-mode playback reached the end of its range
+func (c *playbackCapture) Finished() bool {
+	return c.finished
+}
+
+// Interrupt ends a read waiting for recorded frames
+func (c *playbackCapture) Interrupt() {
+	c.cancel()
+}
+
+// Close stops playback, keeping the position for a reopened capture
+func (c *playbackCapture) Close() error {
+	c.cancel()
+
+	c.state.mu.Lock()
+	defer c.state.mu.Unlock()
+	if c.state.player == c.player {
+		c.state.position = c.player.Position()
+		c.state.player = nil
+	}
+	c.player.Close()
+	return nil
+}
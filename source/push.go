@@ -0,0 +1,80 @@
+package source
+
+import (
+	"fmt"
+	"log"
+	"sync"
+
+	"gocv.io/x/gocv"
+)
+
+// pushCapture receives JPEG frames uploaded by clients. Only the newest
+// upload is kept, so a slow pipeline drops frames instead of queueing them.
+type pushCapture struct {
+	frames    chan []byte   // Pending upload
+	done      chan struct{} // Closed on Close
+	closeOnce sync.Once     // Ensures done is closed once
+}
+
+func newPushCapture() Capture {
+	return &pushCapture{
+		frames: make(chan []byte, 1),
+		done:   make(chan struct{}),
+	}
+}
+
+// Push hands an uploaded frame to the source, replacing any pending frame
+func (c *pushCapture) Push(data []byte) error {
+	if !isJPEG(data) {
+		return fmt.Errorf("frame is not a JPEG image")
+	}
+	for {
+		select {
+		case <-c.done:
+			return fmt.Errorf("source is not streaming")
+		case c.frames <- data:
+			return nil
+		default:
+		}
+		select {
+		case <-c.frames:
+		default:
+		}
+	}
+}
+
+// ReadJPEG waits for the next uploaded frame
+func (c *pushCapture) ReadJPEG() ([]byte, bool) {
+	select {
+	case data := <-c.frames:
+		return data, true
+	case <-c.done:
+		return nil, false
+	}
+}
+
+// Read decodes the next uploaded frame
+func (c *pushCapture) Read(img *gocv.Mat) bool {
+	for {
+		data, ok := c.ReadJPEG()
+		if !ok {
+			return false
+		}
+		if err := gocv.IMDecodeIntoMat(data, gocv.IMReadColor, img); err != nil {
+			log.Printf("Skipping undecodable uploaded frame: %v", err)
+			continue
+		}
+		return true
+	}
+}
+
+// Interrupt ends a read waiting for an upload
+func (c *pushCapture) Interrupt() {
+	c.Close()
+}
+
+// Close stops accepting uploads
+func (c *pushCapture) Close() error {
+	c.closeOnce.Do(func() { close(c.done) })
+	return nil
+}
@@ -0,0 +1,265 @@
+package source
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/Thivyesh/cameraServiceGo/types"
+	"gocv.io/x/gocv"
+)
+
+// sequenceExtensions are the image files picked up from a directory
+var sequenceExtensions = []string{".jpg", ".jpeg", ".png", ".bmp"}
+
+// finiteCapture is implemented by captures that can run out of frames
+// without failing
+type finiteCapture interface {
+	Finished() bool
+}
+
+// sequenceCapture plays a list of image files as a video
+type sequenceCapture struct {
+	files  []string           // Images in playback order
+	pos    int                // Index of the next image
+	loop   bool               // Whether playback restarts after the last image
+	pace   pacer              // Playback frame rate
+	ctx    context.Context    // Cancelled on Close
+	cancel context.CancelFunc // Interrupts pacing
+}
+
+func newSequenceCapture(pattern string, config *types.SequenceConfig) (Capture, error) {
+	if config == nil {
+		config = &types.SequenceConfig{}
+	}
+
+	interval, err := frameInterval(config.FPS)
+	if err != nil {
+		return nil, err
+	}
+
+	var loop bool
+	switch config.Mode {
+	case "", "loop":
+		loop = true
+	case "once":
+	default:
+		return nil, fmt.Errorf("unsupported playback mode: %s", config.Mode)
+	}
+
+	files, err := sequenceFiles(pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	switch config.Order {
+	case "", "name":
+		slices.Sort(files)
+	case "timestamp":
+		if files, err = sortByTimestamp(files, config.TimestampLayout); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unsupported order: %s", config.Order)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	return &sequenceCapture{
+		files:  files,
+		loop:   loop,
+		pace:   pacer{interval: interval},
+		ctx:    ctx,
+		cancel: cancel,
+	}, nil
+}
+
+// next waits for the next frame time and returns the file to show
+func (c *sequenceCapture) next() (string, bool) {
+	if c.pos >= len(c.files) {
+		if !c.loop {
+			return "", false
+		}
+		c.pos = 0
+	}
+	if !c.pace.wait(c.ctx) {
+		return "", false
+	}
+	file := c.files[c.pos]
+	c.pos++
+	return file, true
+}
+
+// ReadJPEG returns the next image JPEG encoded, reading JPEG files as is
+func (c *sequenceCapture) ReadJPEG() ([]byte, bool) {
+	for {
+		file, ok := c.next()
+		if !ok {
+			return nil, false
+		}
+
+		data, err := os.ReadFile(file)
+		if err != nil {
+			log.Printf("Skipping unreadable image %s: %v", file, err)
+			continue
+		}
+		if isJPEG(data) {
+			return data, true
+		}
+
+		img, err := gocv.IMDecode(data, gocv.IMReadColor)
+		if err != nil || img.Empty() {
+			img.Close()
+			log.Printf("Skipping undecodable image %s", file)
+			continue
+		}
+		buf, err := gocv.IMEncode(".jpg", img)
+		img.Close()
+		if err != nil {
+			log.Printf("Failed to encode image %s: %v", file, err)
+			continue
+		}
+		encoded := append([]byte(nil), buf.GetBytes()...)
+		buf.Close()
+		return encoded, true
+	}
+}
+
+// Read decodes the next image
+func (c *sequenceCapture) Read(img *gocv.Mat) bool {
+	for {
+		file, ok := c.next()
+		if !ok {
+			return false
+		}
+
+		data, err := os.ReadFile(file)
+		if err == nil {
+			err = gocv.IMDecodeIntoMat(data, gocv.IMReadColor, img)
+		}
+		if err != nil {
+			log.Printf("Skipping unreadable image %s: %v", file, err)
+			continue
+		}
+		return true
+	}
+}
+
+// Finished reports whether a once-mode sequence has played every image
+func (c *sequenceCapture) Finished() bool {
+	return !c.loop && c.pos >= len(c.files)
+}
+
+// Interrupt ends a read waiting for the next frame
+func (c *sequenceCapture) Interrupt() {
+	c.cancel()
+}
+
+// Close interrupts playback
+func (c *sequenceCapture) Close() error {
+	c.cancel()
+	return nil
+}
+
+// sequenceFiles lists the images of a directory or matching a glob pattern
+func sequenceFiles(pattern string) ([]string, error) {
+	var files []string
+	if info, err := os.Stat(pattern); err == nil && info.IsDir() {
+		entries, err := os.ReadDir(pattern)
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range entries {
+			ext := strings.ToLower(filepath.Ext(entry.Name()))
+			if !entry.IsDir() && slices.Contains(sequenceExtensions, ext) {
+				files = append(files, filepath.Join(pattern, entry.Name()))
+			}
+		}
+	} else {
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern %q: %v", pattern, err)
+		}
+		for _, match := range matches {
+			if info, err := os.Stat(match); err == nil && !info.IsDir() {
+				files = append(files, match)
+			}
+		}
+	}
+
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no images found for %s", pattern)
+	}
+	return files, nil
+}
+
+// sortByTimestamp orders files by the timestamp in their names. Files
+// without a timestamp are skipped.
+func sortByTimestamp(files []string, layout string) ([]string, error) {
+	type stamped struct {
+		file string
+		at   time.Time
+	}
+
+	var sorted []stamped
+	for _, file := range files {
+		at, ok := nameTimestamp(filepath.Base(file), layout)
+		if !ok {
+			log.Printf("Skipping image without timestamp: %s", file)
+			continue
+		}
+		sorted = append(sorted, stamped{file, at})
+	}
+	if len(sorted) == 0 {
+		return nil, fmt.Errorf("no image names contain a timestamp")
+	}
+
+	slices.SortStableFunc(sorted, func(a, b stamped) int {
+		if c := a.at.Compare(b.at); c != 0 {
+			return c
+		}
+		return strings.Compare(a.file, b.file)
+	})
+
+	files = make([]string, len(sorted))
+	for i, s := range sorted {
+		files[i] = s.file
+	}
+	return files, nil
+}
+
+// nameTimestamp finds a timestamp in a file name. With a layout, the first
+// substring parsing with it is used; otherwise the first run of 10 or 13
+// digits is read as Unix seconds or milliseconds.
+func nameTimestamp(name string, layout string) (time.Time, bool) {
+	name = strings.TrimSuffix(name, filepath.Ext(name))
+
+	if layout != "" {
+		for i := 0; i+len(layout) <= len(name); i++ {
+			if at, err := time.Parse(layout, name[i:i+len(layout)]); err == nil {
+				return at, true
+			}
+		}
+		return time.Time{}, false
+	}
+
+	for _, digits := range strings.FieldsFunc(name, func(r rune) bool { return !unicode.IsDigit(r) }) {
+		value, err := strconv.ParseInt(digits, 10, 64)
+		if err != nil {
+			continue
+		}
+		switch len(digits) {
+		case 10:
+			return time.Unix(value, 0), true
+		case 13:
+			return time.UnixMilli(value), true
+		}
+	}
+	return time.Time{}, false
+}
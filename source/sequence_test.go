@@ -0,0 +1,227 @@
+package source
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/Thivyesh/cameraServiceGo/types"
+)
+
+// writeImages creates files whose content is their name behind a JPEG
+// marker, so played frames identify the file they were read from
+func writeImages(t *testing.T, dir string, names ...string) {
+	t.Helper()
+	for _, name := range names {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("\xff\xd8"+name), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// playNames opens a sequence and returns the names of up to count played
+// images, stopping early when the sequence ends
+func playNames(t *testing.T, pattern string, config *types.SequenceConfig, count int) ([]string, Capture) {
+	t.Helper()
+	capture, err := newSequenceCapture(pattern, config)
+	if err != nil {
+		t.Fatalf("newSequenceCapture: %v", err)
+	}
+	t.Cleanup(func() { capture.Close() })
+
+	var names []string
+	for range count {
+		data, ok := capture.(jpegReader).ReadJPEG()
+		if !ok {
+			break
+		}
+		names = append(names, string(data[2:]))
+	}
+	return names, capture
+}
+
+func TestSequenceOrder(t *testing.T) {
+	dir := t.TempDir()
+	writeImages(t, dir,
+		"b_1700000002.jpg",
+		"a_1700000003.jpg",
+		"c_1700000001000.jpg", // Milliseconds, earliest
+		"untimed.jpg",
+	)
+	// Not images, or not files
+	writeImages(t, dir, "notes.txt")
+	if err := os.Mkdir(filepath.Join(dir, "nested.jpg"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		config types.SequenceConfig
+		want   []string
+	}{
+		{
+			name:   "name",
+			config: types.SequenceConfig{Mode: "once", FPS: maxFPS},
+			want:   []string{"a_1700000003.jpg", "b_1700000002.jpg", "c_1700000001000.jpg", "untimed.jpg"},
+		},
+		{
+			name:   "timestamp",
+			config: types.SequenceConfig{Order: "timestamp", Mode: "once", FPS: maxFPS},
+			want:   []string{"c_1700000001000.jpg", "b_1700000002.jpg", "a_1700000003.jpg"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, _ := playNames(t, dir, &tt.config, 10)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("played %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSequenceTimestampLayout(t *testing.T) {
+	dir := t.TempDir()
+	writeImages(t, dir,
+		"cam_20240101_120000.500.jpg",
+		"cam_20231231_235959.000.jpg",
+		"cam_20240101_120000.250.jpg",
+	)
+
+	config := &types.SequenceConfig{
+		Order:           "timestamp",
+		TimestampLayout: "20060102_150405.000",
+		Mode:            "once",
+		FPS:             maxFPS,
+	}
+	got, _ := playNames(t, dir, config, 10)
+	want := []string{"cam_20231231_235959.000.jpg", "cam_20240101_120000.250.jpg", "cam_20240101_120000.500.jpg"}
+	if !slices.Equal(got, want) {
+		t.Errorf("played %v, want %v", got, want)
+	}
+}
+
+func TestSequenceGlob(t *testing.T) {
+	dir := t.TempDir()
+	writeImages(t, dir, "frame_2.jpg", "frame_1.jpg", "other_1.jpg", "frame_3.png")
+
+	config := &types.SequenceConfig{Mode: "once", FPS: maxFPS}
+	got, _ := playNames(t, filepath.Join(dir, "frame_*.jpg"), config, 10)
+	want := []string{"frame_1.jpg", "frame_2.jpg"}
+	if !slices.Equal(got, want) {
+		t.Errorf("played %v, want %v", got, want)
+	}
+}
+
+func TestSequenceModes(t *testing.T) {
+	dir := t.TempDir()
+	writeImages(t, dir, "1.jpg", "2.jpg")
+
+	t.Run("once", func(t *testing.T) {
+		got, capture := playNames(t, dir, &types.SequenceConfig{Mode: "once", FPS: maxFPS}, 5)
+		if want := []string{"1.jpg", "2.jpg"}; !slices.Equal(got, want) {
+			t.Errorf("played %v, want %v", got, want)
+		}
+		if !capture.(finiteCapture).Finished() {
+			t.Error("once-mode sequence is not finished after the last image")
+		}
+	})
+
+	t.Run("loop", func(t *testing.T) {
+		got, capture := playNames(t, dir, &types.SequenceConfig{FPS: maxFPS}, 5)
+		if want := []string{"1.jpg", "2.jpg", "1.jpg", "2.jpg", "1.jpg"}; !slices.Equal(got, want) {
+			t.Errorf("played %v, want %v", got, want)
+		}
+		if capture.(finiteCapture).Finished() {
+			t.Error("looped sequence reports finished")
+		}
+	})
+}
+
+func TestSequencePacing(t *testing.T) {
+	dir := t.TempDir()
+	writeImages(t, dir, "1.jpg")
+
+	start := time.Now()
+	playNames(t, dir, &types.SequenceConfig{FPS: 50}, 6)
+	// The first frame is due immediately, the others 20ms apart
+	if elapsed := time.Since(start); elapsed < 100*time.Millisecond {
+		t.Errorf("6 frames at 50 fps took %v, want at least 100ms", elapsed)
+	}
+}
+
+func TestSequenceCloseInterruptsPacing(t *testing.T) {
+	dir := t.TempDir()
+	writeImages(t, dir, "1.jpg")
+
+	capture, err := newSequenceCapture(dir, &types.SequenceConfig{FPS: 0.1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	reader := capture.(jpegReader)
+	if _, ok := reader.ReadJPEG(); !ok {
+		t.Fatal("first frame was not played")
+	}
+
+	time.AfterFunc(20*time.Millisecond, func() { capture.Close() })
+	start := time.Now()
+	if _, ok := reader.ReadJPEG(); ok {
+		t.Error("read succeeded after Close")
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Close interrupted pacing after %v", elapsed)
+	}
+}
+
+func TestSequenceInvalidConfig(t *testing.T) {
+	dir := t.TempDir()
+	writeImages(t, dir, "untimed.jpg")
+
+	tests := []struct {
+		name    string
+		pattern string
+		config  types.SequenceConfig
+	}{
+		{"mode", dir, types.SequenceConfig{Mode: "bounce"}},
+		{"order", dir, types.SequenceConfig{Order: "size"}},
+		{"fps", dir, types.SequenceConfig{FPS: maxFPS + 1}},
+		{"negative fps", dir, types.SequenceConfig{FPS: -1}},
+		{"no timestamps", dir, types.SequenceConfig{Order: "timestamp"}},
+		{"no images", filepath.Join(dir, "*.png"), types.SequenceConfig{}},
+		{"bad pattern", filepath.Join(dir, "["), types.SequenceConfig{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := newSequenceCapture(tt.pattern, &tt.config); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
+
+func TestNameTimestamp(t *testing.T) {
+	tests := []struct {
+		name   string
+		layout string
+		want   time.Time
+		ok     bool
+	}{
+		{"img_1700000000.jpg", "", time.Unix(1700000000, 0), true},
+		{"img_1700000000123.jpg", "", time.UnixMilli(1700000000123), true},
+		{"cam2_1700000000.jpg", "", time.Unix(1700000000, 0), true}, // Short digit runs are ignored
+		{"img_12345.jpg", "", time.Time{}, false},
+		{"img.jpg", "", time.Time{}, false},
+		{"cam_20240102_030405.jpg", "20060102_150405", time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC), true},
+		{"cam_1700000000.jpg", "20060102_150405", time.Time{}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := nameTimestamp(tt.name, tt.layout)
+			if ok != tt.ok || !got.Equal(tt.want) {
+				t.Errorf("nameTimestamp(%q, %q) = %v, %v, want %v, %v", tt.name, tt.layout, got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
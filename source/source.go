@@ -3,31 +3,98 @@ package source
 
 import (
 	"context"
+	"crypto/subtle"
+	"errors"
 	"fmt"
 	"log"
+	"slices"
 	"sync"
 	"time"
 
+	"github.com/Thivyesh/cameraServiceGo/devices"
+	"github.com/Thivyesh/cameraServiceGo/events"
+	"github.com/Thivyesh/cameraServiceGo/metrics"
+	"github.com/Thivyesh/cameraServiceGo/pipeline"
+	"github.com/Thivyesh/cameraServiceGo/recording"
 	"github.com/Thivyesh/cameraServiceGo/types"
 	"gocv.io/x/gocv"
 )
 
+// Capture provides decoded frames to a VideoSource. *gocv.VideoCapture
+// satisfies it; virtual source types provide their own implementation.
+type Capture interface {
+	Read(img *gocv.Mat) bool
+	Close() error
+}
+
+// interruptible is implemented by captures whose reads can block for long,
+// e.g. while waiting for other sources or uploads. Interrupt makes pending
+// and later reads fail without releasing the capture, so a read in progress
+// ends when the source is stopped.
+type interruptible interface {
+	Interrupt()
+}
+
+// Subscriber gives virtual sources access to the frames of other sources
+type Subscriber interface {
+	Subscribe(sourceID string) (<-chan types.FrameData, error)
+	Unsubscribe(sourceID string, frames <-chan types.FrameData)
+}
+
+// Dependencies provides services used by sources
+type Dependencies struct {
+	Events     *events.Bus      // Bus for lifecycle and error events
+	Frames     Subscriber       // Frames of other sources, used by virtual sources
+	Recordings *recording.Store // Recorded footage, used by playback sources
+}
+
 // VideoSource manages video capture from a single source
 type VideoSource struct {
-	config    types.SourceConfig   // Source configuration
-	capture   *gocv.VideoCapture   // OpenCV video capture
-	isActive  bool                 // Whether source is streaming
-	frames    chan types.FrameData // Channel for frame distribution
-	closeOnce sync.Once            // Ensures cleanup happens only once
-	mu        sync.RWMutex         // Protects shared state
+	id         string                  // Source identifier
+	config     types.SourceConfig      // Source configuration
+	deps       Dependencies            // Services used by the source
+	events     *events.Bus             // Bus for lifecycle and error events
+	capture    Capture                 // Frame provider
+	pipeline   *pipeline.Pipeline      // Processing applied before encoding
+	codes      *pipeline.CodeLog       // Decoded code history
+	health     *pipeline.HealthMonitor // Image health status
+	process    *processStatus          // Pipe source command status
+	playback   *playbackState          // Playback source position
+	latest     types.FrameData         // Most recently produced frame
+	frameReady chan struct{}           // Closed when a new frame is produced
+	resume     chan struct{}           // Closed to resume capture, nil unless paused
+	stop       chan struct{}           // Closed by Stop
+	isActive   bool                    // Whether source is streaming
+	finished   bool                    // Whether a finite source played all its frames
+	frames     chan types.FrameData    // Channel for frame distribution
+	closeOnce  sync.Once               // Ensures cleanup happens only once
+	mu         sync.RWMutex            // Protects shared state
 }
 
+// codeHistorySize is the number of decoded codes kept per source
+const codeHistorySize = 500
+
+// Delays between attempts to reopen a failed capture
+const (
+	reconnectMinDelay = time.Second
+	reconnectMaxDelay = 30 * time.Second
+)
+
 // NewVideoSource creates a new video source instance
-func NewVideoSource(config types.SourceConfig) *VideoSource {
+func NewVideoSource(config types.SourceConfig, deps Dependencies) *VideoSource {
 	return &VideoSource{
-		config:   config,
-		frames:   make(chan types.FrameData, 100), // Buffer 100 frames
-		isActive: false,
+		id:         fmt.Sprintf("%s_%s", config.Type, config.URI),
+		config:     config,
+		deps:       deps,
+		events:     deps.Events,
+		frames:     make(chan types.FrameData, 100), // Buffer 100 frames
+		frameReady: make(chan struct{}),
+		stop:       make(chan struct{}),
+		codes:      pipeline.NewCodeLog(codeHistorySize),
+		health:     pipeline.NewHealthMonitor(),
+		process:    &processStatus{},
+		playback:   &playbackState{},
+		isActive:   false,
 	}
 }
 
@@ -40,26 +107,22 @@ func (s *VideoSource) Start(ctx context.Context) error {
 		return fmt.Errorf("source already active")
 	}
 
-	// Initialize capture based on source type
-	var err error
-	switch s.config.Type {
-	case "file":
-		s.capture, err = gocv.OpenVideoCapture(s.config.URI)
-	case "webcam":
-		deviceID := 0
-		fmt.Sscanf(s.config.URI, "%d", &deviceID)
-		s.capture, err = gocv.OpenVideoCapture(deviceID)
-	case "ip_camera":
-		s.capture, err = gocv.OpenVideoCapture(s.config.URI)
-	default:
-		return fmt.Errorf("unsupported source type: %s", s.config.Type)
+	// Build processing pipeline before opening the device
+	pl, err := pipeline.New(s.envFor(s.config), s.config.Pipeline)
+	if err != nil {
+		return fmt.Errorf("invalid pipeline: %v", err)
 	}
 
+	// Initialize capture based on source type
+	s.capture, err = s.openCapture(s.config)
 	if err != nil {
+		pl.Close()
 		return fmt.Errorf("failed to open video source: %v", err)
 	}
 
+	s.pipeline = pl
 	s.isActive = true
+	s.events.Publish(events.SourceStarted, s.id, nil)
 
 	// Start frame capture in background
 	go s.captureFrames(ctx)
@@ -67,16 +130,63 @@ func (s *VideoSource) Start(ctx context.Context) error {
 	return nil
 }
 
+// openCapture opens the frame provider for a source type
+func (s *VideoSource) openCapture(config types.SourceConfig) (Capture, error) {
+	switch config.Type {
+	case "file":
+		return videoCapture(gocv.OpenVideoCapture(config.URI))
+	case "webcam":
+		deviceID, err := devices.Index(config.URI)
+		if err != nil {
+			return nil, err
+		}
+		return videoCapture(gocv.OpenVideoCapture(deviceID))
+	case "ip_camera":
+		return videoCapture(gocv.OpenVideoCapture(config.URI))
+	case "gstreamer":
+		return openGStreamer(config.URI)
+	case "mjpeg_http":
+		return newMJPEGCapture(config.URI, config.HTTP)
+	case "push":
+		return newPushCapture(), nil
+	case "image_sequence":
+		return newSequenceCapture(config.URI, config.Sequence)
+	case "pipe":
+		return newPipeCapture(config.Pipe, s.process)
+	case "composite":
+		return newCompositeCapture(s.id, config.Composite, s.deps.Frames)
+	case "derived":
+		return newDerivedCapture(s.id, config.Derived, s.deps.Frames)
+	case "playback":
+		return newPlaybackCapture(config.Playback, s.deps.Recordings, s.playback)
+	default:
+		return nil, fmt.Errorf("unsupported source type: %s", config.Type)
+	}
+}
+
+// videoCapture adapts the result of opening an OpenCV capture
+func videoCapture(vc *gocv.VideoCapture, err error) (Capture, error) {
+	if err != nil {
+		return nil, err
+	}
+	return vc, nil
+}
+
 // captureFrames continuously captures frames from the source
 func (s *VideoSource) captureFrames(ctx context.Context) {
 	// Ensure cleanup on exit
 	defer s.closeOnce.Do(func() {
-		log.Printf("Cleaning up video source: %s", s.config.URI)
 		close(s.frames)
+		s.mu.Lock()
+		log.Printf("Cleaning up video source: %s", s.config.URI)
 		if s.capture != nil {
 			s.capture.Close()
+			s.capture = nil
 		}
+		s.pipeline.Close()
 		s.isActive = false
+		s.mu.Unlock()
+		s.events.Publish(events.SourceStopped, s.id, nil)
 	})
 
 	// Create reusable matrix for frame capture
@@ -84,69 +194,541 @@ func (s *VideoSource) captureFrames(ctx context.Context) {
 	defer img.Close()
 
 	frameID := int64(0)
-	log.Printf("Starting frame capture for source: %s", s.config.URI)
+	log.Printf("Starting frame capture for source: %s", s.id)
+
+	for s.active() {
+		s.mu.RLock()
+		resume := s.resume
+		s.mu.RUnlock()
+		if resume != nil && !s.waitPaused(ctx, resume) {
+			return
+		}
+
+		// The configuration and stages may be replaced while a frame is read
+		s.mu.RLock()
+		config := s.config
+		pl := s.pipeline
+		capture := s.capture
+		s.mu.RUnlock()
 
-	for s.isActive {
 		select {
 		case <-ctx.Done():
-			log.Printf("Context cancelled for source: %s", s.config.URI)
+			log.Printf("Context cancelled for source: %s", config.URI)
+			return
+		case <-s.stop:
 			return
 		default:
+			// JPEG frames pass through untouched when no stage would change them
+			jpeg, encoded := capture.(jpegReader)
+			passthrough := encoded && pl.Empty()
+
 			// Read next frame
-			if ok := s.capture.Read(&img); !ok {
-				log.Printf("Failed to read frame from source: %s", s.config.URI)
-				if s.config.Type == "file" {
-					s.capture.Set(gocv.VideoCapturePosFrames, 0)
+			var frameBytes []byte
+			var ok bool
+			if passthrough {
+				frameBytes, ok = jpeg.ReadJPEG()
+			} else {
+				ok = capture.Read(&img)
+			}
+			if !ok && !s.active() {
+				return
+			}
+			if !ok {
+				log.Printf("Failed to read frame from source: %s", config.URI)
+				if vc, ok := capture.(*gocv.VideoCapture); ok && config.Type == "file" {
+					vc.Set(gocv.VideoCapturePosFrames, 0)
 					continue
 				}
-				return
+				if fc, ok := capture.(finiteCapture); ok && fc.Finished() {
+					log.Printf("Reached end of source: %s", config.URI)
+					s.mu.Lock()
+					s.finished = true
+					s.mu.Unlock()
+					return
+				}
+				s.events.Publish(events.SourceError, s.id, map[string]string{
+					"error": "failed to read frame",
+				})
+				if !s.reconnect(ctx) {
+					return
+				}
+				continue
 			}
 
-			if img.Empty() {
-				log.Printf("Received empty frame from source: %s", s.config.URI)
+			if !passthrough && img.Empty() {
+				log.Printf("Received empty frame from source: %s", config.URI)
 				continue
 			}
 
-			// Encode frame to JPEG
-			buf, err := gocv.IMEncode(".jpg", img)
-			if err != nil {
-				log.Printf("Error encoding frame: %v", err)
+			// Stages added while the frame was read must not be bypassed
+			if passthrough && s.currentPipeline() != pl {
 				continue
 			}
 
-			// Convert NativeByteBuffer to []byte
-			frameBytes := buf.GetBytes()
-			buf.Close()
-
 			// Create frame data
 			frame := types.FrameData{
 				ID:        frameID,
 				Timestamp: time.Now(),
-				Data:      frameBytes,
-				Source:    s.config.URI,
+				Source:    config.URI,
+			}
+
+			// Run processing stages and encode frame to JPEG
+			if !passthrough {
+				start := time.Now()
+				var err error
+				for {
+					work := pipeline.NewFrame(img, &frame)
+					frameBytes, err = pl.Run(work)
+					work.Close()
+					if !errors.Is(err, pipeline.ErrClosed) {
+						break
+					}
+					// Replaced meanwhile, run the frame through the new stages
+					current := s.currentPipeline()
+					if current == pl {
+						break
+					}
+					pl = current
+				}
+				if err != nil {
+					log.Printf("Error processing frame: %v", err)
+					s.events.Publish(events.SourceError, s.id, map[string]string{
+						"error": err.Error(),
+					})
+					continue
+				}
+				duration := time.Since(start)
+				s.observe(func() {
+					metrics.EncodeDuration.WithLabelValues(s.id).Observe(duration.Seconds())
+				})
 			}
+			frame.Data = frameBytes
 			frameID++
+			s.observe(func() {
+				metrics.FrameCaptured(s.id, len(frameBytes), frame.Timestamp)
+			})
+
+			// Publish as latest frame and wake waiters
+			s.mu.Lock()
+			s.latest = frame
+			close(s.frameReady)
+			s.frameReady = make(chan struct{})
+			s.mu.Unlock()
 
 			// Send frame to channel, skip if buffer full
 			select {
 			case s.frames <- frame:
 				if frameID%30 == 0 { // Log every 30 frames
 					log.Printf("Sent frame %d from source: %s (size: %d bytes)",
-						frameID, s.config.URI, len(frameBytes))
+						frameID, config.URI, len(frameBytes))
 				}
 			default:
 				log.Printf("Frame buffer full, dropping frame %d from source: %s",
-					frameID, s.config.URI)
+					frameID, config.URI)
+				s.observe(func() {
+					metrics.SourceDropped.WithLabelValues(s.id).Inc()
+				})
 			}
 		}
 	}
 }
 
-// Stop gracefully stops the video capture
+// reconnect reopens a failed capture with exponential backoff. It returns
+// false for sources that cannot reconnect and when the source is stopped.
+func (s *VideoSource) reconnect(ctx context.Context) bool {
+	s.mu.Lock()
+	config := s.config
+	capture := s.capture
+	s.capture = nil
+	s.mu.Unlock()
+
+	// Virtual, push and image sequence sources only fail when they are closed
+	switch config.Type {
+	case "composite", "derived", "push", "image_sequence":
+		return false
+	}
+
+	if capture != nil {
+		capture.Close()
+	}
+
+	delay := reconnectMinDelay
+	for attempt := 1; ; attempt++ {
+		if !s.observe(func() {
+			metrics.ReconnectAttempts.WithLabelValues(s.id).Inc()
+		}) {
+			return false
+		}
+		s.events.Publish(events.SourceReconnecting, s.id, map[string]interface{}{
+			"attempt":  attempt,
+			"delay_ms": delay.Milliseconds(),
+		})
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return false
+		case <-s.stop:
+			timer.Stop()
+			return false
+		case <-timer.C:
+		}
+
+		capture, err := s.openCapture(config)
+		if err != nil {
+			log.Printf("Reconnecting source %s failed: %v", s.id, err)
+			delay = min(delay*2, reconnectMaxDelay)
+			continue
+		}
+
+		s.mu.Lock()
+		if !s.isActive {
+			s.mu.Unlock()
+			capture.Close()
+			return false
+		}
+		s.capture = capture
+		metrics.Reconnects.WithLabelValues(s.id).Inc()
+		s.mu.Unlock()
+
+		log.Printf("Reconnected source %s after %d attempts", s.id, attempt)
+		s.events.Publish(events.SourceReconnected, s.id, map[string]interface{}{
+			"attempts": attempt,
+		})
+		return true
+	}
+}
+
+// observe updates metrics of the source while it is active and reports
+// whether it is. Series of a stopped source may already be removed, and a
+// frame read meanwhile must not bring them back.
+func (s *VideoSource) observe(update func()) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.isActive {
+		update()
+	}
+	return s.isActive
+}
+
+// currentPipeline returns the stages frames are processed with
+func (s *VideoSource) currentPipeline() *pipeline.Pipeline {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.pipeline
+}
+
+// active reports whether the source has not been stopped
+func (s *VideoSource) active() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.isActive
+}
+
+// waitPaused releases the capture while the source is paused and reopens it
+// when resumed. It returns false when the source is stopped instead.
+func (s *VideoSource) waitPaused(ctx context.Context, resume <-chan struct{}) bool {
+	log.Printf("Pausing source: %s", s.id)
+	s.mu.Lock()
+	capture := s.capture
+	s.capture = nil
+	s.mu.Unlock()
+	if capture != nil {
+		capture.Close()
+	}
+	s.events.Publish(events.SourcePaused, s.id, nil)
+
+	select {
+	case <-ctx.Done():
+		return false
+	case <-s.stop:
+		return false
+	case <-resume:
+	}
+	if !s.active() {
+		return false
+	}
+
+	s.mu.RLock()
+	config := s.config
+	s.mu.RUnlock()
+	capture, err := s.openCapture(config)
+	if err != nil {
+		log.Printf("Reopening source %s failed: %v", s.id, err)
+		s.events.Publish(events.SourceError, s.id, map[string]string{
+			"error": err.Error(),
+		})
+		if !s.reconnect(ctx) {
+			return false
+		}
+	} else {
+		s.mu.Lock()
+		if !s.isActive {
+			s.mu.Unlock()
+			capture.Close()
+			return false
+		}
+		s.capture = capture
+		s.mu.Unlock()
+	}
+	s.events.Publish(events.SourceResumed, s.id, nil)
+	return true
+}
+
+// Pause stops capturing and releases the device until Resume is called.
+// Subscribers stay connected and receive no frames meanwhile.
+func (s *VideoSource) Pause() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.isActive {
+		return fmt.Errorf("source is not active")
+	}
+	if s.resume != nil {
+		return fmt.Errorf("source is already paused")
+	}
+	s.resume = make(chan struct{})
+	return nil
+}
+
+// Resume restarts capturing after Pause
+func (s *VideoSource) Resume() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.resume == nil {
+		return fmt.Errorf("source is not paused")
+	}
+	close(s.resume)
+	s.resume = nil
+	return nil
+}
+
+// EnsurePaused pauses the source unless it is already paused
+func (s *VideoSource) EnsurePaused() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.isActive {
+		return fmt.Errorf("source is not active")
+	}
+	if s.resume == nil {
+		s.resume = make(chan struct{})
+	}
+	return nil
+}
+
+// EnsureRunning resumes the source unless it is already capturing
+func (s *VideoSource) EnsureRunning() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.isActive {
+		return fmt.Errorf("source is not active")
+	}
+	if s.resume != nil {
+		close(s.resume)
+		s.resume = nil
+	}
+	return nil
+}
+
+// Stop gracefully stops the video capture and interrupts reconnecting
 func (s *VideoSource) Stop() {
 	s.mu.Lock()
 	defer s.mu.Unlock()
+	if s.isActive {
+		close(s.stop)
+		if capture, ok := s.capture.(interruptible); ok {
+			capture.Interrupt()
+		}
+	}
 	s.isActive = false
+	if s.resume != nil {
+		close(s.resume)
+		s.resume = nil
+	}
+}
+
+// SetPipeline replaces the processing stages applied to captured frames
+func (s *VideoSource) SetPipeline(stages []types.StageConfig) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	config := s.config
+	config.Pipeline = stages
+	return s.reconfigure(config)
+}
+
+// SetPrivacyZones replaces the zones masked on every frame
+func (s *VideoSource) SetPrivacyZones(zones []types.PrivacyZone) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	config := s.config
+	config.PrivacyZones = zones
+	return s.reconfigure(config)
+}
+
+// AddPrivacyZone adds a zone masked on every frame
+func (s *VideoSource) AddPrivacyZone(zone types.PrivacyZone) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	config := s.config
+	config.PrivacyZones = append(slices.Clone(config.PrivacyZones), zone)
+	return s.reconfigure(config)
+}
+
+// RemovePrivacyZone removes a zone masked on every frame
+func (s *VideoSource) RemovePrivacyZone(zoneID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	config := s.config
+	config.PrivacyZones = slices.DeleteFunc(slices.Clone(config.PrivacyZones), func(zone types.PrivacyZone) bool {
+		return zone.ID == zoneID
+	})
+	if len(config.PrivacyZones) == len(s.config.PrivacyZones) {
+		return fmt.Errorf("privacy zone not found: %s", zoneID)
+	}
+	return s.reconfigure(config)
+}
+
+// GetPrivacyZones returns the zones masked on every frame
+func (s *VideoSource) GetPrivacyZones() []types.PrivacyZone {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.config.PrivacyZones
+}
+
+// CheckRawAccess verifies a token for streaming frames before anonymization
+func (s *VideoSource) CheckRawAccess(token string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	expected := s.config.RawAccessToken
+	return expected != "" && subtle.ConstantTimeCompare([]byte(expected), []byte(token)) == 1
+}
+
+// CheckIngestAccess verifies a token for uploading frames to a push source
+func (s *VideoSource) CheckIngestAccess(token string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	expected := s.config.IngestToken
+	return s.config.Type == "push" && expected != "" &&
+		subtle.ConstantTimeCompare([]byte(expected), []byte(token)) == 1
+}
+
+// IngestToken returns the token required to upload frames to a push source
+func (s *VideoSource) IngestToken() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.config.IngestToken
+}
+
+// Ingest delivers an uploaded JPEG frame to a push source
+func (s *VideoSource) Ingest(data []byte) error {
+	s.mu.RLock()
+	push, ok := s.capture.(*pushCapture)
+	s.mu.RUnlock()
+
+	if !ok {
+		return fmt.Errorf("source does not accept uploaded frames")
+	}
+	return push.Push(data)
+}
+
+// LatestInfo returns metadata of the most recently produced frame
+func (s *VideoSource) LatestInfo() types.FrameInfo {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return types.FrameInfo{
+		ID:        s.latest.ID,
+		Timestamp: s.latest.Timestamp,
+		Metadata:  s.latest.Metadata,
+	}
+}
+
+// reconfigure rebuilds the pipeline for a new configuration. The caller must
+// hold the write lock.
+func (s *VideoSource) reconfigure(config types.SourceConfig) error {
+	pl, err := pipeline.New(s.envFor(config), config.Pipeline)
+	if err != nil {
+		return err
+	}
+
+	old := s.pipeline
+	s.pipeline = pl
+	s.config = config
+	if old != nil {
+		old.Close()
+	}
+
+	// Without a tamper stage the last health report would never change
+	if !slices.ContainsFunc(config.Pipeline, func(stage types.StageConfig) bool {
+		return stage.Type == "tamper"
+	}) {
+		s.health.Clear()
+	}
+	return nil
+}
+
+// NextFrame waits for the next frame produced after the call
+func (s *VideoSource) NextFrame(timeout time.Duration) (types.FrameData, error) {
+	s.mu.RLock()
+	ready := s.frameReady
+	s.mu.RUnlock()
+
+	select {
+	case <-ready:
+	case <-time.After(timeout):
+		return types.FrameData{}, fmt.Errorf("timed out waiting for frame")
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.latest, nil
+}
+
+// GetPipeline returns the configured stages and their timings
+func (s *VideoSource) GetPipeline() types.PipelineInfo {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	info := types.PipelineInfo{Stages: s.config.Pipeline}
+	if info.Stages == nil {
+		info.Stages = []types.StageConfig{}
+	}
+	if s.pipeline != nil {
+		info.Stats = s.pipeline.Stats()
+	}
+	return info
+}
+
+// envFor returns the pipeline environment for a source configuration
+func (s *VideoSource) envFor(config types.SourceConfig) *pipeline.Env {
+	return &pipeline.Env{
+		SourceID: s.id,
+		Config:   config,
+		Events:   s.events,
+		Codes:    s.codes,
+		Health:   s.health,
+	}
+}
+
+// ResetHealthReference makes the next analysed frame the tamper detection
+// scene reference
+func (s *VideoSource) ResetHealthReference() {
+	s.health.ResetReference()
+}
+
+// Codes returns decoded codes newer than since, newest first
+func (s *VideoSource) Codes(since time.Time, limit int) []types.CodeRead {
+	return s.codes.List(since, limit)
 }
 
 // GetFrames returns the channel for receiving frames
@@ -159,10 +741,76 @@ func (s *VideoSource) GetInfo() types.SourceInfo {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
-	return types.SourceInfo{
-		ID:          fmt.Sprintf("%s_%s", s.config.Type, s.config.URI),
+	info := types.SourceInfo{
+		ID:          s.id,
 		Type:        s.config.Type,
 		URI:         s.config.URI,
-		IsStreaming: s.isActive,
+		Name:        s.config.Name,
+		IsStreaming: s.isActive && s.resume == nil,
+		Paused:      s.resume != nil,
+		Finished:    s.finished,
+		Health:      s.health.Health(),
+		Inputs:      s.inputs(),
+		Parent:      s.parent(),
+		MediaID:     s.config.MediaID,
+	}
+	if s.config.Pipe != nil && len(s.config.Pipe.Command) > 0 {
+		info.Process = s.process.Info()
+	}
+	if s.config.Type == "playback" && s.config.Playback != nil {
+		info.Playback = s.playback.Info(s.config.Playback)
+	}
+	if !s.latest.Timestamp.IsZero() {
+		lastFrame := s.latest.Timestamp
+		info.LastFrame = &lastFrame
+	}
+	return info
+}
+
+// ControlPlayback seeks or changes the speed of a playback source
+func (s *VideoSource) ControlPlayback(control types.PlaybackControl) (*types.PlaybackStatus, error) {
+	s.mu.RLock()
+	config := s.config
+	s.mu.RUnlock()
+
+	if config.Type != "playback" || config.Playback == nil {
+		return nil, fmt.Errorf("source is not a playback source")
+	}
+	if err := s.playback.Control(config.Playback, control); err != nil {
+		return nil, err
+	}
+	return s.playback.Info(config.Playback), nil
+}
+
+// Parent returns the ID of the source a derived view is taken from
+func (s *VideoSource) Parent() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.parent()
+}
+
+func (s *VideoSource) parent() string {
+	if s.config.Derived != nil {
+		return s.config.Derived.Parent
 	}
+	return ""
+}
+
+// Feeds returns the IDs of the sources whose frames the source consumes,
+// the parent of a derived view or the inputs of a composite
+func (s *VideoSource) Feeds() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if parent := s.parent(); parent != "" {
+		return []string{parent}
+	}
+	return s.inputs()
+}
+
+// inputs returns the IDs of sources a virtual source is built from
+func (s *VideoSource) inputs() []string {
+	if s.config.Composite != nil {
+		return compositeInputs(s.config.Composite)
+	}
+	return nil
 }
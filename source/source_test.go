@@ -0,0 +1,147 @@
+package source
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Thivyesh/cameraServiceGo/events"
+	"github.com/Thivyesh/cameraServiceGo/types"
+)
+
+// noFrames is a Subscriber whose sources never exist
+type noFrames struct{}
+
+func (noFrames) Subscribe(sourceID string) (<-chan types.FrameData, error) {
+	return nil, fmt.Errorf("source %s not found", sourceID)
+}
+
+func (noFrames) Unsubscribe(sourceID string, frames <-chan types.FrameData) {}
+
+func TestStopEndsBlockedCapture(t *testing.T) {
+	tests := []struct {
+		name   string
+		config types.SourceConfig
+	}{
+		{"push without uploads", types.SourceConfig{Type: "push", URI: "door"}},
+		{"derived without parent", types.SourceConfig{Type: "derived", URI: "view", Derived: &types.DerivedConfig{Parent: "gone"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bus := events.NewBus(10)
+			_, sub := bus.Subscribe(events.Filter{Types: []string{events.SourceStopped, events.SourceError}}, 0)
+			s := NewVideoSource(tt.config, Dependencies{Events: bus, Frames: noFrames{}})
+			if err := s.Start(context.Background()); err != nil {
+				t.Fatal(err)
+			}
+			// Let the capture block in its first read
+			time.Sleep(20 * time.Millisecond)
+			s.Stop()
+
+			select {
+			case _, ok := <-s.GetFrames():
+				if ok {
+					t.Fatal("received a frame")
+				}
+			case <-time.After(time.Second):
+				t.Fatal("capture is still running after Stop")
+			}
+			select {
+			case event := <-sub.C:
+				if event.Type != events.SourceStopped {
+					t.Errorf("published %s, want %s", event.Type, events.SourceStopped)
+				}
+			case <-time.After(time.Second):
+				t.Error("source.stopped was not published")
+			}
+		})
+	}
+}
+
+func TestReconfigureWhileCapturing(t *testing.T) {
+	s := NewVideoSource(types.SourceConfig{Type: "push", URI: "door"}, Dependencies{Events: events.NewBus(10)})
+	if err := s.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	defer s.Stop()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for range 200 {
+			s.Ingest([]byte("\xff\xd8frame"))
+		}
+	}()
+	go func() {
+		for range s.GetFrames() {
+		}
+	}()
+	for range 200 {
+		if err := s.SetPipeline(nil); err != nil {
+			t.Fatal(err)
+		}
+		s.GetInfo()
+	}
+	<-done
+}
+
+func TestConcurrentPrivacyZones(t *testing.T) {
+	s := NewVideoSource(types.SourceConfig{Type: "push", URI: "door"}, Dependencies{Events: events.NewBus(10)})
+
+	const count = 50
+	var wg sync.WaitGroup
+	for i := range count {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			zone := types.PrivacyZone{ID: fmt.Sprint(i), Mode: "fill", Points: []types.Point{{X: 0, Y: 0}, {X: 1, Y: 0}, {X: 1, Y: 1}}}
+			if err := s.AddPrivacyZone(zone); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+	if n := len(s.GetPrivacyZones()); n != count {
+		t.Fatalf("%d zones after %d concurrent additions", n, count)
+	}
+
+	for i := range count / 2 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := s.RemovePrivacyZone(fmt.Sprint(i)); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+	if n := len(s.GetPrivacyZones()); n != count-count/2 {
+		t.Errorf("%d zones left, want %d", n, count-count/2)
+	}
+	if err := s.RemovePrivacyZone("0"); err == nil {
+		t.Error("removed a zone twice")
+	}
+}
+
+func TestObserveOnlyWhileActive(t *testing.T) {
+	s := NewVideoSource(types.SourceConfig{Type: "push", URI: "door"}, Dependencies{Events: events.NewBus(10)})
+	updated := false
+	if s.observe(func() { updated = true }) || updated {
+		t.Error("metrics updated before the source was started")
+	}
+
+	if err := s.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if !s.observe(func() { updated = true }) || !updated {
+		t.Error("metrics not updated while the source is active")
+	}
+
+	s.Stop()
+	updated = false
+	if s.observe(func() { updated = true }) || updated {
+		t.Error("metrics updated after the source was stopped")
+	}
+}
@@ -0,0 +1,511 @@
+// Package timelapse takes periodic stills of sources and assembles them into
+// time-lapse videos
+package timelapse
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Thivyesh/cameraServiceGo/ids"
+	"github.com/Thivyesh/cameraServiceGo/jsonfile"
+	"github.com/Thivyesh/cameraServiceGo/types"
+	"gocv.io/x/gocv"
+)
+
+// defaultFPS is the frame rate of assembled videos unless configured
+const defaultFPS = 25
+
+// snapshotTimeout bounds how long a capture waits for the next frame
+const snapshotTimeout = 10 * time.Second
+
+// File and directory names within a job directory
+const (
+	configFile = "config.json"
+	stillsDir  = "stills"
+	videosDir  = "videos"
+	stillExt   = ".jpg"
+	videoExt   = ".avi"
+)
+
+// Errors callers may want to tell apart
+var (
+	ErrNotFound = errors.New("not found")
+	ErrNoStills = errors.New("no stills to assemble")
+)
+
+// Snapshotter provides the processed frames of sources
+type Snapshotter interface {
+	Snapshot(sourceID string, timeout time.Duration) (types.FrameData, error)
+}
+
+// job is a running time-lapse
+type job struct {
+	config      types.TimelapseConfig // Job configuration
+	dir         string                // Job directory
+	location    *time.Location        // Time zone of the daily assembly
+	cancel      context.CancelFunc    // Stops the capture loop
+	assembling  sync.Mutex            // Serializes assemblies
+	mu          sync.Mutex            // Protects the fields below
+	lastCapture time.Time             // Time of the most recent still
+	lastError   string                // Last capture or assembly failure
+	next        time.Time             // Next scheduled assembly
+	assembled   time.Time             // Time of the newest assembled still
+}
+
+// Manager runs time-lapse jobs and stores their stills and videos in a
+// directory, one subdirectory per job. Jobs survive restarts.
+type Manager struct {
+	dir    string          // Absolute time-lapse directory
+	frames Snapshotter     // Still provider
+	mu     sync.Mutex      // Protects jobs
+	jobs   map[string]*job // Jobs by ID
+}
+
+// NewManager opens or creates a time-lapse directory and resumes its jobs
+func NewManager(dir string, frames Snapshotter) (*Manager, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create time-lapse directory: %v", err)
+	}
+
+	m := &Manager{dir: dir, frames: frames, jobs: make(map[string]*job)}
+	configs, err := filepath.Glob(filepath.Join(dir, "*", configFile))
+	if err != nil {
+		return nil, err
+	}
+	for _, path := range configs {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		var config types.TimelapseConfig
+		if err := json.Unmarshal(data, &config); err != nil || filepath.Base(filepath.Dir(path)) != config.ID {
+			log.Printf("Skipping invalid time-lapse configuration %s", path)
+			continue
+		}
+		j, err := m.newJob(config)
+		if err != nil {
+			log.Printf("Skipping time-lapse %s: %v", config.ID, err)
+			continue
+		}
+		m.start(j)
+	}
+	return m, nil
+}
+
+// newJob validates a configuration and prepares its job directory
+func (m *Manager) newJob(config types.TimelapseConfig) (*job, error) {
+	if config.Source == "" {
+		return nil, fmt.Errorf("source is required")
+	}
+	if config.IntervalSeconds < 1 {
+		return nil, fmt.Errorf("interval_seconds must be at least 1")
+	}
+	if config.FPS < 0 {
+		return nil, fmt.Errorf("fps must not be negative")
+	}
+
+	j := &job{config: config, dir: filepath.Join(m.dir, config.ID), location: time.Local}
+	if config.TimeZone != "" {
+		loc, err := time.LoadLocation(config.TimeZone)
+		if err != nil {
+			return nil, fmt.Errorf("invalid time zone: %v", err)
+		}
+		j.location = loc
+	}
+	if config.AssembleAt != "" {
+		if _, err := time.Parse("15:04", config.AssembleAt); err != nil {
+			return nil, fmt.Errorf("assemble_at must be a time of day as HH:MM")
+		}
+	}
+
+	for _, sub := range []string{stillsDir, videosDir} {
+		if err := os.MkdirAll(filepath.Join(j.dir, sub), 0o755); err != nil {
+			return nil, err
+		}
+	}
+	for _, video := range j.videos() {
+		if video.To.After(j.assembled) {
+			j.assembled = video.To
+		}
+	}
+	return j, nil
+}
+
+// start registers a job and runs its capture loop
+func (m *Manager) start(j *job) {
+	ctx, cancel := context.WithCancel(context.Background())
+	j.cancel = cancel
+
+	m.mu.Lock()
+	m.jobs[j.config.ID] = j
+	m.mu.Unlock()
+
+	go m.run(ctx, j)
+}
+
+// run takes stills at the configured interval and assembles them daily
+func (m *Manager) run(ctx context.Context, j *job) {
+	ticker := time.NewTicker(time.Duration(j.config.IntervalSeconds) * time.Second)
+	defer ticker.Stop()
+
+	// Jobs without a daily assembly time are only assembled on demand
+	var assemble <-chan time.Time
+	if j.config.AssembleAt != "" {
+		assemble = time.After(time.Until(j.schedule(time.Now())))
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			j.capture(m.frames)
+		case <-assemble:
+			if _, err := j.assemble(); err != nil && !errors.Is(err, ErrNoStills) {
+				log.Printf("Error assembling time-lapse %s: %v", j.config.ID, err)
+			}
+			assemble = time.After(time.Until(j.schedule(time.Now())))
+		}
+	}
+}
+
+// schedule computes and stores the next daily assembly after now
+func (j *job) schedule(now time.Time) time.Time {
+	at, _ := time.Parse("15:04", j.config.AssembleAt)
+	local := now.In(j.location)
+	next := time.Date(local.Year(), local.Month(), local.Day(), at.Hour(), at.Minute(), 0, 0, j.location)
+	if !next.After(now) {
+		next = next.AddDate(0, 0, 1)
+	}
+
+	j.mu.Lock()
+	j.next = next
+	j.mu.Unlock()
+	return next
+}
+
+// capture stores the next frame of the source as a still
+func (j *job) capture(frames Snapshotter) {
+	frame, err := frames.Snapshot(j.config.Source, snapshotTimeout)
+	if err == nil {
+		name := strconv.FormatInt(frame.Timestamp.UnixNano(), 10) + stillExt
+		err = os.WriteFile(filepath.Join(j.dir, stillsDir, name), frame.Data, 0o644)
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if err != nil {
+		j.lastError = fmt.Sprintf("capture failed: %v", err)
+		return
+	}
+	j.lastCapture = frame.Timestamp
+	j.lastError = ""
+}
+
+// still is a stored still and its capture time
+type still struct {
+	path string
+	time time.Time
+}
+
+// stills returns the stills taken after the last assembly, oldest first
+func (j *job) stills() []still {
+	files, _ := os.ReadDir(filepath.Join(j.dir, stillsDir))
+
+	j.mu.Lock()
+	assembled := j.assembled
+	j.mu.Unlock()
+
+	var stills []still
+	for _, file := range files {
+		base, ok := strings.CutSuffix(file.Name(), stillExt)
+		if !ok {
+			continue
+		}
+		ns, err := strconv.ParseInt(base, 10, 64)
+		if err != nil {
+			continue
+		}
+		t := time.Unix(0, ns)
+		if t.After(assembled) {
+			stills = append(stills, still{path: filepath.Join(j.dir, stillsDir, file.Name()), time: t})
+		}
+	}
+	slices.SortFunc(stills, func(a, b still) int {
+		return a.time.Compare(b.time)
+	})
+	return stills
+}
+
+// assemble encodes the pending stills into a Motion JPEG AVI. Stills with a
+// different size than the first are scaled to match.
+func (j *job) assemble() (types.TimelapseVideo, error) {
+	j.assembling.Lock()
+	defer j.assembling.Unlock()
+
+	video, err := j.encode()
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if err != nil {
+		if !errors.Is(err, ErrNoStills) {
+			j.lastError = fmt.Sprintf("assembly failed: %v", err)
+		}
+		return video, err
+	}
+	j.assembled = video.To
+	j.lastError = ""
+	return video, nil
+}
+
+func (j *job) encode() (types.TimelapseVideo, error) {
+	stills := j.stills()
+	if len(stills) == 0 {
+		return types.TimelapseVideo{}, ErrNoStills
+	}
+
+	video := types.TimelapseVideo{
+		ID:        ids.New("video_"),
+		Timelapse: j.config.ID,
+		From:      stills[0].time,
+		To:        stills[len(stills)-1].time,
+		FPS:       j.config.FPS,
+	}
+	if video.FPS == 0 {
+		video.FPS = defaultFPS
+	}
+	path := filepath.Join(j.dir, videosDir, video.ID+videoExt)
+
+	var writer *gocv.VideoWriter
+	var size image.Point
+	for _, s := range stills {
+		img := gocv.IMRead(s.path, gocv.IMReadColor)
+		if img.Empty() {
+			img.Close()
+			continue
+		}
+		if writer == nil {
+			size = image.Pt(img.Cols(), img.Rows())
+			var err error
+			if writer, err = gocv.VideoWriterFile(path, "MJPG", video.FPS, size.X, size.Y, true); err != nil {
+				img.Close()
+				return video, fmt.Errorf("failed to open video writer: %v", err)
+			}
+		}
+		if img.Cols() != size.X || img.Rows() != size.Y {
+			gocv.Resize(img, &img, size, 0, 0, gocv.InterpolationLinear)
+		}
+		err := writer.Write(img)
+		img.Close()
+		if err != nil {
+			writer.Close()
+			os.Remove(path)
+			return video, err
+		}
+		video.Frames++
+	}
+	if writer == nil {
+		return video, fmt.Errorf("none of the %d stills could be decoded", len(stills))
+	}
+	if err := writer.Close(); err != nil {
+		os.Remove(path)
+		return video, err
+	}
+
+	stat, err := os.Stat(path)
+	if err != nil {
+		return video, err
+	}
+	video.Size = stat.Size()
+	video.CreatedAt = time.Now()
+	if err := jsonfile.Write(filepath.Join(j.dir, videosDir, video.ID+".json"), video); err != nil {
+		os.Remove(path)
+		return video, err
+	}
+
+	if !j.config.KeepStills {
+		for _, s := range stills {
+			os.Remove(s.path)
+		}
+	}
+	return video, nil
+}
+
+// videos returns the assembled videos, most recent first
+func (j *job) videos() []types.TimelapseVideo {
+	paths, _ := filepath.Glob(filepath.Join(j.dir, videosDir, "video_*.json"))
+	videos := make([]types.TimelapseVideo, 0, len(paths))
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		var video types.TimelapseVideo
+		if json.Unmarshal(data, &video) == nil {
+			videos = append(videos, video)
+		}
+	}
+	slices.SortFunc(videos, func(a, b types.TimelapseVideo) int {
+		return b.CreatedAt.Compare(a.CreatedAt)
+	})
+	return videos
+}
+
+// info returns the job status
+func (j *job) info() types.TimelapseInfo {
+	info := types.TimelapseInfo{
+		Config: j.config,
+		Stills: len(j.stills()),
+		Videos: len(j.videos()),
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if !j.lastCapture.IsZero() {
+		last := j.lastCapture
+		info.LastCapture = &last
+	}
+	if !j.next.IsZero() {
+		next := j.next
+		info.NextAssembly = &next
+	}
+	info.LastError = j.lastError
+	return info
+}
+
+// Create validates, stores and starts a time-lapse job
+func (m *Manager) Create(config types.TimelapseConfig) (types.TimelapseInfo, error) {
+	config.ID = ids.New("timelapse_")
+	j, err := m.newJob(config)
+	if err != nil {
+		os.RemoveAll(filepath.Join(m.dir, config.ID))
+		return types.TimelapseInfo{}, err
+	}
+	if err := jsonfile.Write(filepath.Join(j.dir, configFile), config); err != nil {
+		os.RemoveAll(j.dir)
+		return types.TimelapseInfo{}, fmt.Errorf("failed to save time-lapse configuration: %v", err)
+	}
+
+	m.start(j)
+	return j.info(), nil
+}
+
+// List returns all jobs
+func (m *Manager) List() []types.TimelapseInfo {
+	m.mu.Lock()
+	jobs := make([]*job, 0, len(m.jobs))
+	for _, j := range m.jobs {
+		jobs = append(jobs, j)
+	}
+	m.mu.Unlock()
+
+	infos := make([]types.TimelapseInfo, 0, len(jobs))
+	for _, j := range jobs {
+		infos = append(infos, j.info())
+	}
+	slices.SortFunc(infos, func(a, b types.TimelapseInfo) int {
+		return strings.Compare(a.Config.ID, b.Config.ID)
+	})
+	return infos
+}
+
+func (m *Manager) job(id string) (*job, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	j, ok := m.jobs[id]
+	if !ok {
+		return nil, fmt.Errorf("time-lapse %w: %s", ErrNotFound, id)
+	}
+	return j, nil
+}
+
+// Get returns the status of a job
+func (m *Manager) Get(id string) (types.TimelapseInfo, error) {
+	j, err := m.job(id)
+	if err != nil {
+		return types.TimelapseInfo{}, err
+	}
+	return j.info(), nil
+}
+
+// Delete stops a job and removes its stills and videos
+func (m *Manager) Delete(id string) error {
+	m.mu.Lock()
+	j, ok := m.jobs[id]
+	delete(m.jobs, id)
+	m.mu.Unlock()
+	if !ok {
+		return fmt.Errorf("time-lapse %w: %s", ErrNotFound, id)
+	}
+
+	j.cancel()
+	j.assembling.Lock()
+	defer j.assembling.Unlock()
+	return os.RemoveAll(j.dir)
+}
+
+// Assemble encodes the stills taken since the last assembly into a video
+func (m *Manager) Assemble(id string) (types.TimelapseVideo, error) {
+	j, err := m.job(id)
+	if err != nil {
+		return types.TimelapseVideo{}, err
+	}
+	return j.assemble()
+}
+
+// Videos returns the assembled videos of a job, most recent first
+func (m *Manager) Videos(id string) ([]types.TimelapseVideo, error) {
+	j, err := m.job(id)
+	if err != nil {
+		return nil, err
+	}
+	return j.videos(), nil
+}
+
+// Video returns an assembled video and the path of its file
+func (m *Manager) Video(id, videoID string) (types.TimelapseVideo, string, error) {
+	j, err := m.job(id)
+	if err != nil {
+		return types.TimelapseVideo{}, "", err
+	}
+	for _, video := range j.videos() {
+		if video.ID == videoID {
+			return video, filepath.Join(j.dir, videosDir, video.ID+videoExt), nil
+		}
+	}
+	return types.TimelapseVideo{}, "", fmt.Errorf("video %w: %s", ErrNotFound, videoID)
+}
+
+// DeleteVideo removes an assembled video
+func (m *Manager) DeleteVideo(id, videoID string) error {
+	_, path, err := m.Video(id, videoID)
+	if err != nil {
+		return err
+	}
+	os.Remove(path)
+	return os.Remove(strings.TrimSuffix(path, videoExt) + ".json")
+}
+
+// Close stops all capture loops
+func (m *Manager) Close() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, j := range m.jobs {
+		j.cancel()
+	}
+}
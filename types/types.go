@@ -1,7 +1,10 @@
 // Package types contains all shared data structures for the camera service
 package types
 
-import "time"
+import (
+	"encoding/json"
+	"time"
+)
 
 // FrameData represents a single frame from any video source
 // @Description Video frame data structure
@@ -10,18 +13,329 @@ type FrameData struct {
 	Timestamp time.Time `json:"timestamp"` // When the frame was captured
 	Data      []byte    `json:"data"`      // Raw frame data in JPEG format
 	Source    string    `json:"source"`    // Identifier of the source
+	// Values attached by pipeline stages (e.g. analytics results)
+	Metadata map[string]interface{} `json:"metadata,omitempty"`
+	// JPEG encoded frame before anonymization, only kept for sources with
+	// raw access enabled
+	Raw []byte `json:"-"`
+}
+
+// FrameInfo describes a frame without its image data
+// @Description Frame metadata without image data
+type FrameInfo struct {
+	// @Description Frame identifier
+	ID int64 `json:"id"` // Frame sequence number
+	// @Description When the frame was captured
+	Timestamp time.Time `json:"timestamp"` // Capture time
+	// @Description Values attached by pipeline stages
+	Metadata map[string]interface{} `json:"metadata,omitempty"` // Stage results
+}
+
+// Box is a rectangle in normalized image coordinates (0..1)
+// @Description Normalized bounding box
+type Box struct {
+	// @Description Left edge
+	X float64 `json:"x"`
+	// @Description Top edge
+	Y float64 `json:"y"`
+	// @Description Width relative to the image width
+	Width float64 `json:"width"`
+	// @Description Height relative to the image height
+	Height float64 `json:"height"`
+}
+
+// Detection is an object found by an analytics stage
+// @Description Detected object
+type Detection struct {
+	// @Description Class label, or the class ID when no labels are configured
+	Label string `json:"label"` // Class name
+	// @Description Numeric class ID reported by the model
+	ClassID int `json:"class_id"` // Model class index
+	// @Description Detection confidence between 0 and 1
+	Confidence float64 `json:"confidence"` // Model score
+	// @Description Bounding box in normalized coordinates
+	Box Box `json:"box"` // Object location
+}
+
+// CodeRead is a decoded QR code
+// @Description Decoded QR code
+type CodeRead struct {
+	// @Description Decoded payload
+	Payload string `json:"payload"` // Code contents
+	// @Description Corners of the code in normalized coordinates
+	Points []Point `json:"points"` // Bounding quadrilateral
+	// @Description Frame the code was read from
+	FrameID int64 `json:"frame_id"` // Frame sequence number
+	// @Description When the code was read
+	Timestamp time.Time `json:"timestamp"` // Capture time
+}
+
+// ImageHealth reports image quality metrics used for tamper detection
+// @Description Image health metrics and active tamper alerts
+type ImageHealth struct {
+	// @Description Mean brightness (0-255)
+	Brightness float64 `json:"brightness"` // Mean gray level
+	// @Description Variance of the Laplacian, low values indicate defocus
+	Sharpness float64 `json:"sharpness"` // Focus measure
+	// @Description Number of consecutive identical frames
+	FrozenFrames int `json:"frozen_frames"` // Unchanged frame count
+	// @Description Fraction of the image that differs from the reference (0-1)
+	SceneChange float64 `json:"scene_change"` // Difference to reference
+	// @Description Active alerts (too_dark, too_bright, defocused, frozen, scene_changed)
+	Alerts []string `json:"alerts"` // Raised tamper alerts
+	// @Description When the metrics were last computed
+	UpdatedAt time.Time `json:"updated_at"` // Evaluation time
 }
 
 // SourceConfig defines the configuration for a video source
 // @Description Configuration for a video source
 type SourceConfig struct {
-	// @Description Type of video source (webcam, file, ip_camera)
+	// @Description Type of video source (webcam, file, ip_camera, gstreamer, mjpeg_http, push, image_sequence, pipe, composite, derived, playback)
 	Type string `json:"type"`
 	// @Description URI or identifier for the video source
 	URI string `json:"uri"`
+	// @Description Media library item to play, sets type file and the URI when given
+	MediaID string `json:"media_id,omitempty"`
 	// For files: path to video file
 	// For webcam: device ID (e.g., "0" for default camera)
 	// For IP camera: RTSP/HTTP URL
+	// @Description Human readable camera name, used by on-screen display
+	Name string `json:"name,omitempty"`
+	// @Description Ordered frame processing stages applied before encoding
+	Pipeline []StageConfig `json:"pipeline,omitempty"`
+	// @Description Regions masked on every frame before any other processing
+	PrivacyZones []PrivacyZone `json:"privacy_zones,omitempty"`
+	// @Description Token required to stream frames before anonymization, empty disables raw access
+	RawAccessToken string `json:"raw_access_token,omitempty"`
+	// @Description Token required to upload frames to a push source, generated when empty
+	IngestToken string `json:"ingest_token,omitempty"`
+	// @Description Layout of a composite source
+	Composite *CompositeConfig `json:"composite,omitempty"`
+	// @Description Parent and transform of a derived source
+	Derived *DerivedConfig `json:"derived,omitempty"`
+	// @Description Credentials and timeouts of an HTTP source
+	HTTP *HTTPConfig `json:"http,omitempty"`
+	// @Description Playback settings of an image sequence source, whose URI is a directory or glob pattern
+	Sequence *SequenceConfig `json:"sequence,omitempty"`
+	// @Description Command or named pipe and frame format of a pipe source
+	Pipe *PipeConfig `json:"pipe,omitempty"`
+	// @Description Recorded footage played by a playback source
+	Playback *PlaybackConfig `json:"playback,omitempty"`
+}
+
+// PlaybackConfig selects the recorded footage played by a playback source
+// @Description Playback source configuration
+type PlaybackConfig struct {
+	// @Description Source whose recordings are played
+	Source string `json:"source"`
+	// @Description Start of the played time range
+	From time.Time `json:"from"`
+	// @Description End of the played time range, defaults to when the playback source is added
+	To time.Time `json:"to,omitempty"`
+	// @Description Playback speed, up to 16, defaults to 1
+	Speed float64 `json:"speed,omitempty"`
+	// @Description Playback mode (once, loop), defaults to once
+	Mode string `json:"mode,omitempty"`
+}
+
+// PlaybackStatus describes the progress of a playback source
+// @Description Playback source status
+type PlaybackStatus struct {
+	// @Description Source whose recordings are played
+	Source string `json:"source"` // Recorded source ID
+	// @Description Start of the played time range
+	From time.Time `json:"from"` // Range start
+	// @Description End of the played time range
+	To time.Time `json:"to"` // Range end
+	// @Description Capture time of the last played frame
+	Position time.Time `json:"position"` // Playback position
+	// @Description Playback speed, 1 is real time
+	Speed float64 `json:"speed"` // Speed factor
+	// @Description Playback mode (once, loop)
+	Mode string `json:"mode"` // Playback mode
+}
+
+// PlaybackControl changes the position or speed of a playback source
+// @Description Playback source control request
+type PlaybackControl struct {
+	// @Description Capture time to continue playback at
+	Position *time.Time `json:"position,omitempty"` // Seek target
+	// @Description New playback speed, up to 16
+	Speed float64 `json:"speed,omitempty"` // Speed factor
+}
+
+// PipeConfig configures a source reading frames from an external process or
+// a named pipe
+// @Description Pipe source configuration
+type PipeConfig struct {
+	// @Description Command line to run, frames are read from its standard output
+	Command []string `json:"command,omitempty"`
+	// @Description Named pipe (FIFO) to read frames from instead of running a command
+	Path string `json:"path,omitempty"`
+	// @Description Frame format (mjpeg, raw), defaults to mjpeg
+	Format string `json:"format,omitempty"`
+	// @Description Raw frame width in pixels
+	Width int `json:"width,omitempty"`
+	// @Description Raw frame height in pixels
+	Height int `json:"height,omitempty"`
+	// @Description Raw pixel format (bgr24, rgb24, gray), defaults to bgr24
+	PixelFormat string `json:"pixel_format,omitempty"`
+}
+
+// ProcessInfo describes the external process of a pipe source
+// @Description Pipe source process status
+type ProcessInfo struct {
+	// @Description Process ID of the running command
+	PID int `json:"pid,omitempty"`
+	// @Description Whether the command is running
+	Running bool `json:"running"`
+	// @Description Number of times the command was restarted
+	Restarts int `json:"restarts"`
+	// @Description How the command last exited
+	LastExit string `json:"last_exit,omitempty"`
+	// @Description Most recent lines written to standard error
+	Stderr []string `json:"stderr"`
+}
+
+// SequenceConfig configures playback of a set of image files
+// @Description Image sequence source configuration
+type SequenceConfig struct {
+	// @Description Frame order (name, timestamp), defaults to name
+	Order string `json:"order,omitempty"`
+	// @Description Go time layout of the timestamp in file names, e.g. 20060102_150405.000; Unix seconds or milliseconds are detected when empty
+	TimestampLayout string `json:"timestamp_layout,omitempty"`
+	// @Description Playback frame rate, defaults to 10
+	FPS float64 `json:"fps,omitempty"`
+	// @Description Playback mode (loop, once), defaults to loop
+	Mode string `json:"mode,omitempty"`
+}
+
+// HTTPConfig configures sources fetched over HTTP
+// @Description HTTP source connection settings
+type HTTPConfig struct {
+	// @Description User name for basic or digest authentication
+	Username string `json:"username,omitempty"`
+	// @Description Password for basic or digest authentication
+	Password string `json:"password,omitempty"`
+	// @Description Time allowed to connect and receive response headers in milliseconds, defaults to 10000
+	ConnectTimeoutMs int `json:"connect_timeout_ms,omitempty"`
+	// @Description Longest gap between frames in milliseconds before reconnecting, defaults to 10000
+	ReadTimeoutMs int `json:"read_timeout_ms,omitempty"`
+}
+
+// DerivedConfig describes a view of another source. Transforms are applied
+// in order: crop, rotate, flip, scale.
+// @Description Derived view source configuration
+type DerivedConfig struct {
+	// @Description ID of the source whose frames are transformed
+	Parent string `json:"parent"`
+	// @Description Region of the parent frame in normalized coordinates
+	Crop *Box `json:"crop,omitempty"`
+	// @Description Clockwise rotation in degrees
+	Rotate float64 `json:"rotate,omitempty"`
+	// @Description Flip direction (horizontal, vertical, both)
+	Flip string `json:"flip,omitempty"`
+	// @Description Scale factor applied last, e.g. 2 for a digital zoom of a crop
+	Scale float64 `json:"scale,omitempty"`
+}
+
+// CompositeConfig describes a virtual source built from other sources
+// @Description Composite (mosaic) source configuration
+type CompositeConfig struct {
+	// @Description Composition mode (grid, pip), defaults to grid
+	Mode string `json:"mode,omitempty"`
+	// @Description IDs of the sources to combine, in tile order (grid mode)
+	Sources []string `json:"sources,omitempty"`
+	// @Description Grid layout as COLUMNSxROWS (e.g. 2x2), derived from the source count when empty
+	Layout string `json:"layout,omitempty"`
+	// @Description Picture-in-picture settings (pip mode)
+	Pip *PipConfig `json:"pip,omitempty"`
+	// @Description Draw the capture time of each input on its tile
+	ShowTimestamps bool `json:"show_timestamps,omitempty"`
+	// @Description Output width in pixels
+	Width int `json:"width,omitempty"`
+	// @Description Output height in pixels
+	Height int `json:"height,omitempty"`
+	// @Description Output frame rate
+	FPS float64 `json:"fps,omitempty"`
+}
+
+// PipConfig places a secondary source as an inset over a primary source
+// @Description Picture-in-picture configuration
+type PipConfig struct {
+	// @Description Source filling the output
+	Primary string `json:"primary"`
+	// @Description Source shown in the inset
+	Secondary string `json:"secondary"`
+	// @Description Inset corner (top_left, top_right, bottom_left, bottom_right), defaults to bottom_right
+	Position string `json:"position,omitempty"`
+	// @Description Inset size as a fraction of the output size, defaults to 0.3
+	Size float64 `json:"size,omitempty"`
+	// @Description Distance of the inset from the output edges in pixels, defaults to 16
+	Margin *int `json:"margin,omitempty"`
+	// @Description Inset border width in pixels, defaults to 2
+	Border *int `json:"border,omitempty"`
+	// @Description Inset border color as #RRGGBB, defaults to #FFFFFF
+	BorderColor string `json:"border_color,omitempty"`
+}
+
+// Point is a position in normalized image coordinates (0..1)
+// @Description Normalized image coordinate
+type Point struct {
+	// @Description Horizontal position, 0 is the left and 1 the right edge
+	X float64 `json:"x"`
+	// @Description Vertical position, 0 is the top and 1 the bottom edge
+	Y float64 `json:"y"`
+}
+
+// PrivacyZone defines a polygon that is masked out of every frame
+// @Description Privacy masking zone
+type PrivacyZone struct {
+	// @Description Zone identifier, assigned when omitted
+	ID string `json:"id"` // Unique within the source
+	// @Description Optional description of the zone
+	Name string `json:"name,omitempty"` // Human readable label
+	// @Description Polygon vertices in normalized coordinates
+	Points []Point `json:"points"` // At least three vertices
+	// @Description Masking mode (fill, pixelate, blur)
+	Mode string `json:"mode"` // Defaults to fill
+	// @Description Fill color as #RRGGBB for fill mode
+	Color string `json:"color,omitempty"` // Defaults to black
+}
+
+// StageConfig configures a single frame processing stage
+// @Description Configuration for a frame processing stage
+type StageConfig struct {
+	// @Description Stage type (resize, crop, rotate, flip, color, denoise, osd, detect, face_blur, qrcode, tamper or a registered custom type)
+	Type string `json:"type"`
+	// @Description Stage specific options
+	Options json.RawMessage `json:"options,omitempty" swaggertype:"object"`
+}
+
+// StageStats reports timing information for a pipeline stage
+// @Description Timing statistics for a pipeline stage
+type StageStats struct {
+	// @Description Stage type
+	Type string `json:"type"` // Stage type, or "encode" for JPEG encoding
+	// @Description Number of frames processed by the stage
+	Frames int64 `json:"frames"` // Frames processed
+	// @Description Number of frames the stage failed on
+	Errors int64 `json:"errors"` // Failed invocations
+	// @Description Duration of the most recent invocation in milliseconds
+	LastMs float64 `json:"last_ms"` // Latest duration
+	// @Description Average duration in milliseconds
+	AvgMs float64 `json:"avg_ms"` // Mean duration
+	// @Description Longest duration in milliseconds
+	MaxMs float64 `json:"max_ms"` // Worst case duration
+}
+
+// PipelineInfo describes a source's processing pipeline and its timings
+// @Description Processing pipeline configuration and per-stage timings
+type PipelineInfo struct {
+	// @Description Configured stages in execution order
+	Stages []StageConfig `json:"stages"` // Stage configuration
+	// @Description Timings per stage followed by JPEG encoding
+	Stats []StageStats `json:"stats"` // Stage timings
 }
 
 // SourceInfo provides information about a video source
@@ -33,6 +347,362 @@ type SourceInfo struct {
 	Type string `json:"type"` // Source type
 	// @Description URI of the video source
 	URI string `json:"uri"` // Source location
+	// @Description Human readable camera name
+	Name string `json:"name,omitempty"` // Camera name
 	// @Description Whether the source is currently streaming
 	IsStreaming bool `json:"is_streaming"` // Whether source is actively streaming
+	// @Description Whether capture is paused, e.g. by a schedule
+	Paused bool `json:"paused,omitempty"` // Whether capture is paused
+	// @Description Whether a once-mode sequence or playback source played all its frames
+	Finished bool `json:"finished,omitempty"` // Whether capture ended normally
+	// @Description When the source last produced a frame
+	LastFrame *time.Time `json:"last_frame,omitempty"` // Latest frame time
+	// @Description Image health metrics, present when a tamper stage is configured
+	Health *ImageHealth `json:"health,omitempty"` // Tamper detection status
+	// @Description Sources a virtual source consumes
+	Inputs []string `json:"inputs,omitempty"` // Input source IDs
+	// @Description Source a derived view is taken from
+	Parent string `json:"parent,omitempty"` // Parent source ID
+	// @Description Ancestors of a derived view, starting with the capturing source
+	Lineage []string `json:"lineage,omitempty"` // Parent chain
+	// @Description External process of a pipe source
+	Process *ProcessInfo `json:"process,omitempty"` // Command status
+	// @Description Media library item played by a file source
+	MediaID string `json:"media_id,omitempty"` // Played media
+	// @Description Whether frames are being recorded to disk
+	Recording bool `json:"recording"` // Recording state
+	// @Description Position and speed of a playback source
+	Playback *PlaybackStatus `json:"playback,omitempty"` // Playback progress
+}
+
+// HealthReport describes the health of the service and its sources
+// @Description Service health
+type HealthReport struct {
+	// @Description Overall status (ok, degraded or unavailable)
+	Status string `json:"status"` // Overall status
+	// @Description Whether the service is ready to serve, see /readyz
+	Ready bool `json:"ready"` // Readiness
+	// @Description Reasons the service is not ready
+	Problems []string `json:"problems,omitempty"` // Readiness failures
+	// @Description When the service started
+	StartedAt time.Time `json:"started_at"` // Process start
+	// @Description Oldest last frame allowed before a source counts as stalled, in seconds
+	MaxFrameAgeSeconds float64 `json:"max_frame_age_seconds"` // Stall threshold
+	// @Description Health of every source and of missing critical sources
+	Sources []SourceHealth `json:"sources"` // Per-source health
+}
+
+// SourceHealth describes the health of a single source
+// @Description Source health
+type SourceHealth struct {
+	// @Description Source identifier
+	ID string `json:"id"` // Source ID
+	// @Description Human readable camera name
+	Name string `json:"name,omitempty"` // Camera name
+	// @Description Type of video source
+	Type string `json:"type,omitempty"` // Source type
+	// @Description Source status (streaming, paused, finished, waiting, stalled, stopped, pending or missing)
+	Status string `json:"status"` // Source status
+	// @Description Whether the service is only ready while this source is healthy
+	Critical bool `json:"critical"` // Required for readiness
+	// @Description Whether the source is streaming or deliberately paused
+	Healthy bool `json:"healthy"` // Health verdict
+	// @Description When the source last produced a frame
+	LastFrame *time.Time `json:"last_frame,omitempty"` // Latest frame time
+	// @Description Seconds since the source last produced a frame
+	LastFrameAgeSeconds float64 `json:"last_frame_age_seconds,omitempty"` // Latest frame age
+	// @Description Active tamper alerts of the source
+	Alerts []string `json:"alerts,omitempty"` // Tamper alerts
+}
+
+// Event represents a single service event such as a source lifecycle change
+// @Description Service event delivered over the event stream
+type Event struct {
+	// @Description Monotonically increasing event identifier
+	ID int64 `json:"id"` // Used as the SSE event ID for resuming
+	// @Description Event type (e.g. source.added, subscriber.connected)
+	Type string `json:"type"` // Dotted event type
+	// @Description Source the event relates to, if any
+	Source string `json:"source,omitempty"` // Source ID
+	// @Description When the event occurred
+	Timestamp time.Time `json:"timestamp"` // Event time
+	// @Description Event specific payload
+	Data interface{} `json:"data,omitempty"` // Optional payload
+}
+
+// VideoDevice describes a local video capture device
+// @Description Local video device
+type VideoDevice struct {
+	// @Description Device node, e.g. /dev/video0
+	Path string `json:"path"` // Device node
+	// @Description Index to use as webcam URI
+	Index int `json:"index"` // OpenCV device index
+	// @Description Stable symlinks to the device, usable as webcam URI
+	Links []string `json:"links,omitempty"` // by-id and by-path links
+	// @Description Device name reported by the driver
+	Name string `json:"name"` // V4L2 card name
+	// @Description Kernel driver
+	Driver string `json:"driver,omitempty"` // V4L2 driver name
+	// @Description Bus the device is attached to
+	BusInfo string `json:"bus_info,omitempty"` // V4L2 bus info
+	// @Description Whether the device node captures video, metadata nodes do not
+	Capture bool `json:"capture"` // Video capture capability
+	// @Description Supported pixel formats and resolutions
+	Formats []VideoFormat `json:"formats"` // Capture formats
+	// @Description Sources currently capturing from the device
+	InUseBy []string `json:"in_use_by"` // Source IDs
+}
+
+// VideoFormat is a pixel format supported by a video device
+// @Description Video device pixel format
+type VideoFormat struct {
+	// @Description Four character code, e.g. MJPG or YUYV
+	FourCC string `json:"fourcc"` // Pixel format code
+	// @Description Format description reported by the driver
+	Description string `json:"description"` // Driver description
+	// @Description Supported frame sizes; for stepwise formats the minimum and maximum
+	Resolutions []Resolution `json:"resolutions"` // Frame sizes
+	// @Description Whether any size between the minimum and maximum is supported
+	Stepwise bool `json:"stepwise,omitempty"` // Continuous size range
+}
+
+// Resolution is a frame size in pixels
+// @Description Frame size
+type Resolution struct {
+	// @Description Width in pixels
+	Width int `json:"width"`
+	// @Description Height in pixels
+	Height int `json:"height"`
+}
+
+// MediaItem is a video file in the media library
+// @Description Media library video
+type MediaItem struct {
+	// @Description Media identifier, usable as media_id of a file source
+	ID string `json:"id"` // Media ID
+	// @Description Original file name
+	Name string `json:"name"` // Uploaded file name
+	// @Description File size in bytes
+	Size int64 `json:"size"` // Size in bytes
+	// @Description Duration in seconds
+	Duration float64 `json:"duration"` // Length of the video
+	// @Description Frame rate
+	FPS float64 `json:"fps"` // Frames per second
+	// @Description Number of frames
+	Frames int `json:"frames"` // Frame count
+	// @Description Frame width in pixels
+	Width int `json:"width"` // Frame width
+	// @Description Frame height in pixels
+	Height int `json:"height"` // Frame height
+	// @Description Video codec four character code
+	Codec string `json:"codec"` // FourCC
+	// @Description When the upload completed
+	UploadedAt time.Time `json:"uploaded_at"` // Upload time
+}
+
+// UploadInfo describes a resumable media upload
+// @Description Resumable upload status
+type UploadInfo struct {
+	// @Description Upload identifier
+	ID string `json:"id"` // Upload ID
+	// @Description Name of the file being uploaded
+	Filename string `json:"filename"` // Original file name
+	// @Description Total size in bytes
+	Size int64 `json:"size"` // Expected size
+	// @Description Bytes received so far, where the next chunk must start
+	Offset int64 `json:"offset"` // Received bytes
+	// @Description Media item created when the upload completed
+	MediaID string `json:"media_id,omitempty"` // Resulting media
+}
+
+// UploadRequest starts a resumable media upload
+// @Description Resumable upload request
+type UploadRequest struct {
+	// @Description Name of the file being uploaded
+	Filename string `json:"filename"`
+	// @Description Total size in bytes
+	Size int64 `json:"size"`
+}
+
+// ExportJob describes a clip export of recorded frames
+// @Description Clip export job
+type ExportJob struct {
+	// @Description Export identifier
+	ID string `json:"id"` // Export ID
+	// @Description Source the frames were recorded from
+	Source string `json:"source"` // Source ID
+	// @Description Start of the exported time range
+	From time.Time `json:"from"` // Range start
+	// @Description End of the exported time range
+	To time.Time `json:"to"` // Range end
+	// @Description Output format (avi, zip or mjpeg)
+	Format string `json:"format"` // Output format
+	// @Description Job state (running, done, failed or cancelled)
+	Status string `json:"status"` // Job state
+	// @Description Fraction of frames written, from 0 to 1
+	Progress float64 `json:"progress"` // Completion
+	// @Description Number of frames in the range
+	Frames int `json:"frames"` // Frame count
+	// @Description Size of the output file in bytes
+	Size int64 `json:"size,omitempty"` // Output size
+	// @Description Reason the export failed
+	Error string `json:"error,omitempty"` // Failure reason
+	// @Description When the export was requested
+	CreatedAt time.Time `json:"created_at"` // Request time
+	// @Description When the export finished
+	FinishedAt *time.Time `json:"finished_at,omitempty"` // Completion time
+}
+
+// Timeline describes the footage of a source within a time range
+// @Description Recorded footage and event markers of a source
+type Timeline struct {
+	// @Description Source the footage was recorded from
+	Source string `json:"source"` // Source ID
+	// @Description Start of the requested time range
+	From time.Time `json:"from"` // Range start
+	// @Description End of the requested time range
+	To time.Time `json:"to"` // Range end
+	// @Description Periods with footage, oldest first
+	Ranges []TimeRange `json:"ranges"` // Covered periods
+	// @Description Periods without footage, oldest first
+	Gaps []TimeRange `json:"gaps"` // Uncovered periods
+	// @Description Events of the source, oldest first
+	Events []TimelineEvent `json:"events"` // Event markers
+	// @Description Whether further events in the range were left out
+	EventsTruncated bool `json:"events_truncated,omitempty"` // More events exist
+}
+
+// TimeRange is a period of time
+// @Description Time range
+type TimeRange struct {
+	// @Description Start of the period
+	From time.Time `json:"from"` // Period start
+	// @Description End of the period
+	To time.Time `json:"to"` // Period end
+}
+
+// TimelineEvent marks an event on a source's timeline
+// @Description Timeline event marker
+type TimelineEvent struct {
+	// @Description Event identifier
+	ID int64 `json:"id"` // Event ID at the time it was published
+	// @Description Event type (e.g. analytics.detection, tamper.alert)
+	Type string `json:"type"` // Dotted event type
+	// @Description When the event occurred
+	Time time.Time `json:"time"` // Event time
+	// @Description Event specific payload
+	Data interface{} `json:"data,omitempty"` // Optional payload
+}
+
+// TimelapseConfig configures periodic stills of a source assembled into a
+// time-lapse video
+// @Description Time-lapse job configuration
+type TimelapseConfig struct {
+	// @Description Time-lapse identifier, assigned when the job is created
+	ID string `json:"id"` // Job ID
+	// @Description Source stills are taken from
+	Source string `json:"source"` // Source ID
+	// @Description Human readable job name
+	Name string `json:"name,omitempty"` // Job name
+	// @Description Seconds between stills
+	IntervalSeconds int `json:"interval_seconds"` // Capture interval
+	// @Description Frame rate of assembled videos, defaults to 25
+	FPS float64 `json:"fps,omitempty"` // Output frame rate
+	// @Description Daily time (HH:MM) stills are assembled into a video, empty to only assemble on demand
+	AssembleAt string `json:"assemble_at,omitempty"` // Daily assembly time
+	// @Description IANA time zone of assemble_at, defaults to the server time zone
+	TimeZone string `json:"time_zone,omitempty"` // Assembly time zone
+	// @Description Keep stills after they were assembled
+	KeepStills bool `json:"keep_stills,omitempty"` // Whether stills survive assembly
+}
+
+// TimelapseInfo describes a time-lapse job and its progress
+// @Description Time-lapse job status
+type TimelapseInfo struct {
+	// @Description Job configuration
+	Config TimelapseConfig `json:"config"` // Job configuration
+	// @Description Stills waiting to be assembled
+	Stills int `json:"stills"` // Pending still count
+	// @Description Time of the most recent still
+	LastCapture *time.Time `json:"last_capture,omitempty"` // Last still
+	// @Description Reason the last capture or assembly failed
+	LastError string `json:"last_error,omitempty"` // Last failure
+	// @Description Time of the next scheduled assembly
+	NextAssembly *time.Time `json:"next_assembly,omitempty"` // Next assembly
+	// @Description Number of assembled videos
+	Videos int `json:"videos"` // Video count
+}
+
+// TimelapseVideo is an assembled time-lapse video
+// @Description Time-lapse video
+type TimelapseVideo struct {
+	// @Description Video identifier
+	ID string `json:"id"` // Video ID
+	// @Description Time-lapse job the video belongs to
+	Timelapse string `json:"timelapse"` // Job ID
+	// @Description Time of the first still
+	From time.Time `json:"from"` // First still
+	// @Description Time of the last still
+	To time.Time `json:"to"` // Last still
+	// @Description Number of stills in the video
+	Frames int `json:"frames"` // Frame count
+	// @Description Frame rate
+	FPS float64 `json:"fps"` // Frames per second
+	// @Description File size in bytes
+	Size int64 `json:"size"` // Size in bytes
+	// @Description When the video was assembled
+	CreatedAt time.Time `json:"created_at"` // Assembly time
+}
+
+// Schedule runs an action on a source at times given by a cron expression
+// @Description Scheduled action
+type Schedule struct {
+	// @Description Schedule identifier, assigned when the schedule is created
+	ID string `json:"id"` // Schedule ID
+	// @Description Human readable schedule name
+	Name string `json:"name,omitempty"` // Schedule name
+	// @Description Five field cron expression (minute hour day-of-month month day-of-week) or a macro such as @daily
+	Cron string `json:"cron"` // When the action runs
+	// @Description IANA time zone the expression is evaluated in, defaults to the server time zone
+	TimeZone string `json:"time_zone,omitempty"` // Evaluation time zone
+	// @Description Source the action targets
+	Source string `json:"source"` // Source ID
+	// @Description Action (start_capture, stop_capture, start_recording, stop_recording, snapshot, set_pipeline)
+	Action string `json:"action"` // Action to run
+	// @Description Stages applied by set_pipeline
+	Pipeline []StageConfig `json:"pipeline,omitempty"` // New pipeline
+	// @Description Whether the schedule is suspended
+	Disabled bool `json:"disabled,omitempty"` // Suspended schedules do not run
+}
+
+// ScheduleInfo describes a schedule and when it runs
+// @Description Schedule status
+type ScheduleInfo struct {
+	// @Description Schedule definition
+	Schedule Schedule `json:"schedule"` // Schedule definition
+	// @Description Next time the action runs
+	NextRun *time.Time `json:"next_run,omitempty"` // Next run
+	// @Description Most recent execution
+	LastRun *ScheduleRun `json:"last_run,omitempty"` // Last execution
+}
+
+// ScheduleRun records one execution of a scheduled action
+// @Description Schedule execution
+type ScheduleRun struct {
+	// @Description Schedule that ran
+	Schedule string `json:"schedule"` // Schedule ID
+	// @Description Action that ran
+	Action string `json:"action"` // Action
+	// @Description Source the action targeted
+	Source string `json:"source"` // Source ID
+	// @Description When the action ran
+	Time time.Time `json:"time"` // Execution time
+	// @Description Whether the run was triggered manually
+	Manual bool `json:"manual,omitempty"` // Run on demand
+	// @Description Whether the action succeeded
+	Success bool `json:"success"` // Outcome
+	// @Description Reason the action failed
+	Error string `json:"error,omitempty"` // Failure reason
+	// @Description File name of the still taken by a snapshot action
+	Snapshot string `json:"snapshot,omitempty"` // Stored snapshot
 }